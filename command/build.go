@@ -6,10 +6,13 @@ package command
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
 	"math"
+	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"sync"
@@ -19,6 +22,7 @@ import (
 	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
 	"github.com/hashicorp/packer/internal/hcp/registry"
 	"github.com/hashicorp/packer/packer"
+	"github.com/hashicorp/packer/version"
 	"golang.org/x/sync/semaphore"
 
 	"github.com/hako/durafmt"
@@ -60,9 +64,35 @@ func (c *BuildCommand) ParseArgs(args []string) (*BuildArgs, int) {
 		return &cfg, 1
 	}
 	cfg.Path = args[0]
+
+	if cfg.Resume || cfg.JournalDir != "" {
+		cfg.Journal = true
+	}
+	if cfg.Journal {
+		if cfg.JournalDir == "" {
+			cfg.JournalDir = journalDir(cfg.Path)
+		}
+		// Keep the machine alive when a provisioner fails so that the build
+		// can be resumed against it.
+		if cfg.OnError == "" {
+			cfg.OnError = "abort"
+		}
+	}
 	return &cfg, 0
 }
 
+// journalDir returns the directory where the build journal is written by
+// default: next to the template.
+func journalDir(path string) string {
+	if path == "-" {
+		return "."
+	}
+	if isDir, _ := isDir(path); isDir {
+		return path
+	}
+	return filepath.Dir(path)
+}
+
 func writeDiags(ui packersdk.Ui, files map[string]*hcl.File, diags hcl.Diagnostics) int {
 	// write HCL errors/diagnostics if any.
 	b := bytes.NewBuffer(nil)
@@ -82,6 +112,10 @@ func writeDiags(ui packersdk.Ui, files map[string]*hcl.File, diags hcl.Diagnosti
 }
 
 func (c *BuildCommand) RunContext(buildCtx context.Context, cla *BuildArgs) int {
+	// Each run is a trace, of which the builds are spans.
+	buildCtx, span := packer.StartTrace(buildCtx, "build", cla.Path)
+	defer span.End()
+
 	packerStarter, ret := c.GetConfig(&cla.MetaArgs)
 	if ret != 0 {
 		return ret
@@ -99,6 +133,10 @@ func (c *BuildCommand) RunContext(buildCtx context.Context, cla *BuildArgs) int
 		return ret
 	}
 
+	if cla.Plan {
+		return c.printPlan(packerStarter, cla)
+	}
+
 	hcpRegistry, diags := registry.New(packerStarter, c.Ui)
 	ret = writeDiags(c.Ui, nil, diags)
 	if ret != 0 {
@@ -119,11 +157,12 @@ func (c *BuildCommand) RunContext(buildCtx context.Context, cla *BuildArgs) int
 	}
 
 	builds, diags := packerStarter.GetBuilds(packer.GetBuildsOptions{
-		Only:    cla.Only,
-		Except:  cla.Except,
-		Debug:   cla.Debug,
-		Force:   cla.Force,
-		OnError: cla.OnError,
+		Only:                 cla.Only,
+		Except:               cla.Except,
+		Debug:                cla.Debug,
+		Force:                cla.Force,
+		OnError:              cla.OnError,
+		DeferDependentBuilds: true,
 	})
 
 	// here, something could have gone wrong but we still want to run valid
@@ -133,6 +172,22 @@ func (c *BuildCommand) RunContext(buildCtx context.Context, cla *BuildArgs) int
 		return ret
 	}
 
+	if cla.Journal {
+		journal, err := packer.LoadBuildJournal(cla.JournalDir)
+		if err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+		log.Printf("Build journal: %s", journal.Path())
+		for _, b := range builds {
+			if coreBuild, ok := b.(*packer.CoreBuild); ok {
+				coreBuild.SetJournal(journal, cla.Resume)
+			}
+		}
+	}
+
+	setBuildTimeouts(builds, cla.Timeout)
+
 	if cla.Debug {
 		c.Ui.Say("Debug mode enabled. Builds will not be parallelized.")
 	}
@@ -148,9 +203,12 @@ func (c *BuildCommand) RunContext(buildCtx context.Context, cla *BuildArgs) int
 	buildUis := make(map[packersdk.Build]packersdk.Ui)
 	for i := range builds {
 		ui := c.Ui
+		_, machineReadable := c.Ui.(*packer.MachineReadableUi)
+		_, jsonLines := c.Ui.(*packer.JSONLinesUi)
 		if cla.Color {
-			// Only set up UI colors if -machine-readable isn't set.
-			if _, ok := c.Ui.(*packer.MachineReadableUi); !ok {
+			// Only set up UI colors if -machine-readable or -output=jsonl
+			// aren't set.
+			if !machineReadable && !jsonLines {
 				ui = &packer.ColoredUi{
 					Color: colors[i%len(colors)],
 					Ui:    ui,
@@ -162,8 +220,9 @@ func (c *BuildCommand) RunContext(buildCtx context.Context, cla *BuildArgs) int
 				}
 			}
 		}
-		// Now add timestamps if requested
-		if cla.TimestampUi {
+		// Now add timestamps if requested, JSON events are already
+		// timestamped.
+		if cla.TimestampUi && !jsonLines {
 			ui = &packer.TimestampedUi{
 				Ui: ui,
 			}
@@ -201,6 +260,23 @@ func (c *BuildCommand) RunContext(buildCtx context.Context, cla *BuildArgs) int
 		sync.RWMutex
 		m map[string]error
 	}{m: make(map[string]error)}
+	// Builds that depended on a build that did not succeed, with the reason
+	var skipped = struct {
+		sync.RWMutex
+		m map[string]error
+	}{m: make(map[string]error)}
+	// Builds that timed out
+	var timedOut = struct {
+		sync.RWMutex
+		m map[string]*packer.BuildTimeoutError
+	}{m: make(map[string]*packer.BuildTimeoutError)}
+	// Provisioners that were retried, by build
+	var retried = struct {
+		sync.RWMutex
+		m map[string][]packer.ProvisionerRetries
+	}{m: make(map[string][]packer.ProvisionerRetries)}
+	graph := newBuildGraph(builds)
+	builds = sortBuilds(builds)
 	limitParallel := semaphore.NewWeighted(cla.ParallelBuilds)
 	for i := range builds {
 		if err := buildCtx.Err(); err != nil {
@@ -211,23 +287,61 @@ func (c *BuildCommand) RunContext(buildCtx context.Context, cla *BuildArgs) int
 		b := builds[i]
 		name := b.Name()
 		ui := buildUis[b]
-		if err := limitParallel.Acquire(buildCtx, 1); err != nil {
-			ui.Error(fmt.Sprintf("Build '%s' failed to acquire semaphore: %s", name, err))
-			errs.Lock()
-			errs.m[name] = err
-			errs.Unlock()
-			break
+		deps := dependsOn(b)
+		// Builds with dependencies acquire the semaphore once their
+		// dependencies are done, so that they don't hold a slot while
+		// waiting.
+		if len(deps) == 0 {
+			if err := limitParallel.Acquire(buildCtx, 1); err != nil {
+				ui.Error(fmt.Sprintf("Build '%s' failed to acquire semaphore: %s", name, err))
+				errs.Lock()
+				errs.m[name] = err
+				errs.Unlock()
+				break
+			}
 		}
 		// Increment the waitgroup so we wait for this item to finish properly
 		wg.Add(1)
 
 		// Run the build in a goroutine
 		go func() {
+			defer wg.Done()
+			defer graph.finish(name)
+
+			if len(deps) > 0 {
+				depArtifacts, err := graph.wait(buildCtx, deps)
+				if err != nil {
+					ui.Error(fmt.Sprintf("Build '%s' skipped: %s", name, err))
+					skipped.Lock()
+					skipped.m[name] = err
+					skipped.Unlock()
+					return
+				}
+				if err := limitParallel.Acquire(buildCtx, 1); err != nil {
+					ui.Error(fmt.Sprintf("Build '%s' failed to acquire semaphore: %s", name, err))
+					errs.Lock()
+					errs.m[name] = err
+					errs.Unlock()
+					return
+				}
+				if cb, ok := b.(*packer.CoreBuild); ok && cb.ResolveDependencies != nil {
+					if err := cb.ResolveDependencies(depArtifacts); err != nil {
+						limitParallel.Release(1)
+						ui.Error(fmt.Sprintf("Build '%s' errored: %s", name, err))
+						errs.Lock()
+						errs.m[name] = err
+						errs.Unlock()
+						return
+					}
+					// Resolving the dependencies configured the build, with
+					// the timeout of its source.
+					setBuildTimeouts([]packersdk.Build{cb}, cla.Timeout)
+				}
+			}
+
 			// Get the start of the build
 			buildStart := time.Now()
 
-			defer wg.Done()
-
 			defer limitParallel.Release(1)
 
 			err := hcpRegistry.StartBuild(buildCtx, b)
@@ -236,6 +350,9 @@ func (c *BuildCommand) RunContext(buildCtx context.Context, cla *BuildArgs) int
 				// If the build is already done, we skip without a warning
 				if errors.As(err, &registry.ErrBuildAlreadyDone{}) {
 					ui.Say(fmt.Sprintf("skipping already done build %q", name))
+					// The builds depending on it can still run, they get no
+					// artifacts from it.
+					graph.succeed(name, nil)
 					return
 				}
 				writeDiags(c.Ui, nil, hcl.Diagnostics{
@@ -253,6 +370,14 @@ func (c *BuildCommand) RunContext(buildCtx context.Context, cla *BuildArgs) int
 			log.Printf("Starting build run: %s", name)
 			runArtifacts, err := b.Run(buildCtx, ui)
 
+			if cb, ok := b.(*packer.CoreBuild); ok {
+				if retries := cb.ProvisionerRetries(); len(retries) > 0 {
+					retried.Lock()
+					retried.m[name] = retries
+					retried.Unlock()
+				}
+			}
+
 			// Get the duration of the build and parse it
 			buildEnd := time.Now()
 			buildDuration := buildEnd.Sub(buildStart)
@@ -275,13 +400,20 @@ func (c *BuildCommand) RunContext(buildCtx context.Context, cla *BuildArgs) int
 				})
 			}
 
-			if err != nil {
+			var timeoutErr *packer.BuildTimeoutError
+			if errors.As(err, &timeoutErr) {
+				ui.Error(fmt.Sprintf("Build '%s' %s", name, timeoutErr))
+				timedOut.Lock()
+				timedOut.m[name] = timeoutErr
+				timedOut.Unlock()
+			} else if err != nil {
 				ui.Error(fmt.Sprintf("Build '%s' errored after %s: %s", name, fmtBuildDuration, err))
 				errs.Lock()
 				errs.m[name] = err
 				errs.Unlock()
 			} else {
 				ui.Say(fmt.Sprintf("Build '%s' finished after %s.", name, fmtBuildDuration))
+				graph.succeed(name, runArtifacts)
 				if runArtifacts != nil {
 					artifacts.Lock()
 					artifacts.m[name] = runArtifacts
@@ -312,6 +444,16 @@ func (c *BuildCommand) RunContext(buildCtx context.Context, cla *BuildArgs) int
 	fmtBuildCommandDuration := durafmt.Parse(buildCommandDuration).LimitFirstN(2)
 	c.Ui.Say(fmt.Sprintf("\n==> Wait completed after %s", fmtBuildCommandDuration))
 
+	if cla.Report != "" {
+		reports := buildReports(builds, errs.m, skipped.m)
+		if err := writeBuildReport(cla.Report, reports); err != nil {
+			c.Ui.Error(fmt.Sprintf("Failed to write the build report: %s", err))
+			ret = 1
+		} else {
+			c.Ui.Say(fmt.Sprintf("Build report written to %s", cla.Report))
+		}
+	}
+
 	if err := buildCtx.Err(); err != nil {
 		c.Ui.Say("Cleanly cancelled builds after being interrupted.")
 		return 1
@@ -334,6 +476,57 @@ func (c *BuildCommand) RunContext(buildCtx context.Context, cla *BuildArgs) int
 		}
 	}
 
+	if len(timedOut.m) > 0 {
+		c.Ui.Error("\n==> Some builds timed out and were cancelled:")
+		for name, err := range timedOut.m {
+			ui := &packer.TargetedUI{
+				Target: name,
+				Ui:     c.Ui,
+			}
+
+			ui.Machine("timed-out", err.Timeout.String())
+
+			c.Ui.Error(fmt.Sprintf("--> %s: %s", name, err))
+		}
+	}
+
+	if len(skipped.m) > 0 {
+		c.Ui.Error("\n==> Some builds were skipped because a build they depend on did not succeed:")
+		for name, err := range skipped.m {
+			ui := &packer.TargetedUI{
+				Target: name,
+				Ui:     c.Ui,
+			}
+
+			ui.Machine("skipped", err.Error())
+
+			c.Ui.Error(fmt.Sprintf("--> %s: %s", name, err))
+		}
+	}
+
+	if len(retried.m) > 0 {
+		c.Ui.Say("\n==> Some provisioners failed and were retried:")
+		for name, retries := range retried.m {
+			ui := &packer.TargetedUI{
+				Target: name,
+				Ui:     c.Ui,
+			}
+
+			for _, r := range retries {
+				provisioner := r.Type
+				if r.Name != "" && r.Name != r.Type {
+					provisioner = fmt.Sprintf("%s (type %s)", r.Name, r.Type)
+				}
+				lastErr := r.Errors[len(r.Errors)-1]
+
+				ui.Machine("provisioner-retries", r.Type, strconv.Itoa(len(r.Errors)))
+
+				c.Ui.Say(fmt.Sprintf("--> %s: %s retried %d time(s), last retried error: %s",
+					name, provisioner, len(r.Errors), lastErr))
+			}
+		}
+	}
+
 	if len(artifacts.m) > 0 {
 		c.Ui.Say("\n==> Builds finished. The artifacts of successful builds are:")
 		for name, buildArtifacts := range artifacts.m {
@@ -384,14 +577,124 @@ func (c *BuildCommand) RunContext(buildCtx context.Context, cla *BuildArgs) int
 		c.Ui.Say("\n==> Builds finished but no artifacts were created.")
 	}
 
-	if len(errs.m) > 0 {
-		// If any errors occurred, exit with a non-zero exit status
+	if len(errs.m) > 0 || len(timedOut.m) > 0 || len(skipped.m) > 0 {
+		// If any errors occurred, or builds timed out or were skipped, exit
+		// with a non-zero exit status
 		ret = 1
 	}
 
 	return ret
 }
 
+// setBuildTimeouts sets timeout on the builds that don't have a shorter
+// timeout.
+func setBuildTimeouts(builds []packersdk.Build, timeout time.Duration) {
+	if timeout <= 0 {
+		return
+	}
+	for _, b := range builds {
+		if cb, ok := b.(*packer.CoreBuild); ok && (cb.Timeout == 0 || timeout < cb.Timeout) {
+			cb.Timeout = timeout
+		}
+	}
+}
+
+// buildReportDocument is the JSON document written by the -report option.
+type buildReportDocument struct {
+	PackerVersion string                `json:"packer_version"`
+	Builds        []*packer.BuildReport `json:"builds"`
+}
+
+// buildReports returns the reports of builds, in order. Builds that could not
+// run are reported with the error that stopped them, and builds that were
+// never started are left out.
+func buildReports(builds []packersdk.Build, errs, skipped map[string]error) []*packer.BuildReport {
+	reports := []*packer.BuildReport{}
+	for _, b := range builds {
+		name := b.Name()
+		var report *packer.BuildReport
+		if cb, ok := b.(*packer.CoreBuild); ok {
+			report = cb.Report()
+		}
+		switch {
+		case report != nil:
+		case skipped[name] != nil:
+			report = packer.NewBuildReport(name, packer.OutcomeSkipped, skipped[name])
+		case errs[name] != nil:
+			report = packer.NewBuildReport(name, packer.OutcomeFailed, errs[name])
+		default:
+			continue
+		}
+		reports = append(reports, report)
+	}
+	return reports
+}
+
+// writeBuildReport writes the reports of the builds to path, as JSON.
+func writeBuildReport(path string, reports []*packer.BuildReport) error {
+	out, err := json.MarshalIndent(buildReportDocument{
+		PackerVersion: version.FormattedVersion(),
+		Builds:        reports,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(out, '\n'), 0644)
+}
+
+// printPlan starts the builds of the config and prints what each of them
+// would do, without running any of them.
+func (c *BuildCommand) printPlan(packerStarter packer.Handler, cla *BuildArgs) int {
+	builds, diags := packerStarter.GetBuilds(packer.GetBuildsOptions{
+		Only:    cla.Only,
+		Except:  cla.Except,
+		Debug:   cla.Debug,
+		Force:   cla.Force,
+		OnError: cla.OnError,
+	})
+	ret := writeDiags(c.Ui, nil, diags)
+	if ret != 0 {
+		return ret
+	}
+	setBuildTimeouts(builds, cla.Timeout)
+
+	// The builds are planned in the order they would run in. Those depending
+	// on other builds are planned with the values of these builds unknown.
+	builds = sortBuilds(builds)
+
+	plans := []packer.BuildPlan{}
+	for _, b := range builds {
+		coreBuild, ok := b.(*packer.CoreBuild)
+		if !ok {
+			c.Ui.Error(fmt.Sprintf("Cannot compute the plan of build %q", b.Name()))
+			return 1
+		}
+		plans = append(plans, coreBuild.Plan())
+	}
+
+	if cla.PlanFormat == "json" {
+		out, err := json.MarshalIndent(plans, "", "  ")
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Failed to encode build plan: %s", err))
+			return 1
+		}
+		c.Ui.Say(string(out))
+		return 0
+	}
+
+	if len(plans) == 0 {
+		c.Ui.Say("No builds to run.")
+		return 0
+	}
+	for _, plan := range plans {
+		c.Ui.Say(plan.String())
+	}
+	return 0
+}
+
 func (*BuildCommand) Help() string {
 	helpText := `
 Usage: packer build [options] TEMPLATE
@@ -406,9 +709,17 @@ Options:
   -except=foo,bar,baz           Run all builds and post-processors other than these.
   -only=foo,bar,baz             Build only the specified builds.
   -force                        Force a build to continue if artifacts exist, deletes existing artifacts.
+  -journal                      Record the provisioners that completed for each build, so that a failed build can be resumed.
+  -journal-dir=path             Directory of the build journal, implies -journal. (Default: the template directory)
   -machine-readable             Produce machine-readable output.
+  -output=jsonl                 Output one JSON encoded event per line.
   -on-error=[cleanup|abort|ask|run-cleanup-provisioner] If the build fails do: clean up (default), abort, ask, or run-cleanup-provisioner.
   -parallel-builds=1            Number of builds to run in parallel. 1 disables parallelization. 0 means no limit (Default: 0)
+  -plan                         Print what each build would do, without running anything.
+  -plan-format=[text|json]      Output format of the -plan option. (Default: text)
+  -report=path                  Write a JSON report of the builds, their provisioners, post-processors and artifacts to path.
+  -resume                       Resume failed builds from their journal, only running the remaining provisioners and the post-processors. Implies -journal.
+  -timeout=duration             Cancel each build that runs for longer than duration, like 2h. Sources can set a shorter timeout.
   -timestamp-ui                 Enable prefixing of each ui output with an RFC3339 timestamp.
   -var 'key=value'              Variable for templates, can be used multiple times.
   -var-file=path                JSON or HCL2 file containing user variables, can be used multiple times.
@@ -432,10 +743,18 @@ func (*BuildCommand) AutocompleteFlags() complete.Flags {
 		"-debug":            complete.PredictNothing,
 		"-except":           complete.PredictNothing,
 		"-only":             complete.PredictNothing,
+		"-output":           complete.PredictSet("jsonl"),
 		"-force":            complete.PredictNothing,
+		"-journal":          complete.PredictNothing,
+		"-journal-dir":      complete.PredictDirs("*"),
 		"-machine-readable": complete.PredictNothing,
 		"-on-error":         complete.PredictNothing,
 		"-parallel":         complete.PredictNothing,
+		"-plan":             complete.PredictNothing,
+		"-plan-format":      complete.PredictNothing,
+		"-report":           complete.PredictFiles("*.json"),
+		"-resume":           complete.PredictNothing,
+		"-timeout":          complete.PredictNothing,
 		"-timestamp-ui":     complete.PredictNothing,
 		"-var":              complete.PredictNothing,
 		"-var-file":         complete.PredictNothing,
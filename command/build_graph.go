@@ -0,0 +1,142 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package command
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer/packer"
+)
+
+// buildGraph tracks the builds being run so that a build depending on other
+// builds through `depends_on` is only started once they all succeeded.
+type buildGraph struct {
+	// blocks maps the name of a build block to the names of its builds that
+	// are part of this run.
+	blocks map[string][]string
+	done   map[string]chan struct{}
+
+	l         sync.Mutex
+	succeeded map[string][]packersdk.Artifact
+}
+
+func newBuildGraph(builds []packersdk.Build) *buildGraph {
+	g := &buildGraph{
+		blocks:    map[string][]string{},
+		done:      map[string]chan struct{}{},
+		succeeded: map[string][]packersdk.Artifact{},
+	}
+	for _, b := range builds {
+		if cb, ok := b.(*packer.CoreBuild); ok && cb.BuildName != "" {
+			g.blocks[cb.BuildName] = append(g.blocks[cb.BuildName], b.Name())
+		}
+		g.done[b.Name()] = make(chan struct{})
+	}
+	return g
+}
+
+// dependsOn returns the names of the build blocks b depends on.
+func dependsOn(b packersdk.Build) []string {
+	cb, ok := b.(*packer.CoreBuild)
+	if !ok {
+		return nil
+	}
+	return cb.DependsOn
+}
+
+// sortBuilds orders builds so that builds come after the builds they depend
+// on, otherwise keeping their order.
+func sortBuilds(builds []packersdk.Build) []packersdk.Build {
+	remaining := map[string]int{}
+	for _, b := range builds {
+		if cb, ok := b.(*packer.CoreBuild); ok && cb.BuildName != "" {
+			remaining[cb.BuildName]++
+		}
+	}
+
+	sorted := make([]packersdk.Build, 0, len(builds))
+	placed := make([]bool, len(builds))
+	for len(sorted) < len(builds) {
+		progress := false
+		for i, b := range builds {
+			if placed[i] {
+				continue
+			}
+			ready := true
+			for _, dep := range dependsOn(b) {
+				if remaining[dep] > 0 {
+					ready = false
+					break
+				}
+			}
+			if !ready {
+				continue
+			}
+			placed[i] = true
+			progress = true
+			sorted = append(sorted, b)
+			if cb, ok := b.(*packer.CoreBuild); ok && cb.BuildName != "" {
+				remaining[cb.BuildName]--
+			}
+			// Start over so that the original order is kept as much as
+			// possible.
+			break
+		}
+		if !progress {
+			// Cycles are rejected when the template is parsed, keep the
+			// remaining builds in order if one gets here.
+			for i, b := range builds {
+				if !placed[i] {
+					placed[i] = true
+					sorted = append(sorted, b)
+				}
+			}
+		}
+	}
+	return sorted
+}
+
+// succeed records the artifacts of a build that succeeded.
+func (g *buildGraph) succeed(name string, artifacts []packersdk.Artifact) {
+	g.l.Lock()
+	defer g.l.Unlock()
+	g.succeeded[name] = artifacts
+}
+
+// finish signals that the build is over, whether it succeeded or not.
+func (g *buildGraph) finish(name string) {
+	close(g.done[name])
+}
+
+// wait waits for the builds of the given build blocks to finish and returns
+// their artifacts, indexed by build block name. An error explaining why the
+// dependant build can't run is returned if one of them did not succeed.
+func (g *buildGraph) wait(ctx context.Context, blocks []string) (map[string][]packersdk.Artifact, error) {
+	artifacts := map[string][]packersdk.Artifact{}
+	for _, block := range blocks {
+		names, ok := g.blocks[block]
+		if !ok {
+			return nil, fmt.Errorf("build %q it depends on is not part of this run", block)
+		}
+		for _, name := range names {
+			select {
+			case <-g.done[name]:
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+
+			g.l.Lock()
+			buildArtifacts, ok := g.succeeded[name]
+			g.l.Unlock()
+			if !ok {
+				return nil, fmt.Errorf("build '%s' it depends on did not succeed", name)
+			}
+			artifacts[block] = append(artifacts[block], buildArtifacts...)
+		}
+	}
+	return artifacts, nil
+}
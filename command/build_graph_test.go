@@ -0,0 +1,66 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package command
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestBuildCommand_DependsOn(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("This test uses posix shell commands")
+	}
+
+	defer cleanup("depends-on.txt")
+
+	run(t, []string{testFixture("depends-on")}, 0)
+	fileCheck{
+		expectedContent: map[string]string{"depends-on.txt": "base\nNull\n"},
+	}.verify(t, "")
+}
+
+func TestBuildCommand_DependsOnFailure(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("This test uses posix shell commands")
+	}
+
+	defer cleanup("depends-on.txt")
+
+	c := &BuildCommand{
+		Meta: TestMetaFile(t),
+	}
+	args := []string{"-var", "fail=true", testFixture("depends-on")}
+	if code := c.Run(args); code != 1 {
+		fatalCommand(t, c.Meta)
+	}
+	fileCheck{
+		notExpected: []string{"depends-on.txt"},
+	}.verify(t, "")
+
+	_, stderr := GetStdoutAndErrFromTestMeta(t, c.Meta)
+	if !strings.Contains(stderr, "--> derived.null.derived: build 'base.null.base' it depends on did not succeed") {
+		t.Errorf("expected derived build to be reported as skipped, got:\n%s", stderr)
+	}
+}
+
+func TestBuildCommand_DependsOnTimeout(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("This test uses posix shell commands")
+	}
+
+	c := &BuildCommand{
+		Meta: TestMetaFile(t),
+	}
+	args := []string{"-timeout=500ms", testFixture("depends-on-timeout")}
+	if code := c.Run(args); code != 1 {
+		t.Fatalf("expected the timed out build to fail the command, got exit code %d", code)
+	}
+
+	_, stderr := GetStdoutAndErrFromTestMeta(t, c.Meta)
+	if !strings.Contains(stderr, "Build 'derived.null.derived' timed out after 500ms") {
+		t.Errorf("expected the -timeout to apply to the dependent build, got:\n%s", stderr)
+	}
+}
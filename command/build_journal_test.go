@@ -0,0 +1,59 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package command
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/hashicorp/packer/packer"
+)
+
+func TestBuildCommand_Resume(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("This test uses posix shell commands")
+	}
+
+	journalDir := t.TempDir()
+	defer cleanup("journal-first.txt", "journal-ok.txt", "journal-last.txt")
+
+	args := []string{
+		"-journal-dir=" + journalDir,
+		testFixture("journal", "journal.pkr.hcl"),
+	}
+
+	// The second provisioner fails, the first one is journaled.
+	run(t, args, 1)
+	fileCheck{
+		expectedContent: map[string]string{"journal-first.txt": "first\n"},
+		notExpected:     []string{"journal-last.txt"},
+	}.verify(t, "")
+
+	journal, err := packer.LoadBuildJournal(journalDir)
+	if err != nil {
+		t.Fatalf("failed to load journal: %s", err)
+	}
+	if !journal.Completed("null.resume", 0) || journal.Completed("null.resume", 1) {
+		t.Fatalf("unexpected journal: %#v", journal.Entry("null.resume"))
+	}
+
+	if err := os.WriteFile("journal-ok.txt", []byte("ok"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Resuming skips the first provisioner.
+	run(t, append([]string{"-resume"}, args...), 0)
+	fileCheck{
+		expectedContent: map[string]string{
+			"journal-first.txt": "first\n",
+			"journal-last.txt":  "last\n",
+		},
+	}.verify(t, "")
+
+	if _, err := os.Stat(filepath.Join(journalDir, packer.JournalFileName)); err == nil {
+		t.Errorf("journal should be removed once all builds succeeded")
+	}
+}
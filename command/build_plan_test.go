@@ -0,0 +1,229 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package command
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/packer/packer"
+)
+
+func TestBuildCommand_Plan(t *testing.T) {
+	c := &BuildCommand{
+		Meta: TestMetaFile(t),
+	}
+
+	args := []string{
+		"-plan",
+		testFixture("plan", "plan.pkr.hcl"),
+	}
+
+	defer cleanup("plan-manifest.json")
+
+	if code := c.Run(args); code != 0 {
+		fatalCommand(t, c.Meta)
+	}
+
+	out, _ := GetStdoutAndErrFromTestMeta(t, c.Meta)
+	for _, expected := range []string{
+		"null.packer:",
+		"builder: null",
+		"0: setup (type shell-local) [pause_before=5s, max_retries=3]",
+		"shell-local [timeout=10m0s]",
+		"error-cleanup-provisioner: shell-local",
+		"notify (type shell-local)",
+		"null.other:",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("expected plan to contain %q, got:\n%s", expected, out)
+		}
+	}
+
+	if fileExists("plan-manifest.json") {
+		t.Errorf("a plan should not run any post-processor")
+	}
+}
+
+func TestBuildCommand_PlanParallelProvisioners(t *testing.T) {
+	c := &BuildCommand{
+		Meta: TestMetaFile(t),
+	}
+
+	args := []string{
+		"-plan",
+		testFixture("hcl", "provisioners-parallel", "parallel.pkr.hcl"),
+	}
+
+	if code := c.Run(args); code != 0 {
+		fatalCommand(t, c.Meta)
+	}
+
+	out, _ := GetStdoutAndErrFromTestMeta(t, c.Meta)
+	for _, expected := range []string{
+		"0: downloads (type provisioners) [parallel]",
+		"- first (type shell-local)",
+		"- second (type shell-local)",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("expected plan to contain %q, got:\n%s", expected, out)
+		}
+	}
+}
+
+func TestBuildCommand_PlanConditions(t *testing.T) {
+	c := &BuildCommand{
+		Meta: TestMetaFile(t),
+	}
+
+	args := []string{
+		"-plan",
+		testFixture("hcl", "provisioner-when", "when.pkr.hcl"),
+	}
+
+	if code := c.Run(args); code != 0 {
+		fatalCommand(t, c.Meta)
+	}
+
+	out, _ := GetStdoutAndErrFromTestMeta(t, c.Meta)
+	for _, expected := range []string{
+		"0: gpu-drivers (type shell-local) [when=var.install_gpu_drivers, skipped (condition false)]",
+		"1: always (type shell-local)\n",
+		// the ID is only known once the builder ran
+		"2: on-host (type shell-local) [when=build.ID != \"\"]\n",
+		"gpu-check (type shell-local) [when=var.install_gpu_drivers, skipped (condition false)]",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("expected plan to contain %q, got:\n%s", expected, out)
+		}
+	}
+}
+
+func TestBuildCommand_PlanTest(t *testing.T) {
+	c := &BuildCommand{
+		Meta: TestMetaFile(t),
+	}
+
+	args := []string{
+		"-plan",
+		testFixture("hcl", "test-block", "test.pkr.hcl"),
+	}
+
+	if code := c.Run(args); code != 0 {
+		fatalCommand(t, c.Meta)
+	}
+
+	out, _ := GetStdoutAndErrFromTestMeta(t, c.Meta)
+	if !strings.Contains(out, "test assertions: uptime\n") {
+		t.Errorf("expected plan to contain the test assertions, got:\n%s", out)
+	}
+	if fileExists("test-block-report.xml") {
+		t.Errorf("a plan should not run any test")
+	}
+}
+
+func TestBuildCommand_PlanTimeout(t *testing.T) {
+	c := &BuildCommand{
+		Meta: TestMetaFile(t),
+	}
+
+	args := []string{
+		"-plan",
+		"-timeout=1h",
+		testFixture("hcl", "build-timeout", "timeout.pkr.hcl"),
+	}
+
+	if code := c.Run(args); code != 0 {
+		fatalCommand(t, c.Meta)
+	}
+
+	out, _ := GetStdoutAndErrFromTestMeta(t, c.Meta)
+	if !strings.Contains(out, "  timeout: 10m0s\n") {
+		t.Errorf("expected plan to contain the shorter timeout, got:\n%s", out)
+	}
+}
+
+func TestBuildCommand_PlanJSON(t *testing.T) {
+	c := &BuildCommand{
+		Meta: TestMetaFile(t),
+	}
+
+	args := []string{
+		"-plan",
+		"-plan-format=json",
+		"-only=null.other",
+		testFixture("plan", "plan.pkr.hcl"),
+	}
+
+	if code := c.Run(args); code != 0 {
+		fatalCommand(t, c.Meta)
+	}
+
+	out, _ := GetStdoutAndErrFromTestMeta(t, c.Meta)
+	var plans []packer.BuildPlan
+	if err := json.Unmarshal([]byte(out), &plans); err != nil {
+		t.Fatalf("failed to decode plan %q: %s", out, err)
+	}
+
+	expected := []packer.BuildPlan{
+		{
+			Name:    "null.other",
+			Builder: "null",
+			Provisioners: []packer.ProvisionerPlan{
+				{Type: "shell-local", Timeout: "10m0s"},
+			},
+			ErrorCleanupProvisioner: &packer.ProvisionerPlan{Type: "shell-local"},
+			PostProcessors: [][]packer.PostProcessorPlan{
+				{{Type: "manifest"}},
+			},
+		},
+	}
+	if diff := cmp.Diff(expected, plans); diff != "" {
+		t.Errorf("unexpected plan: %s", diff)
+	}
+}
+
+func TestBuildCommand_PlanDependsOn(t *testing.T) {
+	c := &BuildCommand{
+		Meta: TestMetaFile(t),
+	}
+
+	args := []string{
+		"-plan",
+		testFixture("depends-on", "depends-on.pkr.hcl"),
+	}
+
+	if code := c.Run(args); code != 0 {
+		fatalCommand(t, c.Meta)
+	}
+
+	// The derived build is declared first, but planned after the build it
+	// depends on, as it would run.
+	out, _ := GetStdoutAndErrFromTestMeta(t, c.Meta)
+	expected := `base.null.base:
+  builder: null
+  provisioners:
+    0: shell-local
+  post-processors:
+    <no post-processor>
+
+derived.null.derived:
+  builder: null
+  depends on: base
+  unknown values: build.base
+  provisioners:
+    0: shell-local
+  post-processors:
+    <no post-processor>
+
+`
+	if diff := cmp.Diff(expected, out); diff != "" {
+		t.Errorf("unexpected plan: %s", diff)
+	}
+	if fileExists("depends-on.txt") {
+		t.Errorf("a plan should not run any build")
+	}
+}
@@ -4,6 +4,7 @@
 package command
 
 import (
+	"encoding/json"
 	"fmt"
 	"math"
 	"os"
@@ -15,6 +16,7 @@ import (
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/packer/packer"
 )
 
 var (
@@ -518,6 +520,26 @@ func Test_build_output(t *testing.T) {
 			[]string{"null.example1: yes overridden", "null.example2: not overridden"},
 			[]string{"null.example2: yes overridden", "null.example1: not overridden"},
 			"posix"},
+		{[]string{"build", "--color=false", testFixture("hcl", "provisioners-parallel", "parallel.pkr.hcl")},
+			nil,
+			[]string{"null.example:     first: downloading first", "null.example:     second: downloading second"},
+			[]string{},
+			"posix"},
+		{[]string{"build", "--color=false", testFixture("hcl", "provisioner-when", "when.pkr.hcl")},
+			nil,
+			[]string{
+				"Provisioner gpu-drivers skipped (condition false): var.install_gpu_drivers",
+				"null.example: always running",
+				"null.example: running with an ID",
+				"Post-processor gpu-check skipped (condition false): var.install_gpu_drivers",
+			},
+			[]string{"installing gpu drivers", "checking gpu drivers"},
+			"posix"},
+		{[]string{"build", "--color=false", "-var", "install_gpu_drivers=true", testFixture("hcl", "provisioner-when", "when.pkr.hcl")},
+			nil,
+			[]string{"null.example: installing gpu drivers", "checking gpu drivers"},
+			[]string{"skipped (condition false)"},
+			"posix"},
 		{[]string{"build", "--color=false", testFixture("provisioners", "provisioner-override.json")},
 			nil,
 			[]string{"example1: yes overridden", "example2: not overridden"},
@@ -550,6 +572,144 @@ func Test_build_output(t *testing.T) {
 	}
 }
 
+func TestBuild_provisionerRetries(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fixture uses a posix shell")
+	}
+
+	marker := filepath.Join(t.TempDir(), "marker")
+	p := helperCommand(t, "build", "--color=false", "-var", "marker="+marker,
+		testFixture("hcl", "provisioner-retry", "retry.pkr.hcl"))
+	bs, err := p.Output()
+	if err != nil {
+		t.Fatalf("%v: %s", err, bs)
+	}
+	for _, expected := range []string{
+		"==> Some provisioners failed and were retried:",
+		"--> null.example: flaky (type shell-local) retried 1 time(s), last retried error: Script exited with non-zero exit status: 3",
+	} {
+		if !strings.Contains(string(bs), expected) {
+			t.Errorf("Should contain output %q.\nReceived: %s", expected, bs)
+		}
+	}
+}
+
+func TestBuildCommand_failedTest(t *testing.T) {
+	c := &BuildCommand{
+		Meta: TestMetaFile(t),
+	}
+
+	args := []string{
+		testFixture("hcl", "test-block", "test.pkr.hcl"),
+	}
+
+	defer cleanup("test-block-report.xml", "test-block-manifest.json")
+
+	if code := c.Run(args); code != 1 {
+		t.Fatalf("expected the build to fail, got exit code %d", code)
+	}
+
+	_, errOut := GetStdoutAndErrFromTestMeta(t, c.Meta)
+	if !strings.Contains(errOut, "1 of 1 test assertion(s) failed, see test-block-report.xml") {
+		t.Errorf("expected the failed assertions to be reported, got:\n%s", errOut)
+	}
+	if !fileExists("test-block-report.xml") {
+		t.Error("expected a JUnit report to be written")
+	}
+	if fileExists("test-block-manifest.json") {
+		t.Error("expected the post-processors not to run after a failed test")
+	}
+}
+
+func TestBuildCommand_Report(t *testing.T) {
+	c := &BuildCommand{
+		Meta: TestMetaFile(t),
+	}
+
+	reportPath := filepath.Join(t.TempDir(), "reports", "build.json")
+	args := []string{
+		"-report=" + reportPath,
+		testFixture("hcl", "build-report", "report.pkr.hcl"),
+	}
+
+	if code := c.Run(args); code != 1 {
+		t.Fatalf("expected the broken build to fail, got exit code %d", code)
+	}
+
+	raw, err := os.ReadFile(reportPath)
+	if err != nil {
+		t.Fatalf("expected a report to be written: %s", err)
+	}
+	var report buildReportDocument
+	if err := json.Unmarshal(raw, &report); err != nil {
+		t.Fatalf("invalid report: %s", err)
+	}
+	if len(report.Builds) != 2 {
+		t.Fatalf("expected both builds to be reported, got:\n%s", raw)
+	}
+	for _, b := range report.Builds {
+		switch b.Name {
+		case "null.ok":
+			if b.Outcome != packer.OutcomeSucceeded || len(b.Provisioners) != 1 || len(b.PostProcessors) != 1 || len(b.Artifacts) == 0 {
+				t.Errorf("unexpected report for %s:\n%s", b.Name, raw)
+			}
+			if b.Artifacts[0].BuilderID != "fnoeding.null" || b.Artifacts[0].GeneratedData["ID"] != "Null" {
+				t.Errorf("unexpected artifact for %s: %#v", b.Name, b.Artifacts[0])
+			}
+		case "null.broken":
+			if b.Outcome != packer.OutcomeFailed || b.Error == "" || len(b.Provisioners) != 2 || len(b.PostProcessors) != 0 {
+				t.Errorf("unexpected report for %s:\n%s", b.Name, raw)
+			}
+			if p := b.Provisioners[1]; p.Name != "fail" || p.Outcome != packer.OutcomeFailed {
+				t.Errorf("expected the fail provisioner to be reported as failed, got %#v", p)
+			}
+		default:
+			t.Errorf("unexpected build %q in the report", b.Name)
+		}
+	}
+}
+
+func TestBuildCommand_Timeout(t *testing.T) {
+	c := &BuildCommand{
+		Meta: TestMetaFile(t),
+	}
+
+	reportPath := filepath.Join(t.TempDir(), "build.json")
+	args := []string{
+		"-timeout=500ms",
+		"-report=" + reportPath,
+		testFixture("hcl", "build-timeout", "timeout.pkr.hcl"),
+	}
+
+	if code := c.Run(args); code != 1 {
+		t.Fatalf("expected the timed out build to fail the command, got exit code %d", code)
+	}
+	_, stderr := GetStdoutAndErrFromTestMeta(t, c.Meta)
+	if !strings.Contains(stderr, "Build 'null.short' timed out after 500ms") {
+		t.Errorf("expected the timeout to be reported, got:\n%s", stderr)
+	}
+
+	raw, err := os.ReadFile(reportPath)
+	if err != nil {
+		t.Fatalf("expected a report to be written: %s", err)
+	}
+	var report buildReportDocument
+	if err := json.Unmarshal(raw, &report); err != nil {
+		t.Fatalf("invalid report: %s", err)
+	}
+	outcomes := map[string]string{}
+	for _, b := range report.Builds {
+		outcomes[b.Name] = b.Outcome
+	}
+	expected := map[string]string{
+		"null.short": packer.OutcomeTimedOut,
+		"null.long":  packer.OutcomeSucceeded,
+	}
+	if diff := cmp.Diff(expected, outcomes); diff != "" {
+		t.Errorf("unexpected outcomes: %s", diff)
+	}
+}
+
 func TestBuildOnlyFileCommaFlags(t *testing.T) {
 	c := &BuildCommand{
 		Meta: TestMetaFile(t),
@@ -6,6 +6,7 @@ package command
 import (
 	"flag"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/packer/command/enumflag"
 	kvflag "github.com/hashicorp/packer/command/flag-kv"
@@ -83,6 +84,15 @@ func (ba *BuildArgs) AddFlagSets(flags *flag.FlagSet) {
 	flags.BoolVar(&ba.Force, "force", false, "")
 	flags.BoolVar(&ba.TimestampUi, "timestamp-ui", false, "")
 	flags.BoolVar(&ba.MachineReadable, "machine-readable", false, "")
+	flags.BoolVar(&ba.Plan, "plan", false, "")
+	flags.BoolVar(&ba.Journal, "journal", false, "")
+	flags.StringVar(&ba.JournalDir, "journal-dir", "", "")
+	flags.BoolVar(&ba.Resume, "resume", false, "")
+	flags.StringVar(&ba.Report, "report", "", "")
+	flags.DurationVar(&ba.Timeout, "timeout", 0, "")
+
+	flagPlanFormat := enumflag.New(&ba.PlanFormat, "text", "json")
+	flags.Var(flagPlanFormat, "plan-format", "")
 
 	flags.Int64Var(&ba.ParallelBuilds, "parallel-builds", 0, "")
 
@@ -100,6 +110,23 @@ type BuildArgs struct {
 	Color, TimestampUi, MachineReadable bool
 	ParallelBuilds                      int64
 	OnError                             string
+
+	// Plan prints what each build would do instead of running them.
+	Plan       bool
+	PlanFormat string
+
+	// Journal records the provisioners that completed for each build in
+	// JournalDir, so that a failed build can be resumed with Resume.
+	Journal, Resume bool
+	JournalDir      string
+
+	// Report is the path of the JSON report of the builds, written once
+	// they ran.
+	Report string
+
+	// Timeout is the time after which each build is cancelled, unless its
+	// source sets a shorter timeout.
+	Timeout time.Duration
 }
 
 func (ia *InitArgs) AddFlagSets(flags *flag.FlagSet) {
@@ -119,6 +146,19 @@ type PluginsRequiredArgs struct {
 	MetaArgs
 }
 
+func (pa *PluginsMirrorArgs) AddFlagSets(flags *flag.FlagSet) {
+	flags.Var((*sliceflag.StringFlag)(&pa.Platforms), "platform", "os_arch pairs to mirror plugins for.")
+
+	pa.MetaArgs.AddFlagSets(flags)
+}
+
+// PluginsMirrorArgs represents a parsed cli line for a `packer plugins mirror <dir> [<path>]`
+type PluginsMirrorArgs struct {
+	MetaArgs
+	Dir       string
+	Platforms []string
+}
+
 // ConsoleArgs represents a parsed cli line for a `packer console`
 type ConsoleArgs struct {
 	MetaArgs
@@ -126,6 +166,8 @@ type ConsoleArgs struct {
 
 func (fa *FixArgs) AddFlagSets(flags *flag.FlagSet) {
 	flags.BoolVar(&fa.Validate, "validate", true, "")
+	flags.BoolVar(&fa.Diff, "diff", false, "display the diff of the fixes (HCL2 only)")
+	flags.BoolVar(&fa.Inplace, "inplace", false, "overwrite the templates with their fixes (HCL2 only)")
 
 	fa.MetaArgs.AddFlagSets(flags)
 }
@@ -133,7 +175,8 @@ func (fa *FixArgs) AddFlagSets(flags *flag.FlagSet) {
 // FixArgs represents a parsed cli line for a `packer fix`
 type FixArgs struct {
 	MetaArgs
-	Validate bool
+	Validate      bool
+	Diff, Inplace bool
 }
 
 func (va *ValidateArgs) AddFlagSets(flags *flag.FlagSet) {
@@ -152,12 +195,23 @@ type ValidateArgs struct {
 }
 
 func (va *InspectArgs) AddFlagSets(flags *flag.FlagSet) {
+	va.Format = "text"
+	flags.Var(enumflag.New(&va.Format, "text", "json"), "format", "")
+	flags.StringVar(&va.Explain, "explain", "", "")
+	flags.BoolVar(&va.ShowDataSources, "show-data-sources", false, "")
 	va.MetaArgs.AddFlagSets(flags)
 }
 
 // InspectArgs represents a parsed cli line for a `packer inspect`
 type InspectArgs struct {
 	MetaArgs
+	// Format is the format of the inspection, text or json.
+	Format string
+	// Explain is the input variable whose assignments are explained instead.
+	Explain string
+	// ShowDataSources reveals the outputs of the data sources in the json
+	// format.
+	ShowDataSources bool
 }
 
 func (va *HCL2UpgradeArgs) AddFlagSets(flags *flag.FlagSet) {
@@ -187,3 +241,20 @@ type FormatArgs struct {
 	MetaArgs
 	Check, Diff, Write, Recursive bool
 }
+
+func (la *LintArgs) AddFlagSets(flags *flag.FlagSet) {
+	la.Format = "text"
+	flags.Var(enumflag.New(&la.Format, "text", "sarif"), "format", "")
+	flags.StringVar(&la.Config, "config", "", "")
+	la.MetaArgs.AddFlagSets(flags)
+}
+
+// LintArgs represents a parsed cli line for `packer lint`
+type LintArgs struct {
+	MetaArgs
+	// Format is the format of the issues, text or sarif.
+	Format string
+	// Config is the path of the lint config file, it defaults to the
+	// .packer-lint.hcl file of the template directory.
+	Config string
+}
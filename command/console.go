@@ -8,15 +8,26 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"log"
+	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/chzyer/readline"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/packer-plugin-sdk/pathing"
+	"github.com/hashicorp/packer/hcl2template"
+	"github.com/hashicorp/packer/hcl2template/repl"
 	"github.com/hashicorp/packer/helper/wrappedreadline"
 	"github.com/hashicorp/packer/helper/wrappedstreams"
 	"github.com/hashicorp/packer/packer"
 	"github.com/posener/complete"
 )
 
+// consoleHistoryFile is the file of the config directory keeping the history
+// of the console across sessions.
+const consoleHistoryFile = "console_history"
+
 var TiniestBuilder = strings.NewReader(`{
 	"builders": [
 		{
@@ -67,10 +78,10 @@ func (c *ConsoleCommand) RunContext(ctx context.Context, cla *ConsoleArgs) int {
 
 	// Determine if stdin is a pipe. If so, we evaluate directly.
 	if c.StdinPiped() {
-		return c.modePiped(packerStarter)
+		return c.modePiped(cla, packerStarter)
 	}
 
-	return c.modeInteractive(packerStarter)
+	return c.modeInteractive(cla, packerStarter)
 }
 
 func (*ConsoleCommand) Help() string {
@@ -106,31 +117,49 @@ func (*ConsoleCommand) AutocompleteFlags() complete.Flags {
 	}
 }
 
-func (c *ConsoleCommand) modePiped(cfg packer.Evaluator) int {
+func (c *ConsoleCommand) modePiped(cla *ConsoleArgs, cfg packer.Handler) int {
 	var lastResult string
 	scanner := bufio.NewScanner(wrappedstreams.Stdin())
 	ret := 0
+	input := ""
 	for scanner.Scan() {
-		result, _, diags := cfg.EvaluateExpression(strings.TrimSpace(scanner.Text()))
+		input += scanner.Text()
+		if !inputComplete(cfg, input) {
+			input += "\n"
+			continue
+		}
+		result, _, diags := c.evaluate(cla, &cfg, input)
+		input = ""
 		if len(diags) > 0 {
 			ret = writeDiags(c.Ui, nil, diags)
 		}
 		// Store the last result
 		lastResult = result
 	}
+	if strings.TrimSpace(input) != "" {
+		// the input ended in the middle of an expression
+		result, _, diags := c.evaluate(cla, &cfg, input)
+		if len(diags) > 0 {
+			ret = writeDiags(c.Ui, nil, diags)
+		}
+		lastResult = result
+	}
 
 	// Output the final result
 	c.Ui.Message(lastResult)
 	return ret
 }
 
-func (c *ConsoleCommand) modeInteractive(cfg packer.Evaluator) int {
+func (c *ConsoleCommand) modeInteractive(cla *ConsoleArgs, cfg packer.Handler) int {
+	completer := &consoleCompleter{cfg: cfg}
 	// Setup the UI so we can output directly to stdout
 	l, err := readline.NewEx(wrappedreadline.Override(&readline.Config{
 		Prompt:            "> ",
 		InterruptPrompt:   "^C",
 		EOFPrompt:         "exit",
+		HistoryFile:       consoleHistoryPath(),
 		HistorySearchFold: true,
+		AutoComplete:      completer,
 	}))
 	if err != nil {
 		c.Ui.Error(fmt.Sprintf(
@@ -138,28 +167,105 @@ func (c *ConsoleCommand) modeInteractive(cfg packer.Evaluator) int {
 			err))
 		return 1
 	}
+	defer l.Close()
+
+	input := ""
 	for {
 		// Read a line
 		line, err := l.Readline()
 		if err == readline.ErrInterrupt {
-			if len(line) == 0 {
+			if len(line) == 0 && input == "" {
 				break
 			} else {
+				// drop the expression being typed
+				input = ""
+				l.SetPrompt("> ")
 				continue
 			}
 		} else if err == io.EOF {
 			break
 		}
-		out, exit, diags := cfg.EvaluateExpression(line)
+
+		input += line
+		if !inputComplete(cfg, input) {
+			input += "\n"
+			l.SetPrompt(". ")
+			continue
+		}
+		l.SetPrompt("> ")
+
+		out, exit, diags := c.evaluate(cla, &cfg, input)
+		input = ""
+		completer.cfg = cfg
 		ret := writeDiags(c.Ui, nil, diags)
 		if exit {
 			return ret
 		}
 		c.Ui.Say(out)
-		if exit {
-			return ret
-		}
 	}
 
 	return 0
 }
+
+// evaluate evaluates the input of the console with cfg, or loads cfg again
+// from the template for the :reload command.
+func (c *ConsoleCommand) evaluate(cla *ConsoleArgs, cfg *packer.Handler, input string) (string, bool, hcl.Diagnostics) {
+	if strings.TrimSpace(input) != ":reload" {
+		return (*cfg).EvaluateExpression(strings.TrimSpace(input))
+	}
+
+	if cla.Path == "" {
+		return "There is no template to reload.", false, nil
+	}
+	packerStarter, ret := c.GetConfig(&cla.MetaArgs)
+	if ret != 0 {
+		return "The template was not reloaded.", false, nil
+	}
+	_ = packerStarter.Initialize(packer.InitializeOptions{})
+	*cfg = packerStarter
+	return "The template was reloaded.", false, nil
+}
+
+// inputComplete tells whether input is a complete expression of cfg. Only HCL2
+// expressions can span multiple lines.
+func inputComplete(cfg packer.Evaluator, input string) bool {
+	if _, ok := cfg.(*hcl2template.PackerConfig); !ok {
+		return true
+	}
+	return repl.IsComplete(input)
+}
+
+// consoleHistoryPath returns the path of the history file of the console, in
+// the config directory, or "" when it cannot be created.
+func consoleHistoryPath() string {
+	configDir, err := pathing.ConfigDir()
+	if err != nil {
+		log.Printf("[WARN] Cannot keep the history of the console: %s", err)
+		return ""
+	}
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		log.Printf("[WARN] Cannot keep the history of the console: %s", err)
+		return ""
+	}
+	return filepath.Join(configDir, consoleHistoryFile)
+}
+
+// consoleCompleter completes the references, functions and commands of the
+// HCL2 console.
+type consoleCompleter struct {
+	cfg packer.Evaluator
+}
+
+func (cc *consoleCompleter) Do(line []rune, pos int) ([][]rune, int) {
+	hclCfg, ok := cc.cfg.(*hcl2template.PackerConfig)
+	if !ok {
+		return nil, 0
+	}
+
+	completions, length := repl.Complete(string(line[:pos]), hclCfg.ConsoleCompletions())
+	newLine := make([][]rune, 0, len(completions))
+	for _, completion := range completions {
+		newLine = append(newLine, []rune(completion))
+	}
+	return newLine, length
+}
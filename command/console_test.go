@@ -40,6 +40,10 @@ func Test_console(t *testing.T) {
 		{"var.untyped", []string{"console", `-var=untyped=just_a_string`, filepath.Join(testFixture("hcl", "variables", "untyped_var"))}, nil, "just_a_string\n"},
 		{"var.untyped", []string{"console", filepath.Join(testFixture("hcl", "variables", "untyped_var", "var.pkr.hcl"))}, nil, "<unknown>\n"},
 		{"var.untyped", []string{"console", filepath.Join(testFixture("hcl", "variables", "untyped_var", "var.pkr.hcl"))}, []string{"PKR_VAR_untyped=just_a_string"}, "just_a_string\n"},
+		{"{\n  fruit = upper(var.fruit)\n  heredoc = <<EOF\n  ${var.fruit}\nEOF\n}", []string{"console", filepath.Join(testFixture("var-arg"), "fruit_builder.pkr.hcl")}, []string{"PKR_VAR_fruit=potato"}, "{\n" + `  "fruit" = "POTATO"` + "\n" + `  "heredoc" = "  potato\n"` + "\n}\n"},
+		{":reload\nvar.fruit", []string{"console", filepath.Join(testFixture("var-arg"), "fruit_builder.pkr.hcl")}, []string{"PKR_VAR_fruit=potato"}, "potato\n"},
+		{":reload", []string{"console", filepath.Join(testFixture("var-arg"), "fruit_builder.pkr.hcl")}, nil, "The template was reloaded.\n"},
+		{":reload", []string{"console", "--config-type=hcl2"}, nil, "There is no template to reload.\n"},
 	}
 
 	for _, tc := range tc {
@@ -14,6 +14,7 @@ import (
 
 	"github.com/hashicorp/packer-plugin-sdk/template"
 	"github.com/hashicorp/packer/fix"
+	"github.com/hashicorp/packer/hcl2template"
 
 	"github.com/posener/complete"
 )
@@ -54,7 +55,10 @@ func (c *FixCommand) ParseArgs(args []string) (*FixArgs, int) {
 
 func (c *FixCommand) RunContext(ctx context.Context, cla *FixArgs) int {
 	if hcl2, _ := isHCLLoaded(cla.Path); hcl2 {
-		c.Ui.Error("packer fix only works with JSON files for now.")
+		return c.fixHCL2(cla)
+	}
+	if cla.Diff || cla.Inplace {
+		c.Ui.Error("The -diff and -inplace options only work with HCL2 templates.")
 		return 1
 	}
 	// Read the file for decoding
@@ -136,13 +140,30 @@ func (c *FixCommand) RunContext(ctx context.Context, cla *FixArgs) int {
 	return 0
 }
 
+// fixHCL2 runs the fixers on the HCL2 templates of cla.Path, which can be a
+// directory.
+func (c *FixCommand) fixHCL2(cla *FixArgs) int {
+	fixer := hcl2template.HCL2Fixer{
+		ShowDiff: cla.Diff,
+		Write:    cla.Inplace,
+		Output:   os.Stdout,
+	}
+
+	_, diags := fixer.Fix(cla.Path)
+	return writeDiags(c.Ui, nil, diags)
+}
+
 func (*FixCommand) Help() string {
 	helpText := `
 Usage: packer fix [options] TEMPLATE
 
-  Reads the JSON template and attempts to fix known backwards
+  Reads the template and attempts to fix known backwards
   incompatibilities. The fixed template will be outputted to standard out.
 
+  TEMPLATE can be a JSON template, an HCL2 template file or a directory of
+  HCL2 template files. HCL2 templates keep their comments; the fixed files
+  are formatted.
+
   If the template cannot be fixed due to an error, the command will exit
   with a non-zero exit status. Error messages will appear on standard error.
 
@@ -158,7 +179,11 @@ Fixes that are run (in order):
 	helpText += `
 Options:
 
-  -validate=true      If true (default), validates the fixed template.
+  -validate=true      If true (default), validates the fixed JSON template.
+  -diff               Display the diff of the fixes instead of the fixed
+                      template (HCL2 only).
+  -inplace            Overwrite the templates with their fixes, which is
+                      needed to fix a directory (HCL2 only).
 `
 
 	return strings.TrimSpace(helpText)
@@ -175,5 +200,7 @@ func (c *FixCommand) AutocompleteArgs() complete.Predictor {
 func (c *FixCommand) AutocompleteFlags() complete.Flags {
 	return complete.Flags{
 		"-validate": complete.PredictNothing,
+		"-diff":     complete.PredictNothing,
+		"-inplace":  complete.PredictNothing,
 	}
 }
@@ -4,6 +4,7 @@
 package command
 
 import (
+	"os"
 	"path/filepath"
 	"strings"
 	"testing"
@@ -64,3 +65,42 @@ func TestFix_invalidTemplateDisableValidation(t *testing.T) {
 		fatalCommand(t, c.Meta)
 	}
 }
+
+func TestFix_hcl2(t *testing.T) {
+	c := &FixCommand{
+		Meta: testMeta(t),
+	}
+
+	template := filepath.Join(testFixture("fix-hcl"), "template.pkr.hcl")
+	data, err := os.ReadFile(template)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	path := filepath.Join(dir, "template.pkr.hcl")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if code := c.Run([]string{"-inplace", dir}); code != 0 {
+		fatalCommand(t, c.Meta)
+	}
+	fixed, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, string(fixed), `ssh_timeout  = "5m"`)
+	assert.NotContains(t, string(fixed), "ssh_wait_timeout =")
+	assert.Contains(t, string(fixed), "# The ssh_wait_timeout option was renamed to ssh_timeout.")
+}
+
+func TestFix_hcl2DiffOptionsOnJSON(t *testing.T) {
+	c := &FixCommand{
+		Meta: testMeta(t),
+	}
+
+	args := []string{"-diff", filepath.Join(testFixture("fix"), "template.json")}
+	if code := c.Run(args); code != 1 {
+		fatalCommand(t, c.Meta)
+	}
+}
@@ -12,10 +12,9 @@ import (
 	"strings"
 
 	pluginsdk "github.com/hashicorp/packer-plugin-sdk/plugin"
+	"github.com/hashicorp/packer/hcl2template"
 	"github.com/hashicorp/packer/packer"
 	plugingetter "github.com/hashicorp/packer/packer/plugin-getter"
-	"github.com/hashicorp/packer/packer/plugin-getter/github"
-	"github.com/hashicorp/packer/version"
 	"github.com/posener/complete"
 )
 
@@ -93,17 +92,10 @@ for more info.`)
 
 	log.Printf("[TRACE] init: %#v", opts)
 
-	getters := []plugingetter.Getter{
-		&github.Getter{
-			// In the past some terraform plugins downloads were blocked from a
-			// specific aws region by s3. Changing the user agent unblocked the
-			// downloads so having one user agent per version will help mitigate
-			// that a little more. Especially in the case someone forks this
-			// code to make it more aggressive or something.
-			// TODO: allow to set this from the config file or an environment
-			// variable.
-			UserAgent: "packer-getter-github-" + version.String(),
-		},
+	getters, err := c.Meta.pluginGetters()
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
 	}
 
 	ui := &packer.ColoredUi{
@@ -111,6 +103,13 @@ for more info.`)
 		Ui:    c.Ui,
 	}
 
+	lock, err := c.loadPluginLock(packerStarter, len(reqs) > 0)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+
+	var required []string
 	for _, pluginRequirement := range reqs {
 		// Get installed plugins that match requirement
 
@@ -122,28 +121,79 @@ for more info.`)
 
 		log.Printf("[TRACE] for plugin %s found %d matching installation(s)", pluginRequirement.Identifier, len(installs))
 
-		if len(installs) > 0 && cla.Upgrade == false {
+		identifier := pluginRequirement.Identifier.String()
+		required = append(required, identifier)
+
+		locked := lock.Plugin(identifier)
+		if locked != nil && !cla.Upgrade {
+			if err := locked.CheckConstraints(pluginRequirement.VersionConstraints); err != nil {
+				c.Ui.Error(fmt.Sprintf("Failed getting the %q plugin: %s.\n"+
+					"Run packer init -upgrade to select a new version.", identifier, err))
+				ret = 1
+				continue
+			}
+		} else {
+			locked = &plugingetter.LockedPlugin{Identifier: identifier}
+			if len(installs) > 0 && !cla.Upgrade {
+				// Lock the installation that was used until now, without
+				// querying the remote.
+				if err := locked.LockInstallation(installs[len(installs)-1]); err != nil {
+					c.Ui.Error(fmt.Sprintf("Failed locking the %q plugin: %s", identifier, err))
+					ret = 1
+					continue
+				}
+			}
+		}
+		locked.Constraints = pluginRequirement.VersionConstraints.String()
+
+		if _, err := locked.SelectInstallation(installs); err == nil {
+			lock.SetPlugin(locked)
 			continue
 		}
 
-		newInstall, err := pluginRequirement.InstallLatest(plugingetter.InstallOptions{
+		newInstall, err := pluginRequirement.InstallLocked(plugingetter.InstallOptions{
 			InFolders:                 opts.FromFolders,
 			BinaryInstallationOptions: opts.BinaryInstallationOptions,
 			Getters:                   getters,
-		})
+		}, locked)
 		if err != nil {
 			c.Ui.Error(fmt.Sprintf("Failed getting the %q plugin:", pluginRequirement.Identifier))
 			c.Ui.Error(err.Error())
 			ret = 1
+			continue
 		}
+		lock.SetPlugin(locked)
 		if newInstall != nil {
 			msg := fmt.Sprintf("Installed plugin %s %s in %q", pluginRequirement.Identifier, newInstall.Version, newInstall.BinaryPath)
 			ui.Say(msg)
 		}
 	}
+
+	if lock != nil && ret == 0 {
+		lock.Retain(required)
+		if err := lock.Save(); err != nil {
+			c.Ui.Error(fmt.Sprintf("Failed to write the plugin lock file: %s", err))
+			return 1
+		}
+	}
 	return ret
 }
 
+// loadPluginLock loads the plugin lock file of an HCL2 config. Legacy JSON
+// templates, or configs without plugin requirements, have no lock file and
+// nil is returned.
+func (c *InitCommand) loadPluginLock(cfg packer.Handler, hasRequirements bool) (*plugingetter.Lock, error) {
+	hclCfg, ok := cfg.(*hcl2template.PackerConfig)
+	if !ok || !hasRequirements {
+		return nil, nil
+	}
+	lock, err := plugingetter.LoadLock(hclCfg.Basedir)
+	if err != nil {
+		return nil, err
+	}
+	return lock, nil
+}
+
 func (*InitCommand) Help() string {
 	helpText := `
 Usage: packer init [options] TEMPLATE
@@ -157,10 +207,16 @@ Usage: packer init [options] TEMPLATE
   This command is always safe to run multiple times. Though subsequent runs may
   give errors, this command will never delete anything.
 
+  The selected version of each plugin and its checksums are recorded in a
+  .packer.lock.hcl file next to the config. Subsequent runs install the locked
+  versions, and packer build and packer validate refuse to use plugins that do
+  not match the lock file. The lock file is meant to be committed.
+
 Options:
   -upgrade                     On top of installing missing plugins, update
                                installed plugins to the latest available
-                               version, if there is a new higher one. Note that
+                               version, if there is a new higher one, and
+                               update the lock file accordingly. Note that
                                this still takes into consideration the version
                                constraint of the config.
 `
@@ -43,6 +43,10 @@ func (c *InspectCommand) ParseArgs(args []string) (*InspectArgs, int) {
 }
 
 func (c *InspectCommand) RunContext(ctx context.Context, cla *InspectArgs) int {
+	if cla.Explain != "" {
+		return c.explain(cla)
+	}
+
 	packerStarter, ret := c.GetConfig(&cla.MetaArgs)
 	if ret != 0 {
 		return ret
@@ -52,10 +56,34 @@ func (c *InspectCommand) RunContext(ctx context.Context, cla *InspectArgs) int {
 	_ = packerStarter.Initialize(packer.InitializeOptions{})
 
 	return packerStarter.InspectConfig(packer.InspectConfigOptions{
-		Ui: c.Ui,
+		Ui:              c.Ui,
+		Format:          cla.Format,
+		ShowDataSources: cla.ShowDataSources,
 	})
 }
 
+// explain explains where the value of the cla.Explain input variable comes
+// from. Unlike the other inspections, it also runs when the variables have
+// invalid values, to tell which assignments are invalid.
+func (c *InspectCommand) explain(cla *InspectArgs) int {
+	if cfgType, _ := cla.GetConfigType(); cfgType != ConfigTypeHCL2 {
+		c.Ui.Error("The -explain option is only supported for HCL2 templates.")
+		return 1
+	}
+
+	packerStarter, ret := c.GetConfigFromHCL(&cla.MetaArgs)
+	if packerStarter == nil {
+		return ret
+	}
+
+	out, diags := packerStarter.ExplainVariable(cla.Explain)
+	if diags.HasErrors() {
+		return writeDiags(c.Ui, nil, diags)
+	}
+	c.Ui.Say(strings.TrimSuffix(out, "\n"))
+	return 0
+}
+
 func (*InspectCommand) Help() string {
 	helpText := `
 Usage: packer inspect TEMPLATE
@@ -64,9 +92,23 @@ Usage: packer inspect TEMPLATE
   defines. This does not validate the contents of a template (other than
   basic syntax by necessity).
 
+  With -format=json, the resolved configuration of an HCL2 template is
+  outputted instead: the values of its variables and where they were set, its
+  locals, the outputs of its data sources, and the evaluated configuration of
+  each source of its builds, with their provisioners and post-processors.
+  Sensitive values are redacted, and so are the outputs of the data sources,
+  which can be secrets, unless -show-data-sources is set.
+
+  With -explain=var.NAME, every assignment of the input variable of an HCL2
+  template is listed instead, in precedence order, and the one used is
+  marked.
+
 Options:
 
-  -machine-readable  Machine-readable output
+  -machine-readable   Machine-readable output
+  -format=text        The format of the inspection: text or json (HCL2 only)
+  -explain=var.NAME   Explain where the value of an input variable comes from (HCL2 only)
+  -show-data-sources  Do not redact the outputs of the data sources with -format=json
 `
 
 	return strings.TrimSpace(helpText)
@@ -82,6 +124,9 @@ func (c *InspectCommand) AutocompleteArgs() complete.Predictor {
 
 func (c *InspectCommand) AutocompleteFlags() complete.Flags {
 	return complete.Flags{
-		"-machine-readable": complete.PredictNothing,
+		"-machine-readable":  complete.PredictNothing,
+		"-format":            complete.PredictSet("text", "json"),
+		"-explain":           complete.PredictNothing,
+		"-show-data-sources": complete.PredictNothing,
 	}
 }
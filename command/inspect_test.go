@@ -159,6 +159,53 @@ Provisioners:
 Note: If your build names contain user variables or template
 functions such as 'timestamp', these are processed at build time,
 and therefore only show in their raw form here.
+`},
+		{[]string{"inspect", "-format=json", "-var=fruit=banana", filepath.Join(testFixture("var-arg"), "fruit_builder.pkr.hcl")}, nil, `{
+  "variables": {
+    "fruit": {
+      "type": "string",
+      "value": "banana",
+      "from": "cmd"
+    }
+  },
+  "locals": {
+    "fruit": {
+      "value": "banana"
+    }
+  },
+  "data_sources": {},
+  "builds": [
+    {
+      "sources": [
+        {
+          "name": "null.builder",
+          "type": "null",
+          "config": {
+            "communicator": "none"
+          },
+          "provisioners": [
+            {
+              "type": "shell-local",
+              "config": {
+                "inline": [
+                  "echo banana > banana.txt"
+                ]
+              }
+            }
+          ],
+          "post_processors": []
+        }
+      ]
+    }
+  ]
+}
+`},
+		{[]string{"inspect", "-explain=var.fruit", "-var=fruit=banana", filepath.Join(testFixture("var-arg"), "fruit_builder.pkr.hcl")}, nil, `var.fruit: "banana"
+
+Assignments, in precedence order:
+
+  1. -var (used)
+     value: "banana"
 `},
 		{
 			[]string{
@@ -184,3 +231,25 @@ and therefore only show in their raw form here.
 		})
 	}
 }
+
+func TestInspect_jsonFormatOnJSONTemplate(t *testing.T) {
+	c := &InspectCommand{
+		Meta: TestMetaFile(t),
+	}
+
+	args := []string{"-format=json", filepath.Join(testFixture("inspect"), "unset_var.json")}
+	if code := c.Run(args); code != 1 {
+		t.Fatalf("expected exit code 1, got %d", code)
+	}
+}
+
+func TestInspect_explainOnJSONTemplate(t *testing.T) {
+	c := &InspectCommand{
+		Meta: TestMetaFile(t),
+	}
+
+	args := []string{"-explain=var.something", filepath.Join(testFixture("inspect"), "unset_var.json")}
+	if code := c.Run(args); code != 1 {
+		t.Fatalf("expected exit code 1, got %d", code)
+	}
+}
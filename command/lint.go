@@ -0,0 +1,302 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/packer/hcl2template"
+	"github.com/hashicorp/packer/packer"
+	"github.com/hashicorp/packer/version"
+	"github.com/posener/complete"
+)
+
+// lintConfigFile is the name of the lint config file looked up in the
+// directory of the template.
+const lintConfigFile = ".packer-lint.hcl"
+
+type LintCommand struct {
+	Meta
+}
+
+func (c *LintCommand) Run(args []string) int {
+	ctx := context.Background()
+
+	cfg, ret := c.ParseArgs(args)
+	if ret != 0 {
+		return ret
+	}
+
+	return c.RunContext(ctx, cfg)
+}
+
+func (c *LintCommand) ParseArgs(args []string) (*LintArgs, int) {
+	var cfg LintArgs
+	flags := c.Meta.FlagSet("lint")
+	flags.Usage = func() { c.Ui.Say(c.Help()) }
+	cfg.AddFlagSets(flags)
+	if err := flags.Parse(args); err != nil {
+		return &cfg, 1
+	}
+
+	args = flags.Args()
+	if len(args) != 1 {
+		flags.Usage()
+		return &cfg, 1
+	}
+	cfg.Path = args[0]
+	return &cfg, 0
+}
+
+func (c *LintCommand) RunContext(ctx context.Context, cla *LintArgs) int {
+	if cfgType, _ := cla.GetConfigType(); cfgType != ConfigTypeHCL2 {
+		c.Ui.Error("packer lint only works with HCL2 templates.")
+		return 1
+	}
+
+	lintConfig, ret := c.lintConfig(cla)
+	if ret != 0 {
+		return ret
+	}
+
+	packerStarter, ret := c.GetConfigFromHCL(&cla.MetaArgs)
+	if ret != 0 {
+		return ret
+	}
+
+	// here we ignore init diags, like unknown plugins, as linting does not
+	// need the plugins; validating the template is left to packer validate.
+	_ = packerStarter.Initialize(packer.InitializeOptions{
+		SkipDatasourcesExecution: true,
+	})
+
+	issues := packerStarter.Lint(lintConfig)
+	if cla.Format == "sarif" {
+		out, err := json.MarshalIndent(newSARIFLog(lintConfig, issues), "", "  ")
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Failed to encode the lint issues: %s", err))
+			return 1
+		}
+		c.Ui.Say(string(out))
+	} else {
+		c.writeLintIssues(issues)
+	}
+
+	if len(issues) > 0 {
+		return 2
+	}
+	return 0
+}
+
+// lintConfig decodes the lint config file of cla, which is optional unless
+// set with -config.
+func (c *LintCommand) lintConfig(cla *LintArgs) (*hcl2template.LintConfig, int) {
+	filename := cla.Config
+	if filename == "" {
+		dir := cla.Path
+		if info, err := os.Stat(dir); err == nil && !info.IsDir() {
+			dir = filepath.Dir(dir)
+		}
+		filename = filepath.Join(dir, lintConfigFile)
+		if _, err := os.Stat(filename); err != nil {
+			return nil, 0
+		}
+	}
+
+	lintConfig, diags := hcl2template.DecodeLintConfigFile(filename)
+	return lintConfig, writeDiags(c.Ui, nil, diags)
+}
+
+func (c *LintCommand) writeLintIssues(issues []hcl2template.LintIssue) {
+	if len(issues) == 0 {
+		c.Ui.Say("No issues found.")
+		return
+	}
+
+	for _, issue := range issues {
+		position := "(unknown position)"
+		if issue.Subject != nil {
+			position = issue.Subject.String()
+		}
+		severity := "warning"
+		if issue.Severity == hcl.DiagError {
+			severity = "error"
+		}
+		c.Ui.Say(fmt.Sprintf("%s: %s: %s [%s]", position, severity, issue.Summary, issue.Rule))
+		if issue.Detail != "" {
+			c.Ui.Say("  " + issue.Detail)
+		}
+	}
+	c.Ui.Say(fmt.Sprintf("\n%d issue(s) found.", len(issues)))
+}
+
+// The SARIF log types follow the SARIF 2.1.0 format of static analysis
+// results, read by code scanning tools.
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn"`
+	EndLine     int `json:"endLine"`
+	EndColumn   int `json:"endColumn"`
+}
+
+func newSARIFLog(config *hcl2template.LintConfig, issues []hcl2template.LintIssue) sarifLog {
+	driver := sarifDriver{
+		Name:           "packer",
+		Version:        version.FormattedVersion(),
+		InformationURI: "https://developer.hashicorp.com/packer/docs/commands/lint",
+		Rules:          []sarifRule{},
+	}
+	for _, name := range hcl2template.LintRuleOrder {
+		if config.Enabled(name) {
+			driver.Rules = append(driver.Rules, sarifRule{
+				ID:               name,
+				ShortDescription: sarifMessage{Text: hcl2template.LintRules[name].Synopsis()},
+			})
+		}
+	}
+
+	results := []sarifResult{}
+	for _, issue := range issues {
+		result := sarifResult{
+			RuleID:  issue.Rule,
+			Level:   "warning",
+			Message: sarifMessage{Text: strings.TrimSpace(issue.Summary + "\n\n" + issue.Detail)},
+		}
+		if issue.Severity == hcl.DiagError {
+			result.Level = "error"
+		}
+		if subject := issue.Subject; subject != nil {
+			result.Locations = []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: filepath.ToSlash(subject.Filename)},
+				Region: sarifRegion{
+					StartLine:   subject.Start.Line,
+					StartColumn: subject.Start.Column,
+					EndLine:     subject.End.Line,
+					EndColumn:   subject.End.Column,
+				},
+			}}}
+		}
+		results = append(results, result)
+	}
+
+	return sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs: []sarifRun{{
+			Tool:    sarifTool{Driver: driver},
+			Results: results,
+		}},
+	}
+}
+
+func (*LintCommand) Help() string {
+	helpText := `
+Usage: packer lint [options] TEMPLATE
+
+  Checks the HCL2 template for likely mistakes and bad practices, like unused
+  variables or hard-coded credentials. Unlike validate, lint does not need the
+  plugins of the template.
+
+  The command exits with a status of 2 when issues are found. The issues of a
+  line can be ignored with a "# packer-lint-ignore" comment on the line, or on
+  the line before it, optionally followed by a comma separated list of the
+  rules to ignore.
+
+  The rules can be disabled in a lint config file, by default the
+  .packer-lint.hcl file of the template directory:
+
+    rule "provisioner-timeout" {
+      enabled = false
+    }
+
+Options:
+
+  -format=text                  The format of the issues: text or sarif.
+  -config=path                  The lint config file.
+  -var 'key=value'              Variable for templates, can be used multiple times.
+  -var-file=path                JSON or HCL2 file containing user variables, can be used multiple times.
+
+Rules:
+
+`
+	for _, name := range hcl2template.LintRuleOrder {
+		helpText += fmt.Sprintf("  %-28s%s\n", name, hcl2template.LintRules[name].Synopsis())
+	}
+
+	return strings.TrimSpace(helpText)
+}
+
+func (*LintCommand) Synopsis() string {
+	return "check a template for likely mistakes"
+}
+
+func (*LintCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (*LintCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{
+		"-format":   complete.PredictSet("text", "sarif"),
+		"-config":   complete.PredictFiles("*.hcl"),
+		"-var":      complete.PredictNothing,
+		"-var-file": complete.PredictNothing,
+	}
+}
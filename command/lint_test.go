@@ -0,0 +1,79 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+func TestLint(t *testing.T) {
+	c := &LintCommand{
+		Meta: TestMetaFile(t),
+	}
+
+	if code := c.Run([]string{testFixture("lint", "clean")}); code != 0 {
+		fatalCommand(t, c.Meta)
+	}
+	out := c.Meta.Ui.(*packersdk.BasicUi).Writer.(*bytes.Buffer)
+	if !strings.Contains(out.String(), "No issues found.") {
+		t.Errorf("expected no issues, got %q", out.String())
+	}
+}
+
+func TestLint_sarif(t *testing.T) {
+	c := &LintCommand{
+		Meta: TestMetaFile(t),
+	}
+
+	// The lint config of the fixture disables the unused-declarations rule.
+	args := []string{"-format=sarif", testFixture("lint", "issues")}
+	if code := c.Run(args); code != 2 {
+		fatalCommand(t, c.Meta)
+	}
+
+	out := c.Meta.Ui.(*packersdk.BasicUi).Writer.(*bytes.Buffer)
+	var log sarifLog
+	if err := json.Unmarshal(out.Bytes(), &log); err != nil {
+		t.Fatalf("failed to decode the SARIF log: %s\n%s", err, out.String())
+	}
+	if len(log.Runs) != 1 {
+		t.Fatalf("expected one run, got %d", len(log.Runs))
+	}
+	for _, rule := range log.Runs[0].Tool.Driver.Rules {
+		if rule.ID == "unused-declarations" {
+			t.Errorf("expected the unused-declarations rule to be disabled")
+		}
+	}
+	expected := []sarifResult{
+		{
+			RuleID:  "provisioner-timeout",
+			Level:   "warning",
+			Message: sarifMessage{Text: "The shell-local provisioner has no timeout\n\nA provisioner that hangs blocks its build until the build is cancelled. Set how long it can run with the timeout argument, for example `timeout = \"30m\"`."},
+			Locations: []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: "test-fixtures/lint/issues/template.pkr.hcl"},
+				Region:           sarifRegion{StartLine: 12, StartColumn: 3, EndLine: 12, EndColumn: 28},
+			}}},
+		},
+	}
+	if diff := cmp.Diff(expected, log.Runs[0].Results); diff != "" {
+		t.Errorf("unexpected results: %s", diff)
+	}
+}
+
+func TestLint_json(t *testing.T) {
+	c := &LintCommand{
+		Meta: TestMetaFile(t),
+	}
+
+	args := []string{testFixture("validate", "build.json")}
+	if code := c.Run(args); code != 1 {
+		fatalCommand(t, c.Meta)
+	}
+}
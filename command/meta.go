@@ -26,6 +26,9 @@ type Meta struct {
 	CoreConfig *packer.CoreConfig
 	Ui         packersdk.Ui
 	Version    string
+
+	// PluginInstallation sets where plugins are installed from.
+	PluginInstallation *PluginInstallationConfig
 }
 
 // Core returns the core for the given template given the configured
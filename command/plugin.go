@@ -24,6 +24,7 @@ import (
 	compresspostprocessor "github.com/hashicorp/packer/post-processor/compress"
 	manifestpostprocessor "github.com/hashicorp/packer/post-processor/manifest"
 	shelllocalpostprocessor "github.com/hashicorp/packer/post-processor/shell-local"
+	signpostprocessor "github.com/hashicorp/packer/post-processor/sign"
 	breakpointprovisioner "github.com/hashicorp/packer/provisioner/breakpoint"
 	fileprovisioner "github.com/hashicorp/packer/provisioner/file"
 	powershellprovisioner "github.com/hashicorp/packer/provisioner/powershell"
@@ -60,6 +61,7 @@ var PostProcessors = map[string]packersdk.PostProcessor{
 	"compress":    new(compresspostprocessor.PostProcessor),
 	"manifest":    new(manifestpostprocessor.PostProcessor),
 	"shell-local": new(shelllocalpostprocessor.PostProcessor),
+	"sign":        new(signpostprocessor.PostProcessor),
 }
 
 var Datasources = map[string]packersdk.Datasource{
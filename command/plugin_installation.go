@@ -0,0 +1,186 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package command
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/packer/hcl2template/addrs"
+	plugingetter "github.com/hashicorp/packer/packer/plugin-getter"
+	"github.com/hashicorp/packer/packer/plugin-getter/github"
+	"github.com/hashicorp/packer/packer/plugin-getter/gitlab"
+	"github.com/hashicorp/packer/packer/plugin-getter/httpindex"
+	"github.com/hashicorp/packer/packer/plugin-getter/mirror"
+	pkrversion "github.com/hashicorp/packer/version"
+	"golang.org/x/oauth2"
+)
+
+const (
+	// FilesystemMirrorEnvVar is a list of directories, separated like the
+	// PATH, to install plugins from.
+	FilesystemMirrorEnvVar = "PACKER_PLUGIN_FILESYSTEM_MIRROR"
+	// NetworkMirrorEnvVar is a space separated list of URLs to install
+	// plugins from.
+	NetworkMirrorEnvVar = "PACKER_PLUGIN_NETWORK_MIRROR"
+	// PluginTokenEnvVarPrefix prefixes the environment variables setting the
+	// token used for the plugins of a host, like
+	// PACKER_PLUGIN_TOKEN_gitlab_example_com for gitlab.example.com.
+	PluginTokenEnvVarPrefix = "PACKER_PLUGIN_TOKEN_"
+)
+
+// Types of plugin sources.
+const (
+	PluginSourceTypeGitLab = "gitlab"
+	PluginSourceTypeHTTPS  = "https"
+)
+
+// PluginInstallationConfig sets where plugins are installed from. It is read
+// from the plugin_installation object of the Packer config file.
+type PluginInstallationConfig struct {
+	// FilesystemMirrors are local directories populated by
+	// `packer plugins mirror`.
+	FilesystemMirrors []string `json:"filesystem_mirror"`
+	// NetworkMirrors are URLs of HTTP servers serving the content of such
+	// directories.
+	NetworkMirrors []string `json:"network_mirror"`
+	// Direct sets whether plugins are also installed from their source, like
+	// GitHub, after trying the mirrors. Defaults to false when a mirror is
+	// set, and to true otherwise.
+	Direct *bool `json:"direct"`
+	// Sources sets how plugins are installed from hosts other than GitHub,
+	// by hostname.
+	Sources map[string]PluginSourceConfig `json:"sources"`
+}
+
+// PluginSourceConfig sets how the plugins of a host are installed.
+type PluginSourceConfig struct {
+	// Type of the host, "gitlab" for the releases of GitLab projects or
+	// "https" for an index of releases served over HTTPS.
+	Type string `json:"type"`
+	// URL of the GitLab API, or of the plugin indexes, of the host. Defaults
+	// to the hostname.
+	URL string `json:"url"`
+}
+
+// pluginGetters returns the getters plugins are installed from, in order of
+// priority: filesystem mirrors, network mirrors and finally the plugin
+// sources themselves. The mirror environment variables take precedence over
+// the config file.
+func (m *Meta) pluginGetters() ([]plugingetter.Getter, error) {
+	var cfg PluginInstallationConfig
+	if m.PluginInstallation != nil {
+		cfg = *m.PluginInstallation
+	}
+	if env := os.Getenv(FilesystemMirrorEnvVar); env != "" {
+		cfg.FilesystemMirrors = filepath.SplitList(env)
+	}
+	if env := os.Getenv(NetworkMirrorEnvVar); env != "" {
+		cfg.NetworkMirrors = strings.Fields(env)
+	}
+
+	var getters []plugingetter.Getter
+	for _, path := range cfg.FilesystemMirrors {
+		getters = append(getters, &mirror.FilesystemGetter{Path: path})
+	}
+	for _, url := range cfg.NetworkMirrors {
+		getters = append(getters, &mirror.NetworkGetter{
+			URL:       url,
+			UserAgent: "packer-getter-network-mirror-" + pkrversion.String(),
+		})
+	}
+
+	direct := len(getters) == 0
+	if cfg.Direct != nil {
+		direct = *cfg.Direct
+	}
+	if direct {
+		registry, err := pluginSourceRegistry(cfg.Sources)
+		if err != nil {
+			return nil, err
+		}
+		getters = append(getters, registry)
+	}
+	return getters, nil
+}
+
+// pluginSourceRegistry returns the getters of the plugins sources, by
+// hostname.
+func pluginSourceRegistry(sources map[string]PluginSourceConfig) (*plugingetter.GetterRegistry, error) {
+	registry := &plugingetter.GetterRegistry{}
+	registry.Register("github.com", &github.Getter{
+		// In the past some terraform plugins downloads were blocked from a
+		// specific aws region by s3. Changing the user agent unblocked the
+		// downloads so having one user agent per version will help mitigate
+		// that a little more. Especially in the case someone forks this
+		// code to make it more aggressive or something.
+		// TODO: allow to set this from the config file or an environment
+		// variable.
+		UserAgent: "packer-getter-github-" + pkrversion.String(),
+	})
+	registry.Register("gitlab.com", &gitlab.Getter{
+		Client:    pluginSourceClient("gitlab.com", ""),
+		UserAgent: "packer-getter-gitlab-" + pkrversion.String(),
+	})
+
+	for given, source := range sources {
+		hostname, err := addrs.ParsePluginHostname(given)
+		if err != nil {
+			return nil, fmt.Errorf("invalid plugin source hostname %q: %s", given, err)
+		}
+		switch source.Type {
+		case PluginSourceTypeGitLab:
+			registry.Register(hostname, &gitlab.Getter{
+				BaseURL:   source.URL,
+				Client:    pluginSourceClient(hostname, source.URL),
+				UserAgent: "packer-getter-gitlab-" + pkrversion.String(),
+			})
+		case PluginSourceTypeHTTPS:
+			registry.Register(hostname, &httpindex.Getter{
+				BaseURL:   source.URL,
+				Client:    pluginSourceClient(hostname, source.URL),
+				UserAgent: "packer-getter-http-index-" + pkrversion.String(),
+			})
+		default:
+			return nil, fmt.Errorf("unknown type %q for the %s plugin source, expected %q or %q",
+				source.Type, hostname, PluginSourceTypeGitLab, PluginSourceTypeHTTPS)
+		}
+	}
+	return registry, nil
+}
+
+// pluginSourceClient returns an HTTP client authenticating requests to the
+// host of sourceURL, or to hostname when it is empty, with the token set in
+// the environment for hostname. A nil client is returned when there is no
+// token.
+func pluginSourceClient(hostname, sourceURL string) *http.Client {
+	envVar := PluginTokenEnvVarPrefix + strings.Map(func(r rune) rune {
+		if r == '.' || r == '-' {
+			return '_'
+		}
+		return r
+	}, hostname)
+	token := os.Getenv(envVar)
+	if token == "" {
+		return nil
+	}
+	log.Printf("[DEBUG] using %s for the plugins of %s", envVar, hostname)
+
+	host := hostname
+	if u, err := url.Parse(sourceURL); err == nil && u.Host != "" {
+		host = u.Host
+	}
+	return &http.Client{
+		Transport: &github.HostSpecificTokenAuthTransport{
+			TokenSources: map[string]oauth2.TokenSource{
+				host: oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token}),
+			},
+		},
+	}
+}
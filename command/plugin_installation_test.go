@@ -0,0 +1,29 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package command
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPluginSourceRegistry(t *testing.T) {
+	registry, err := pluginSourceRegistry(map[string]PluginSourceConfig{
+		"GitLab.Example.com": {Type: PluginSourceTypeGitLab},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expected := []string{"github.com", "gitlab.com", "gitlab.example.com"}
+	if got := registry.Hostnames(); strings.Join(got, ",") != strings.Join(expected, ",") {
+		t.Errorf("unexpected hostnames %v, expected %v", got, expected)
+	}
+
+	_, err = pluginSourceRegistry(map[string]PluginSourceConfig{
+		"gitlab.example.com:8443": {Type: PluginSourceTypeGitLab},
+	})
+	if err == nil || !strings.Contains(err.Error(), `"gitlab.example.com:8443"`) {
+		t.Errorf("expected the invalid hostname to be reported, got %v", err)
+	}
+}
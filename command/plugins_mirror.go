@@ -0,0 +1,199 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package command
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+	pluginsdk "github.com/hashicorp/packer-plugin-sdk/plugin"
+	"github.com/hashicorp/packer/hcl2template"
+	"github.com/hashicorp/packer/packer"
+	plugingetter "github.com/hashicorp/packer/packer/plugin-getter"
+	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
+)
+
+type PluginsMirrorCommand struct {
+	Meta
+}
+
+func (c *PluginsMirrorCommand) Synopsis() string {
+	return "Copy the plugins required by a config into a mirror directory"
+}
+
+func (c *PluginsMirrorCommand) Help() string {
+	helpText := `
+Usage: packer plugins mirror [options] <dir> [<path>]
+
+  This command will download every Packer plugin required by a Packer config,
+  in packer.required_plugins blocks, into a mirror directory. Plugins can then
+  be installed from that directory, or from an HTTP server serving it, with
+  the filesystem_mirror and network_mirror plugin installation settings.
+
+  The most recent version matching the version constraints is mirrored, unless
+  a version is set in the .packer.lock.hcl file of the config. Mirroring more
+  versions in the same directory keeps the previous ones.
+
+  When <path> is omitted, the config of the current directory is used.
+
+  Ex: packer plugins mirror -platform=linux_amd64,darwin_arm64 ./mirror
+  Ex: packer plugins mirror ./mirror path/to/folder/
+
+Options:
+  -platform=os_arch            Mirror plugins for this system. This flag can be
+                               repeated, or take a comma separated list.
+                               Defaults to the current system.
+`
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *PluginsMirrorCommand) Run(args []string) int {
+	ctx, cleanup := handleTermInterrupt(c.Ui)
+	defer cleanup()
+
+	cfg, ret := c.ParseArgs(args)
+	if ret != 0 {
+		return ret
+	}
+
+	return c.RunContext(ctx, cfg)
+}
+
+func (c *PluginsMirrorCommand) ParseArgs(args []string) (*PluginsMirrorArgs, int) {
+	var cfg PluginsMirrorArgs
+	flags := c.Meta.FlagSet("plugins mirror")
+	flags.Usage = func() { c.Ui.Say(c.Help()) }
+	cfg.AddFlagSets(flags)
+	if err := flags.Parse(args); err != nil {
+		return &cfg, 1
+	}
+
+	args = flags.Args()
+	if len(args) < 1 || len(args) > 2 {
+		return &cfg, cli.RunResultHelp
+	}
+	cfg.Dir = args[0]
+	cfg.Path = "."
+	if len(args) > 1 {
+		cfg.Path = args[1]
+	}
+	return &cfg, 0
+}
+
+func (c *PluginsMirrorCommand) RunContext(buildCtx context.Context, cla *PluginsMirrorArgs) int {
+	platforms, err := parsePlatforms(cla.Platforms)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+
+	packerStarter, ret := c.GetConfig(&cla.MetaArgs)
+	if ret != 0 {
+		return ret
+	}
+
+	// Get plugins requirements
+	reqs, diags := packerStarter.PluginRequirements()
+	ret = writeDiags(c.Ui, nil, diags)
+	if ret != 0 {
+		return ret
+	}
+
+	if len(reqs) == 0 {
+		c.Ui.Message(`
+No plugins requirement found, make sure you reference a Packer config
+containing a packer.required_plugins block. See
+https://www.packer.io/docs/templates/hcl_templates/blocks/packer
+for more info.`)
+		return 0
+	}
+
+	lock := &plugingetter.Lock{}
+	if hclCfg, ok := packerStarter.(*hcl2template.PackerConfig); ok {
+		lock, err = plugingetter.LoadLock(hclCfg.Basedir)
+		if err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+	}
+
+	ui := &packer.ColoredUi{
+		Color: packer.UiColorCyan,
+		Ui:    c.Ui,
+	}
+
+	getters, err := c.Meta.pluginGetters()
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+	for _, pluginRequirement := range reqs {
+		opts := plugingetter.MirrorOptions{
+			Getters:   getters,
+			Dir:       cla.Dir,
+			Platforms: platforms,
+		}
+		if locked := lock.Plugin(pluginRequirement.Identifier.String()); locked != nil {
+			v, err := version.NewVersion(locked.Version)
+			if err != nil {
+				c.Ui.Error(fmt.Sprintf("Invalid locked version %q for %s: %s", locked.Version, pluginRequirement.Identifier, err))
+				ret = 1
+				continue
+			}
+			opts.Version = v
+		}
+
+		mirrored, err := pluginRequirement.Mirror(opts)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Failed mirroring the %q plugin:", pluginRequirement.Identifier))
+			c.Ui.Error(err.Error())
+			ret = 1
+		}
+		if mirrored != nil {
+			for _, zip := range mirrored.Zips {
+				ui.Say(fmt.Sprintf("Mirrored plugin %s %s in %q", pluginRequirement.Identifier, mirrored.Version, zip))
+			}
+		}
+	}
+
+	return ret
+}
+
+// parsePlatforms parses os_arch pairs, the current system is returned when
+// there is none.
+func parsePlatforms(pairs []string) ([]plugingetter.BinaryInstallationOptions, error) {
+	if len(pairs) == 0 {
+		pairs = []string{runtime.GOOS + "_" + runtime.GOARCH}
+	}
+
+	var platforms []plugingetter.BinaryInstallationOptions
+	for _, pair := range pairs {
+		goos, goarch, found := strings.Cut(pair, "_")
+		if !found || goos == "" || goarch == "" {
+			return nil, fmt.Errorf("invalid platform %q, expected os_arch, like linux_amd64", pair)
+		}
+		platforms = append(platforms, plugingetter.BinaryInstallationOptions{
+			OS:              goos,
+			ARCH:            goarch,
+			APIVersionMajor: pluginsdk.APIVersionMajor,
+			APIVersionMinor: pluginsdk.APIVersionMinor,
+		})
+	}
+	return platforms, nil
+}
+
+func (*PluginsMirrorCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictDirs("*")
+}
+
+func (*PluginsMirrorCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{
+		"-platform": complete.PredictNothing,
+	}
+}
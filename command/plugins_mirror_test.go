@@ -0,0 +1,135 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package command
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	plugingetter "github.com/hashicorp/packer/packer/plugin-getter"
+)
+
+func TestPluginsMirrorCommand_Run(t *testing.T) {
+	platform := runtime.GOOS + "_" + runtime.GOARCH
+	source := testPluginMirror(t, "v1.2.5", platform, "freebsd_arm")
+	t.Setenv(FilesystemMirrorEnvVar, source)
+
+	configDir := t.TempDir()
+	config := `
+packer {
+  required_plugins {
+    amazon = {
+      source  = "github.com/hashicorp/amazon"
+      version = ">= 1.0.0"
+    }
+  }
+}`
+	if err := os.WriteFile(filepath.Join(configDir, "plugins.pkr.hcl"), []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	mirrorDir := t.TempDir()
+	c := &PluginsMirrorCommand{Meta: TestMetaFile(t)}
+	args := []string{"-platform=" + platform + ",freebsd_arm", mirrorDir, configDir}
+	if code := c.Run(args); code != 0 {
+		fatalCommand(t, c.Meta)
+	}
+
+	pluginDir := filepath.Join(mirrorDir, "github.com", "hashicorp", "amazon")
+	for _, name := range []string{
+		"releases.json",
+		filepath.Join("v1.2.5", "packer-plugin-amazon_v1.2.5_SHA256SUMS"),
+		filepath.Join("v1.2.5", "packer-plugin-amazon_v1.2.5_x5.0_"+platform+".zip"),
+		filepath.Join("v1.2.5", "packer-plugin-amazon_v1.2.5_x5.0_freebsd_arm.zip"),
+	} {
+		if _, err := os.Stat(filepath.Join(pluginDir, name)); err != nil {
+			t.Errorf("Expected %s to be mirrored: %s", name, err)
+		}
+	}
+
+	// packer init installs the plugin from the new mirror, and locks it.
+	t.Setenv(FilesystemMirrorEnvVar, mirrorDir)
+	pluginFolder := t.TempDir()
+	initCmd := &InitCommand{Meta: TestMetaFile(t)}
+	initCmd.CoreConfig.Components.PluginConfig.KnownPluginFolders = []string{pluginFolder}
+	if code := initCmd.Run([]string{configDir}); code != 0 {
+		fatalCommand(t, initCmd.Meta)
+	}
+
+	lock, err := plugingetter.LoadLock(configDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	locked := lock.Plugin("github.com/hashicorp/amazon")
+	if locked == nil || locked.Version != "v1.2.5" {
+		t.Fatalf("Expected v1.2.5 to be locked, got %#v", locked)
+	}
+	if len(locked.ZipSHA256) != 2 || len(locked.BinarySHA256) != 1 {
+		t.Errorf("Expected the checksums of the release to be locked, got %#v", locked)
+	}
+}
+
+func TestPluginsMirrorCommand_invalidPlatform(t *testing.T) {
+	c := &PluginsMirrorCommand{Meta: TestMetaFile(t)}
+	if code := c.Run([]string{"-platform=linux", t.TempDir(), t.TempDir()}); code != 1 {
+		t.Fatalf("Expected an invalid platform to fail, got %d", code)
+	}
+	out, stderr := GetStdoutAndErrFromTestMeta(t, c.Meta)
+	if !strings.Contains(stderr, `invalid platform "linux"`) {
+		t.Errorf("unexpected output: %s\n%s", out, stderr)
+	}
+}
+
+// testPluginMirror writes a filesystem mirror with a release of the amazon
+// plugin for each platform.
+func testPluginMirror(t *testing.T, version string, platforms ...string) string {
+	dir := t.TempDir()
+	releaseDir := filepath.Join(dir, "github.com", "hashicorp", "amazon", version)
+	if err := os.MkdirAll(releaseDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	releases := fmt.Sprintf(`[{"version": %q}]`, version)
+	if err := os.WriteFile(filepath.Join(dir, "github.com", "hashicorp", "amazon", "releases.json"), []byte(releases), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	sums := &strings.Builder{}
+	for _, platform := range platforms {
+		binary := fmt.Sprintf("packer-plugin-amazon_%s_x5.0_%s", version, platform)
+		buf := &bytes.Buffer{}
+		zw := zip.NewWriter(buf)
+		name := binary
+		if strings.HasPrefix(platform, "windows_") {
+			name += ".exe"
+		}
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(binary)); err != nil {
+			t.Fatal(err)
+		}
+		if err := zw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(releaseDir, binary+".zip"), buf.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+		sum := sha256.Sum256(buf.Bytes())
+		fmt.Fprintf(sums, "%s  %s.zip\n", hex.EncodeToString(sum[:]), binary)
+	}
+	if err := os.WriteFile(filepath.Join(releaseDir, "packer-plugin-amazon_"+version+"_SHA256SUMS"), []byte(sums.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
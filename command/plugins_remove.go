@@ -101,6 +101,12 @@ func (c *PluginsRemoveCommand) RunContext(buildCtx context.Context, args []strin
 			c.Ui.Error(fmt.Sprintf("failed to remove %s: %s", shasumFile, err))
 			c.Ui.Error("You may need to remove it manually")
 		}
+		// Only written by the installations of a release.
+		zipShasumFile := fmt.Sprintf("%s_ZIP_SHA256SUM", installation.BinaryPath)
+		if err := os.Remove(zipShasumFile); err != nil && !os.IsNotExist(err) {
+			c.Ui.Error(fmt.Sprintf("failed to remove %s: %s", zipShasumFile, err))
+			c.Ui.Error("You may need to remove it manually")
+		}
 		c.Ui.Message(installation.BinaryPath)
 	}
 
@@ -0,0 +1,24 @@
+source "null" "base" {
+	communicator = "none"
+}
+
+source "null" "derived" {
+	communicator = "none"
+	timeout      = "10m"
+}
+
+build {
+	name    = "derived"
+	sources = ["sources.null.derived"]
+
+	depends_on = [build.base]
+
+	provisioner "shell-local" {
+		inline = ["sleep 5"]
+	}
+}
+
+build {
+	name    = "base"
+	sources = ["sources.null.base"]
+}
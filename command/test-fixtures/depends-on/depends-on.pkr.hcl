@@ -0,0 +1,32 @@
+variable "fail" {
+	type    = bool
+	default = false
+}
+
+source "null" "base" {
+	communicator = "none"
+}
+
+source "null" "derived" {
+	communicator = "none"
+}
+
+build {
+	name    = "derived"
+	sources = ["sources.null.derived"]
+
+	depends_on = [build.base]
+
+	provisioner "shell-local" {
+		inline = ["echo ${build.base.artifacts[0].id} >> depends-on.txt"]
+	}
+}
+
+build {
+	name    = "base"
+	sources = ["sources.null.base"]
+
+	provisioner "shell-local" {
+		inline = [var.fail ? "exit 1" : "echo base > depends-on.txt"]
+	}
+}
@@ -0,0 +1,27 @@
+source "null" "ok" {
+  communicator = "none"
+}
+
+source "null" "broken" {
+  communicator = "none"
+}
+
+build {
+  sources = ["source.null.ok", "source.null.broken"]
+
+  provisioner "shell-local" {
+    name   = "hello"
+    inline = ["echo hello"]
+  }
+
+  provisioner "shell-local" {
+    name   = "fail"
+    only   = ["null.broken"]
+    inline = ["exit 1"]
+  }
+
+  post-processor "shell-local" {
+    name   = "notify"
+    inline = ["echo done"]
+  }
+}
@@ -0,0 +1,19 @@
+source "null" "slow" {
+  communicator = "none"
+  timeout      = "10m"
+}
+
+build {
+  source "null.slow" {
+    name = "short"
+  }
+
+  source "null.slow" {
+    name = "long"
+  }
+
+  provisioner "shell-local" {
+    only   = ["null.short"]
+    inline = ["sleep 5"]
+  }
+}
@@ -0,0 +1,20 @@
+variable "marker" {
+  type = string
+}
+
+source "null" "example" {
+  communicator = "none"
+}
+
+build {
+  sources = ["source.null.example"]
+
+  provisioner "shell-local" {
+    name   = "flaky"
+    inline = ["test -f ${var.marker} || { touch ${var.marker}; exit 3; }"]
+    retry {
+      max_retries         = 2
+      retry_on_exit_codes = [3]
+    }
+  }
+}
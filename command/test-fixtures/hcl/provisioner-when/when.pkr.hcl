@@ -0,0 +1,35 @@
+variable "install_gpu_drivers" {
+  type    = bool
+  default = false
+}
+
+source "null" "example" {
+  communicator = "none"
+}
+
+build {
+  sources = ["source.null.example"]
+
+  provisioner "shell-local" {
+    name   = "gpu-drivers"
+    when   = var.install_gpu_drivers
+    inline = ["echo installing gpu drivers"]
+  }
+
+  provisioner "shell-local" {
+    name   = "always"
+    inline = ["echo always running"]
+  }
+
+  provisioner "shell-local" {
+    name   = "on-host"
+    when   = build.ID != ""
+    inline = ["echo running with an ID"]
+  }
+
+  post-processor "shell-local" {
+    name   = "gpu-check"
+    when   = var.install_gpu_drivers
+    inline = ["echo checking gpu drivers"]
+  }
+}
@@ -0,0 +1,21 @@
+source "null" "example" {
+  communicator = "none"
+}
+
+build {
+  sources = ["source.null.example"]
+
+  provisioners {
+    name     = "downloads"
+    parallel = true
+
+    provisioner "shell-local" {
+      name   = "first"
+      inline = ["echo downloading first"]
+    }
+    provisioner "shell-local" {
+      name   = "second"
+      inline = ["echo downloading second"]
+    }
+  }
+}
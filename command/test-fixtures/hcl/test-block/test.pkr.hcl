@@ -0,0 +1,21 @@
+source "null" "example" {
+  communicator = "none"
+}
+
+build {
+  sources = ["source.null.example"]
+
+  test {
+    junit_report = "test-block-report.xml"
+
+    # commands run through the none communicator have no output.
+    assert "uptime" {
+      command        = "uptime"
+      stdout_matches = "load average"
+    }
+  }
+
+  post-processor "manifest" {
+    output = "test-block-manifest.json"
+  }
+}
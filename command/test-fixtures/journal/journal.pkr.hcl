@@ -0,0 +1,19 @@
+source "null" "resume" {
+	communicator = "none"
+}
+
+build {
+	sources = ["sources.null.resume"]
+
+	provisioner "shell-local" {
+		inline = ["echo first >> journal-first.txt"]
+	}
+
+	provisioner "shell-local" {
+		inline = ["test -f journal-ok.txt"]
+	}
+
+	provisioner "shell-local" {
+		inline = ["echo last > journal-last.txt"]
+	}
+}
@@ -0,0 +1,12 @@
+source "null" "example" {
+  communicator = "none"
+}
+
+build {
+  sources = ["source.null.example"]
+
+  provisioner "shell-local" {
+    timeout = "5m"
+    inline  = ["echo hello"]
+  }
+}
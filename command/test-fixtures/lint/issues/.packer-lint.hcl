@@ -0,0 +1,3 @@
+rule "unused-declarations" {
+  enabled = false
+}
@@ -0,0 +1,15 @@
+variable "unused" {
+  type = string
+}
+
+source "null" "example" {
+  communicator = "none"
+}
+
+build {
+  sources = ["source.null.example"]
+
+  provisioner "shell-local" {
+    inline = ["echo hello"]
+  }
+}
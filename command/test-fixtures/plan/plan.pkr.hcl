@@ -0,0 +1,40 @@
+source "null" "packer" {
+	communicator = "none"
+}
+
+source "null" "other" {
+	communicator = "none"
+}
+
+build {
+	sources = ["sources.null.packer", "null.other"]
+
+	provisioner "shell-local" {
+		name         = "setup"
+		inline       = ["echo setup"]
+		pause_before = "5s"
+		max_retries  = 3
+		only         = ["null.packer"]
+	}
+
+	provisioner "shell-local" {
+		inline  = ["echo configure"]
+		timeout = "10m"
+	}
+
+	error-cleanup-provisioner "shell-local" {
+		inline = ["echo cleanup"]
+	}
+
+	post-processor "manifest" {
+		output = "plan-manifest.json"
+	}
+
+	post-processors {
+		post-processor "shell-local" {
+			name   = "notify"
+			inline = ["echo notify"]
+			except = ["null.other"]
+		}
+	}
+}
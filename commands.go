@@ -59,6 +59,12 @@ func init() {
 			}, nil
 		},
 
+		"lint": func() (cli.Command, error) {
+			return &command.LintCommand{
+				Meta: *CommandMeta,
+			}, nil
+		},
+
 		"plugin": func() (cli.Command, error) {
 			return &command.PluginCommand{
 				Meta: *CommandMeta,
@@ -83,6 +89,12 @@ func init() {
 			}, nil
 		},
 
+		"plugins mirror": func() (cli.Command, error) {
+			return &command.PluginsMirrorCommand{
+				Meta: *CommandMeta,
+			}, nil
+		},
+
 		"plugins remove": func() (cli.Command, error) {
 			return &command.PluginsRemoveCommand{
 				Meta: *CommandMeta,
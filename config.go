@@ -30,6 +30,8 @@ type config struct {
 	RawProvisioners            map[string]string `json:"provisioners"`
 	RawPostProcessors          map[string]string `json:"post-processors"`
 
+	PluginInstallation *command.PluginInstallationConfig `json:"plugin_installation"`
+
 	Plugins *packer.PluginConfig
 }
 
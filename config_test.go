@@ -13,6 +13,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/hashicorp/packer/command"
 	"github.com/hashicorp/packer/packer"
 )
 
@@ -43,6 +44,38 @@ func TestDecodeConfig(t *testing.T) {
 
 }
 
+func TestDecodeConfig_pluginInstallation(t *testing.T) {
+	packerConfig := `
+	{
+		"plugin_installation": {
+			"filesystem_mirror": ["/opt/packer/mirror"],
+			"network_mirror": ["https://mirror.example.com/packer/"],
+			"direct": false,
+			"sources": {
+				"gitlab.example.com": {"type": "gitlab", "url": "https://gitlab.example.com/api/v4/"}
+			}
+		}
+	}`
+
+	var cfg config
+	if err := decodeConfig(strings.NewReader(packerConfig), &cfg); err != nil {
+		t.Fatalf("error encountered decoding configuration: %v", err)
+	}
+
+	direct := false
+	expected := &command.PluginInstallationConfig{
+		FilesystemMirrors: []string{"/opt/packer/mirror"},
+		NetworkMirrors:    []string{"https://mirror.example.com/packer/"},
+		Direct:            &direct,
+		Sources: map[string]command.PluginSourceConfig{
+			"gitlab.example.com": {Type: "gitlab", URL: "https://gitlab.example.com/api/v4/"},
+		},
+	}
+	if !reflect.DeepEqual(cfg.PluginInstallation, expected) {
+		t.Errorf("failed to load plugin installation config; expected %#v got %#v", expected, cfg.PluginInstallation)
+	}
+}
+
 func TestLoadExternalComponentsFromConfig(t *testing.T) {
 	packerConfigData, cleanUpFunc, err := generateFakePackerConfigData()
 	if err != nil {
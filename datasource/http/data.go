@@ -2,32 +2,75 @@
 // SPDX-License-Identifier: BUSL-1.1
 
 //go:generate packer-sdc struct-markdown
-//go:generate packer-sdc mapstructure-to-hcl2 -type DatasourceOutput,Config
+//go:generate packer-sdc mapstructure-to-hcl2 -type DatasourceOutput,Config,RetryConfig
 package http
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
 	"io"
+	"log"
 	"mime"
 	"net/http"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/hcl/v2/hcldec"
 	"github.com/hashicorp/packer-plugin-sdk/common"
 	"github.com/hashicorp/packer-plugin-sdk/hcl2helper"
 	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-sdk/retry"
 	"github.com/hashicorp/packer-plugin-sdk/template/config"
 	"github.com/zclconf/go-cty/cty"
 )
 
 type Config struct {
 	common.PackerConfig `mapstructure:",squash"`
-	// The URL to request data from. This URL must respond with a `200 OK` response and a `text/*` or `application/json` Content-Type.
+	// The URL to request data from. This URL must respond with one of the
+	// `accepted_status_codes` and a `text/*` or `application/json` Content-Type.
 	Url string `mapstructure:"url" required:"true"`
+	// The HTTP method of the request. Defaults to `GET`.
+	Method string `mapstructure:"method" required:"false"`
 	// A map of strings representing additional HTTP headers to include in the request.
 	RequestHeaders map[string]string `mapstructure:"request_headers" required:"false"`
+	// The body of the request.
+	RequestBody string `mapstructure:"request_body" required:"false"`
+	// The timeout of each request, in milliseconds. Defaults to no timeout.
+	RequestTimeoutMs int `mapstructure:"request_timeout_ms" required:"false"`
+	// Retries the request when it fails to be sent, or when the server
+	// responds with a `429` or `5xx` status code that is not accepted. See
+	// the [retry](#retry) block.
+	Retry RetryConfig `mapstructure:"retry" required:"false"`
+	// The status codes considered successful. Defaults to `[200]`.
+	AcceptedStatusCodes []int `mapstructure:"accepted_status_codes" required:"false"`
+	// Disables the verification of the server certificate.
+	Insecure bool `mapstructure:"insecure" required:"false"`
+	// PEM encoded certificate authorities used to verify the server
+	// certificate, instead of the ones of the system.
+	CaCertPem string `mapstructure:"ca_cert_pem" required:"false"`
+	// PEM encoded client certificate, used along with `client_key_pem` to
+	// authenticate with the server.
+	ClientCertPem string `mapstructure:"client_cert_pem" required:"false"`
+	// PEM encoded private key of `client_cert_pem`.
+	ClientKeyPem string `mapstructure:"client_key_pem" required:"false"`
+}
+
+// RetryConfig configures how a failed request is retried, with a delay
+// doubling after each attempt.
+type RetryConfig struct {
+	// The number of times the request is retried. Defaults to 0, the request
+	// is not retried.
+	Attempts int `mapstructure:"attempts" required:"false"`
+	// The delay before the first retry, in milliseconds. The delay doubles
+	// after each retry. Defaults to 1000.
+	MinDelayMs int `mapstructure:"min_delay_ms" required:"false"`
+	// The maximum delay between two retries, in milliseconds. Defaults to
+	// no maximum.
+	MaxDelayMs int `mapstructure:"max_delay_ms" required:"false"`
 }
 
 type Datasource struct {
@@ -37,6 +80,8 @@ type Datasource struct {
 type DatasourceOutput struct {
 	// The URL the data was requested from.
 	Url string `mapstructure:"url"`
+	// The status code of the HTTP response.
+	StatusCode int `mapstructure:"status_code"`
 	// The raw body of the HTTP response.
 	ResponseBody string `mapstructure:"body"`
 	// A map of strings representing the response HTTP headers.
@@ -44,6 +89,16 @@ type DatasourceOutput struct {
 	ResponseHeaders map[string]string `mapstructure:"request_headers"`
 }
 
+var allowedMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+	http.MethodOptions,
+}
+
 func (d *Datasource) ConfigSpec() hcldec.ObjectSpec {
 	return d.config.FlatMapstructure().HCL2Spec()
 }
@@ -62,6 +117,58 @@ func (d *Datasource) Configure(raws ...interface{}) error {
 			fmt.Errorf("the `url` must be specified"))
 	}
 
+	if d.config.Method == "" {
+		d.config.Method = http.MethodGet
+	}
+	d.config.Method = strings.ToUpper(d.config.Method)
+	validMethod := false
+	for _, method := range allowedMethods {
+		if d.config.Method == method {
+			validMethod = true
+		}
+	}
+	if !validMethod {
+		errs = packersdk.MultiErrorAppend(
+			errs,
+			fmt.Errorf("the `method` must be one of %v", allowedMethods))
+	}
+
+	if d.config.RequestTimeoutMs < 0 {
+		errs = packersdk.MultiErrorAppend(
+			errs,
+			fmt.Errorf("the `request_timeout_ms` must be positive"))
+	}
+
+	if d.config.Retry.Attempts < 0 || d.config.Retry.MinDelayMs < 0 || d.config.Retry.MaxDelayMs < 0 {
+		errs = packersdk.MultiErrorAppend(
+			errs,
+			fmt.Errorf("the `retry` attempts and delays must be positive"))
+	}
+	if d.config.Retry.MinDelayMs == 0 {
+		d.config.Retry.MinDelayMs = 1000
+	}
+	if d.config.Retry.MaxDelayMs != 0 && d.config.Retry.MaxDelayMs < d.config.Retry.MinDelayMs {
+		errs = packersdk.MultiErrorAppend(
+			errs,
+			fmt.Errorf("the `retry` max_delay_ms must be greater than min_delay_ms"))
+	}
+
+	if len(d.config.AcceptedStatusCodes) == 0 {
+		d.config.AcceptedStatusCodes = []int{http.StatusOK}
+	}
+
+	if (d.config.ClientCertPem == "") != (d.config.ClientKeyPem == "") {
+		errs = packersdk.MultiErrorAppend(
+			errs,
+			fmt.Errorf("`client_cert_pem` and `client_key_pem` must be set together"))
+	}
+
+	if errs == nil {
+		if _, err := d.tlsConfig(); err != nil {
+			errs = packersdk.MultiErrorAppend(errs, err)
+		}
+	}
+
 	if errs != nil && len(errs.Errors) > 0 {
 		return errs
 	}
@@ -72,6 +179,36 @@ func (d *Datasource) OutputSpec() hcldec.ObjectSpec {
 	return (&DatasourceOutput{}).FlatMapstructure().HCL2Spec()
 }
 
+// tlsConfig returns the TLS configuration of the requests, or nil when the
+// default one can be used.
+func (d *Datasource) tlsConfig() (*tls.Config, error) {
+	if !d.config.Insecure && d.config.CaCertPem == "" && d.config.ClientCertPem == "" {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: d.config.Insecure,
+	}
+
+	if d.config.CaCertPem != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(d.config.CaCertPem)) {
+			return nil, fmt.Errorf("the `ca_cert_pem` does not contain any valid certificate")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if d.config.ClientCertPem != "" {
+		cert, err := tls.X509KeyPair([]byte(d.config.ClientCertPem), []byte(d.config.ClientKeyPem))
+		if err != nil {
+			return nil, fmt.Errorf("invalid client certificate: %s", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
 // This is to prevent potential issues w/ binary files
 // and generally unprintable characters
 // See https://github.com/hashicorp/terraform/pull/3858#issuecomment-156856738
@@ -98,50 +235,115 @@ func isContentTypeText(contentType string) bool {
 	return false
 }
 
+// statusError is returned when the status code of a response is not one of
+// the accepted status codes.
+type statusError struct {
+	StatusCode int
+}
+
+func (err *statusError) Error() string {
+	return fmt.Sprintf("HTTP request error. Response code: %d", err.StatusCode)
+}
+
+// isRetryable tells whether a request that failed with err can be retried:
+// when it could not be sent, or when the server is unavailable or throttling.
+func isRetryable(err error) bool {
+	var statusErr *statusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode == http.StatusTooManyRequests || statusErr.StatusCode >= 500
+	}
+	return true
+}
+
 // Most of this code comes from http terraform provider data source
 // https://github.com/hashicorp/terraform-provider-http/blob/main/internal/provider/data_source.go
 func (d *Datasource) Execute() (cty.Value, error) {
-	ctx := context.TODO()
-	url, headers := d.config.Url, d.config.RequestHeaders
-	client := &http.Client{}
+	tlsConfig, err := d.tlsConfig()
+	if err != nil {
+		return cty.NullVal(cty.EmptyObject), err
+	}
 
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	// TODO: How to make a test case for this?
+	client := &http.Client{
+		Timeout: time.Duration(d.config.RequestTimeoutMs) * time.Millisecond,
+	}
+	if tlsConfig != nil {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = tlsConfig
+		client.Transport = transport
+	}
+
+	backoff := retry.Backoff{
+		InitialBackoff: time.Duration(d.config.Retry.MinDelayMs) * time.Millisecond,
+		MaxBackoff:     time.Duration(d.config.Retry.MaxDelayMs) * time.Millisecond,
+		Multiplier:     2,
+	}
+	retries := 0
+
+	var output DatasourceOutput
+	err = retry.Config{
+		RetryDelay: backoff.Linear,
+		ShouldRetry: func(err error) bool {
+			retries++
+			return retries <= d.config.Retry.Attempts && isRetryable(err)
+		},
+	}.Run(context.Background(), func(ctx context.Context) error {
+		var err error
+		output, err = d.request(ctx, client)
+		return err
+	})
 	if err != nil {
-		fmt.Println("Error creating http request")
 		return cty.NullVal(cty.EmptyObject), err
 	}
 
-	for name, value := range headers {
+	return hcl2helper.HCL2ValueFromConfig(output, d.OutputSpec()), nil
+}
+
+// request sends a single request and reads its response.
+func (d *Datasource) request(ctx context.Context, client *http.Client) (DatasourceOutput, error) {
+	var output DatasourceOutput
+
+	var body io.Reader
+	if d.config.RequestBody != "" {
+		body = strings.NewReader(d.config.RequestBody)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, d.config.Method, d.config.Url, body)
+	if err != nil {
+		return output, fmt.Errorf("error creating http request: %s", err)
+	}
+
+	for name, value := range d.config.RequestHeaders {
 		req.Header.Set(name, value)
 	}
 
 	resp, err := client.Do(req)
-	// TODO: How to make test case for this
 	if err != nil {
-		fmt.Println("Error making performing http request")
-		return cty.NullVal(cty.EmptyObject), err
+		return output, fmt.Errorf("error performing http request: %s", err)
 	}
 
 	defer resp.Body.Close()
 
-	if resp.StatusCode != 200 {
-		return cty.NullVal(cty.EmptyObject), fmt.Errorf("HTTP request error. Response code: %d", resp.StatusCode)
+	accepted := false
+	for _, code := range d.config.AcceptedStatusCodes {
+		if resp.StatusCode == code {
+			accepted = true
+			break
+		}
+	}
+	if !accepted {
+		return output, &statusError{StatusCode: resp.StatusCode}
 	}
 
 	contentType := resp.Header.Get("Content-Type")
 	if contentType == "" || isContentTypeText(contentType) == false {
-		fmt.Println(fmt.Sprintf(
-			"Content-Type is not recognized as a text type, got %q",
-			contentType))
-		fmt.Println("If the content is binary data, Packer may not properly handle the contents of the response.")
+		log.Printf("[WARN] Content-Type is not recognized as a text type, got %q. "+
+			"If the content is binary data, Packer may not properly handle the "+
+			"contents of the response.", contentType)
 	}
 
 	bytes, err := io.ReadAll(resp.Body)
-	// TODO: How to make test case for this?
 	if err != nil {
-		fmt.Println("Error processing response body of call")
-		return cty.NullVal(cty.EmptyObject), err
+		return output, fmt.Errorf("error processing response body of call: %s", err)
 	}
 
 	responseHeaders := make(map[string]string)
@@ -151,10 +353,11 @@ func (d *Datasource) Execute() (cty.Value, error) {
 		responseHeaders[k] = strings.Join(v, ", ")
 	}
 
-	output := DatasourceOutput{
+	output = DatasourceOutput{
 		Url:             d.config.Url,
+		StatusCode:      resp.StatusCode,
 		ResponseHeaders: responseHeaders,
 		ResponseBody:    string(bytes),
 	}
-	return hcl2helper.HCL2ValueFromConfig(output, d.OutputSpec()), nil
+	return output, nil
 }
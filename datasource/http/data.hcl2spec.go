@@ -19,7 +19,16 @@ type FlatConfig struct {
 	PackerUserVars      map[string]string `mapstructure:"packer_user_variables" cty:"packer_user_variables" hcl:"packer_user_variables"`
 	PackerSensitiveVars []string          `mapstructure:"packer_sensitive_variables" cty:"packer_sensitive_variables" hcl:"packer_sensitive_variables"`
 	Url                 *string           `mapstructure:"url" required:"true" cty:"url" hcl:"url"`
+	Method              *string           `mapstructure:"method" required:"false" cty:"method" hcl:"method"`
 	RequestHeaders      map[string]string `mapstructure:"request_headers" required:"false" cty:"request_headers" hcl:"request_headers"`
+	RequestBody         *string           `mapstructure:"request_body" required:"false" cty:"request_body" hcl:"request_body"`
+	RequestTimeoutMs    *int              `mapstructure:"request_timeout_ms" required:"false" cty:"request_timeout_ms" hcl:"request_timeout_ms"`
+	Retry               *FlatRetryConfig  `mapstructure:"retry" required:"false" cty:"retry" hcl:"retry"`
+	AcceptedStatusCodes []int             `mapstructure:"accepted_status_codes" required:"false" cty:"accepted_status_codes" hcl:"accepted_status_codes"`
+	Insecure            *bool             `mapstructure:"insecure" required:"false" cty:"insecure" hcl:"insecure"`
+	CaCertPem           *string           `mapstructure:"ca_cert_pem" required:"false" cty:"ca_cert_pem" hcl:"ca_cert_pem"`
+	ClientCertPem       *string           `mapstructure:"client_cert_pem" required:"false" cty:"client_cert_pem" hcl:"client_cert_pem"`
+	ClientKeyPem        *string           `mapstructure:"client_key_pem" required:"false" cty:"client_key_pem" hcl:"client_key_pem"`
 }
 
 // FlatMapstructure returns a new FlatConfig.
@@ -43,7 +52,16 @@ func (*FlatConfig) HCL2Spec() map[string]hcldec.Spec {
 		"packer_user_variables":      &hcldec.AttrSpec{Name: "packer_user_variables", Type: cty.Map(cty.String), Required: false},
 		"packer_sensitive_variables": &hcldec.AttrSpec{Name: "packer_sensitive_variables", Type: cty.List(cty.String), Required: false},
 		"url":                        &hcldec.AttrSpec{Name: "url", Type: cty.String, Required: false},
+		"method":                     &hcldec.AttrSpec{Name: "method", Type: cty.String, Required: false},
 		"request_headers":            &hcldec.AttrSpec{Name: "request_headers", Type: cty.Map(cty.String), Required: false},
+		"request_body":               &hcldec.AttrSpec{Name: "request_body", Type: cty.String, Required: false},
+		"request_timeout_ms":         &hcldec.AttrSpec{Name: "request_timeout_ms", Type: cty.Number, Required: false},
+		"retry":                      &hcldec.BlockSpec{TypeName: "retry", Nested: hcldec.ObjectSpec((*FlatRetryConfig)(nil).HCL2Spec())},
+		"accepted_status_codes":      &hcldec.AttrSpec{Name: "accepted_status_codes", Type: cty.List(cty.Number), Required: false},
+		"insecure":                   &hcldec.AttrSpec{Name: "insecure", Type: cty.Bool, Required: false},
+		"ca_cert_pem":                &hcldec.AttrSpec{Name: "ca_cert_pem", Type: cty.String, Required: false},
+		"client_cert_pem":            &hcldec.AttrSpec{Name: "client_cert_pem", Type: cty.String, Required: false},
+		"client_key_pem":             &hcldec.AttrSpec{Name: "client_key_pem", Type: cty.String, Required: false},
 	}
 	return s
 }
@@ -52,6 +70,7 @@ func (*FlatConfig) HCL2Spec() map[string]hcldec.Spec {
 // Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
 type FlatDatasourceOutput struct {
 	Url             *string           `mapstructure:"url" cty:"url" hcl:"url"`
+	StatusCode      *int              `mapstructure:"status_code" cty:"status_code" hcl:"status_code"`
 	ResponseBody    *string           `mapstructure:"body" cty:"body" hcl:"body"`
 	ResponseHeaders map[string]string `mapstructure:"request_headers" cty:"request_headers" hcl:"request_headers"`
 }
@@ -69,8 +88,36 @@ func (*DatasourceOutput) FlatMapstructure() interface{ HCL2Spec() map[string]hcl
 func (*FlatDatasourceOutput) HCL2Spec() map[string]hcldec.Spec {
 	s := map[string]hcldec.Spec{
 		"url":             &hcldec.AttrSpec{Name: "url", Type: cty.String, Required: false},
+		"status_code":     &hcldec.AttrSpec{Name: "status_code", Type: cty.Number, Required: false},
 		"body":            &hcldec.AttrSpec{Name: "body", Type: cty.String, Required: false},
 		"request_headers": &hcldec.AttrSpec{Name: "request_headers", Type: cty.Map(cty.String), Required: false},
 	}
 	return s
 }
+
+// FlatRetryConfig is an auto-generated flat version of RetryConfig.
+// Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
+type FlatRetryConfig struct {
+	Attempts   *int `mapstructure:"attempts" required:"false" cty:"attempts" hcl:"attempts"`
+	MinDelayMs *int `mapstructure:"min_delay_ms" required:"false" cty:"min_delay_ms" hcl:"min_delay_ms"`
+	MaxDelayMs *int `mapstructure:"max_delay_ms" required:"false" cty:"max_delay_ms" hcl:"max_delay_ms"`
+}
+
+// FlatMapstructure returns a new FlatRetryConfig.
+// FlatRetryConfig is an auto-generated flat version of RetryConfig.
+// Where the contents a fields with a `mapstructure:,squash` tag are bubbled up.
+func (*RetryConfig) FlatMapstructure() interface{ HCL2Spec() map[string]hcldec.Spec } {
+	return new(FlatRetryConfig)
+}
+
+// HCL2Spec returns the hcl spec of a RetryConfig.
+// This spec is used by HCL to read the fields of RetryConfig.
+// The decoded values from this spec will then be applied to a FlatRetryConfig.
+func (*FlatRetryConfig) HCL2Spec() map[string]hcldec.Spec {
+	s := map[string]hcldec.Spec{
+		"attempts":     &hcldec.AttrSpec{Name: "attempts", Type: cty.Number, Required: false},
+		"min_delay_ms": &hcldec.AttrSpec{Name: "min_delay_ms", Type: cty.Number, Required: false},
+		"max_delay_ms": &hcldec.AttrSpec{Name: "max_delay_ms", Type: cty.Number, Required: false},
+	}
+	return s
+}
@@ -0,0 +1,208 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package http
+
+import (
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+func executeDatasource(t *testing.T, raw map[string]interface{}) (cty.Value, error) {
+	t.Helper()
+
+	d := &Datasource{}
+	if err := d.Configure(raw); err != nil {
+		t.Fatalf("failed to configure datasource: %s", err)
+	}
+	return d.Execute()
+}
+
+func TestDatasource_Configure(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     map[string]interface{}
+		wantErr string
+	}{
+		{"defaults", map[string]interface{}{"url": "http://localhost"}, ""},
+		{"no url", map[string]interface{}{}, "the `url` must be specified"},
+		{"bad method", map[string]interface{}{"url": "http://localhost", "method": "FETCH"}, "the `method` must be one of"},
+		{"lower case method", map[string]interface{}{"url": "http://localhost", "method": "post"}, ""},
+		{"negative timeout", map[string]interface{}{"url": "http://localhost", "request_timeout_ms": -1}, "request_timeout_ms"},
+		{"client cert without key", map[string]interface{}{"url": "http://localhost", "client_cert_pem": "cert"}, "must be set together"},
+		{"bad ca", map[string]interface{}{"url": "http://localhost", "ca_cert_pem": "nope"}, "does not contain any valid certificate"},
+		{"bad retry delays", map[string]interface{}{
+			"url":   "http://localhost",
+			"retry": map[string]interface{}{"min_delay_ms": 100, "max_delay_ms": 10},
+		}, "max_delay_ms must be greater than min_delay_ms"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &Datasource{}
+			err := d.Configure(tt.raw)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestDatasource_Execute(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Method", r.Method)
+		if r.URL.Path == "/created" {
+			w.WriteHeader(http.StatusCreated)
+		}
+		_, _ = w.Write([]byte(`{"query": "` + string(body) + `", "token": "` + r.Header.Get("Authorization") + `"}`))
+	}))
+	defer server.Close()
+
+	t.Run("post", func(t *testing.T) {
+		val, err := executeDatasource(t, map[string]interface{}{
+			"url":             server.URL,
+			"method":          "POST",
+			"request_body":    "images",
+			"request_headers": map[string]string{"Authorization": "secret"},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := val.GetAttr("body").AsString(), `{"query": "images", "token": "secret"}`; got != want {
+			t.Errorf("unexpected body %q, expected %q", got, want)
+		}
+		if got := val.GetAttr("request_headers").Index(cty.StringVal("X-Method")).AsString(); got != "POST" {
+			t.Errorf("unexpected method %q", got)
+		}
+		if got := val.GetAttr("status_code"); !got.RawEquals(cty.NumberIntVal(200)) {
+			t.Errorf("unexpected status code %#v", got)
+		}
+	})
+
+	t.Run("status not accepted", func(t *testing.T) {
+		_, err := executeDatasource(t, map[string]interface{}{
+			"url": server.URL + "/created",
+		})
+		if err == nil || !strings.Contains(err.Error(), "Response code: 201") {
+			t.Fatalf("expected a status code error, got %v", err)
+		}
+	})
+
+	t.Run("status accepted", func(t *testing.T) {
+		val, err := executeDatasource(t, map[string]interface{}{
+			"url":                   server.URL + "/created",
+			"accepted_status_codes": []int{200, 201},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := val.GetAttr("status_code"); !got.RawEquals(cty.NumberIntVal(201)) {
+			t.Errorf("unexpected status code %#v", got)
+		}
+	})
+}
+
+func TestDatasource_Execute_retry(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch atomic.AddInt32(&calls, 1) {
+		case 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case 2:
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			w.Header().Set("Content-Type", "text/plain")
+			_, _ = w.Write([]byte("ok"))
+		}
+	}))
+	defer server.Close()
+
+	_, err := executeDatasource(t, map[string]interface{}{
+		"url":   server.URL,
+		"retry": map[string]interface{}{"attempts": 1, "min_delay_ms": 1},
+	})
+	if err == nil || !strings.Contains(err.Error(), "Response code: 429") {
+		t.Fatalf("expected the last error after exhausting retries, got %v", err)
+	}
+
+	atomic.StoreInt32(&calls, 0)
+	val, err := executeDatasource(t, map[string]interface{}{
+		"url":   server.URL,
+		"retry": map[string]interface{}{"attempts": 2, "min_delay_ms": 1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := val.GetAttr("body").AsString(); got != "ok" {
+		t.Errorf("unexpected body %q", got)
+	}
+}
+
+func TestDatasource_Execute_noRetryOnClientError(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	_, err := executeDatasource(t, map[string]interface{}{
+		"url":   server.URL,
+		"retry": map[string]interface{}{"attempts": 3, "min_delay_ms": 1},
+	})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if calls != 1 {
+		t.Fatalf("a 404 should not be retried, got %d calls", calls)
+	}
+}
+
+func TestDatasource_Execute_tls(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = w.Write([]byte("secure"))
+	}))
+	defer server.Close()
+
+	d := &Datasource{}
+	if err := d.Configure(map[string]interface{}{"url": server.URL}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.Execute(); err == nil {
+		t.Fatal("expected the self-signed certificate to be rejected")
+	}
+
+	caPem := string(pem.EncodeToMemory(&pem.Block{
+		Type:  "CERTIFICATE",
+		Bytes: server.Certificate().Raw,
+	}))
+	for name, raw := range map[string]map[string]interface{}{
+		"ca_cert_pem": {"url": server.URL, "ca_cert_pem": caPem},
+		"insecure":    {"url": server.URL, "insecure": true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			val, err := executeDatasource(t, raw)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := val.GetAttr("body").AsString(); got != "secure" {
+				t.Errorf("unexpected body %q", got)
+			}
+		})
+	}
+}
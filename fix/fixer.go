@@ -3,6 +3,8 @@
 
 package fix
 
+import "github.com/hashicorp/hcl/v2/hclwrite"
+
 // A Fixer is something that can perform a fix operation on a template.
 type Fixer interface {
 	// DeprecatedOptions returns the name(s) of the option(s) being replaced in
@@ -16,6 +18,10 @@ type Fixer interface {
 	// Fix method is allowed to mutate the input.
 	Fix(input map[string]interface{}) (map[string]interface{}, error)
 
+	// FixHCL does the same for an HCL2 template file, transforming its
+	// syntax tree in place.
+	FixHCL(f *hclwrite.File) error
+
 	// Synopsis returns a string description of what the fixer actually
 	// does.
 	Synopsis() string
@@ -6,6 +6,7 @@ package fix
 import (
 	"strings"
 
+	"github.com/hashicorp/hcl/v2/hclwrite"
 	"github.com/mitchellh/mapstructure"
 )
 
@@ -64,6 +65,17 @@ func (FixerAmazonEnhancedNetworking) Fix(input map[string]interface{}) (map[stri
 	return input, nil
 }
 
+func (FixerAmazonEnhancedNetworking) FixHCL(f *hclwrite.File) error {
+	for _, source := range hclSources(f) {
+		if !strings.HasPrefix(source.Type, "amazon-") {
+			continue
+		}
+
+		renameHCLAttribute(source.Body, "enhanced_networking", "ena_support")
+	}
+	return nil
+}
+
 func (FixerAmazonEnhancedNetworking) Synopsis() string {
 	return `Replaces "enhanced_networking" in builders with "ena_support"`
 }
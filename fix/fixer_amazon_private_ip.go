@@ -8,6 +8,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/hashicorp/hcl/v2/hclwrite"
 	"github.com/mitchellh/mapstructure"
 )
 
@@ -78,6 +79,25 @@ func (FixerAmazonPrivateIP) Fix(input map[string]interface{}) (map[string]interf
 	return input, nil
 }
 
+func (FixerAmazonPrivateIP) FixHCL(f *hclwrite.File) error {
+	for _, source := range hclSources(f) {
+		if !strings.HasPrefix(source.Type, "amazon-") {
+			continue
+		}
+
+		// if ssh_interface already set, do nothing
+		if source.Body.GetAttribute("ssh_interface") != nil {
+			continue
+		}
+
+		attr := renameHCLAttribute(source.Body, "ssh_private_ip", "ssh_interface")
+		if attr != nil {
+			source.Body.SetAttributeRaw("ssh_interface", hclBoolChoice(attr, "private_ip", "public_ip"))
+		}
+	}
+	return nil
+}
+
 func (FixerAmazonPrivateIP) Synopsis() string {
 	return "Replaces `\"ssh_private_ip\": true` in amazon builders with `\"ssh_interface\": \"private_ip\"`"
 }
@@ -6,6 +6,7 @@ package fix
 import (
 	"strings"
 
+	"github.com/hashicorp/hcl/v2/hclwrite"
 	"github.com/mitchellh/mapstructure"
 )
 
@@ -58,6 +59,17 @@ func (FixerAmazonShutdownBehavior) Fix(input map[string]interface{}) (map[string
 	return input, nil
 }
 
+func (FixerAmazonShutdownBehavior) FixHCL(f *hclwrite.File) error {
+	for _, source := range hclSources(f) {
+		if !strings.HasPrefix(source.Type, "amazon-") {
+			continue
+		}
+
+		renameHCLAttribute(source.Body, "shutdown_behaviour", "shutdown_behavior")
+	}
+	return nil
+}
+
 func (FixerAmazonShutdownBehavior) Synopsis() string {
 	return `Changes "shutdown_behaviour" to "shutdown_behavior" in Amazon builders.`
 }
@@ -4,6 +4,7 @@
 package fix
 
 import (
+	"github.com/hashicorp/hcl/v2/hclwrite"
 	"github.com/mitchellh/mapstructure"
 )
 
@@ -64,6 +65,24 @@ func (FixerAmazonSpotPriceProductDeprecation) Fix(input map[string]interface{})
 	return input, nil
 }
 
+func (FixerAmazonSpotPriceProductDeprecation) FixHCL(f *hclwrite.File) error {
+	buildersToFix := map[string]bool{
+		"amazon-ebs":          true,
+		"amazon-ebssurrogate": true,
+		"amazon-ebsvolume":    true,
+		"amazon-instance":     true,
+	}
+
+	for _, source := range hclSources(f) {
+		if !buildersToFix[source.Type] {
+			continue
+		}
+
+		source.Body.RemoveAttribute("spot_price_auto_product")
+	}
+	return nil
+}
+
 func (FixerAmazonSpotPriceProductDeprecation) Synopsis() string {
 	return `Removes the deprecated "spot_price_auto_product" setting from Amazon builder templates`
 }
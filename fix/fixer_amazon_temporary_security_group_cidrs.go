@@ -6,6 +6,7 @@ package fix
 import (
 	"strings"
 
+	"github.com/hashicorp/hcl/v2/hclwrite"
 	"github.com/mitchellh/mapstructure"
 )
 
@@ -58,6 +59,21 @@ func (FixerAmazonTemporarySecurityCIDRs) Fix(input map[string]interface{}) (map[
 	return input, nil
 }
 
+func (FixerAmazonTemporarySecurityCIDRs) FixHCL(f *hclwrite.File) error {
+	for _, source := range hclSources(f) {
+		if !strings.HasPrefix(source.Type, "amazon-") {
+			continue
+		}
+
+		attr := renameHCLAttribute(source.Body, "temporary_security_group_source_cidr", "temporary_security_group_source_cidrs")
+		if attr != nil {
+			cidrs := hclwrite.TokensForTuple([]hclwrite.Tokens{hclExprTokens(attr)})
+			source.Body.SetAttributeRaw("temporary_security_group_source_cidrs", cidrs)
+		}
+	}
+	return nil
+}
+
 func (FixerAmazonTemporarySecurityCIDRs) Synopsis() string {
 	return `Replaces "temporary_security_group_source_cidr" (string) with "temporary_security_group_source_cidrs" (list of strings)`
 }
@@ -6,6 +6,7 @@ package fix
 import (
 	"strings"
 
+	"github.com/hashicorp/hcl/v2/hclwrite"
 	"github.com/mitchellh/mapstructure"
 )
 
@@ -70,6 +71,21 @@ func (FixerAzureExcludeFromLatest) Fix(input map[string]interface{}) (map[string
 	return input, nil
 }
 
+func (FixerAzureExcludeFromLatest) FixHCL(f *hclwrite.File) error {
+	for _, source := range hclSources(f) {
+		if !strings.HasPrefix(source.Type, "azure-chroot") {
+			continue
+		}
+
+		for _, block := range source.Body.Blocks() {
+			if block.Type() == "shared_image_destination" {
+				renameHCLAttribute(block.Body(), "exlude_from_latest", "exclude_from_latest")
+			}
+		}
+	}
+	return nil
+}
+
 func (FixerAzureExcludeFromLatest) Synopsis() string {
 	return `Changes "exlude_from_latest" to "exclude_from_latest" in Azure builders.`
 }
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"regexp"
 
+	"github.com/hashicorp/hcl/v2/hclwrite"
 	"github.com/mitchellh/mapstructure"
 )
 
@@ -68,6 +69,27 @@ func (FixerCleanImageName) Fix(input map[string]interface{}) (map[string]interfa
 	return input, nil
 }
 
+func (FixerCleanImageName) FixHCL(f *hclwrite.File) error {
+	re := regexp.MustCompile(`clean_(image|ami)_name`)
+
+	var fixBody func(body *hclwrite.Body)
+	fixBody = func(body *hclwrite.Body) {
+		for _, attr := range body.Attributes() {
+			replaceInHCLStrings(attr.Expr().BuildTokens(nil), func(s string) string {
+				return re.ReplaceAllString(s, "clean_resource_name")
+			})
+		}
+		for _, block := range body.Blocks() {
+			fixBody(block.Body())
+		}
+	}
+
+	for _, source := range hclSources(f) {
+		fixBody(source.Body)
+	}
+	return nil
+}
+
 func (FixerCleanImageName) Synopsis() string {
 	return `Replaces /clean_(image|ami)_name/ in builder configs with "clean_resource_name"`
 }
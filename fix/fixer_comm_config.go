@@ -6,6 +6,7 @@ package fix
 import (
 	"strings"
 
+	"github.com/hashicorp/hcl/v2/hclwrite"
 	"github.com/mitchellh/mapstructure"
 )
 
@@ -107,6 +108,20 @@ func (FixerCommConfig) Fix(input map[string]interface{}) (map[string]interface{}
 	return input, nil
 }
 
+func (FixerCommConfig) FixHCL(f *hclwrite.File) error {
+	for _, source := range hclSources(f) {
+		// only virtualbox builders
+		if !strings.HasPrefix(source.Type, "virtualbox") {
+			continue
+		}
+
+		renameHCLAttribute(source.Body, "ssh_host_port_min", "host_port_min")
+		renameHCLAttribute(source.Body, "ssh_host_port_max", "host_port_max")
+		renameHCLAttribute(source.Body, "ssh_skip_nat_mapping", "skip_nat_mapping")
+	}
+	return nil
+}
+
 func (FixerCommConfig) Synopsis() string {
 	return `Remove ssh prefixes from communicator port forwarding configuration (host_port_min, host_port_max, skip_nat_mapping)`
 }
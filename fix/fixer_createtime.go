@@ -6,6 +6,7 @@ package fix
 import (
 	"regexp"
 
+	"github.com/hashicorp/hcl/v2/hclwrite"
 	"github.com/mitchellh/mapstructure"
 )
 
@@ -58,6 +59,29 @@ func (FixerCreateTime) Fix(input map[string]interface{}) (map[string]interface{}
 	return input, nil
 }
 
+func (FixerCreateTime) FixHCL(f *hclwrite.File) error {
+	badKeys := []string{
+		"ami_name",
+		"bundle_prefix",
+		"snapshot_name",
+	}
+
+	re := regexp.MustCompile(`{{\s*\.CreateTime\s*}}`)
+
+	for _, source := range hclSources(f) {
+		for _, key := range badKeys {
+			attr := source.Body.GetAttribute(key)
+			if attr == nil {
+				continue
+			}
+			replaceInHCLStrings(attr.Expr().BuildTokens(nil), func(s string) string {
+				return re.ReplaceAllString(s, "{{timestamp}}")
+			})
+		}
+	}
+	return nil
+}
+
 func (FixerCreateTime) Synopsis() string {
 	return `Replaces ".CreateTime" in builder configs with "{{timestamp}}"`
 }
@@ -3,7 +3,10 @@
 
 package fix
 
-import "github.com/mitchellh/mapstructure"
+import (
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/mitchellh/mapstructure"
+)
 
 type FixerDockerEmail struct{}
 
@@ -57,6 +60,13 @@ func (FixerDockerEmail) Fix(input map[string]interface{}) (map[string]interface{
 	return input, nil
 }
 
+func (FixerDockerEmail) FixHCL(f *hclwrite.File) error {
+	for _, component := range append(hclSources(f), hclPostProcessors(f)...) {
+		component.Body.RemoveAttribute("login_email")
+	}
+	return nil
+}
+
 func (FixerDockerEmail) Synopsis() string {
 	return `Removes "login_email" from the Docker builder.`
 }
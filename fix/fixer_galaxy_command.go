@@ -4,6 +4,7 @@
 package fix
 
 import (
+	"github.com/hashicorp/hcl/v2/hclwrite"
 	"github.com/mitchellh/mapstructure"
 )
 
@@ -74,6 +75,17 @@ func (FixerGalaxyCommand) Fix(input map[string]interface{}) (map[string]interfac
 	return input, nil
 }
 
+func (FixerGalaxyCommand) FixHCL(f *hclwrite.File) error {
+	for _, provisioner := range hclProvisioners(f) {
+		if provisioner.Type != "ansible-local" {
+			continue
+		}
+
+		renameHCLAttribute(provisioner.Body, "galaxycommand", "galaxy_command")
+	}
+	return nil
+}
+
 func (FixerGalaxyCommand) Synopsis() string {
 	return `Replaces "galaxycommand" in ansible-local provisioner configs with "galaxy_command"`
 }
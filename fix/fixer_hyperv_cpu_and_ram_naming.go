@@ -4,6 +4,7 @@
 package fix
 
 import (
+	"github.com/hashicorp/hcl/v2/hclwrite"
 	"github.com/mitchellh/mapstructure"
 )
 
@@ -60,6 +61,18 @@ func (FizerHypervCPUandRAM) Fix(input map[string]interface{}) (map[string]interf
 	return input, nil
 }
 
+func (FizerHypervCPUandRAM) FixHCL(f *hclwrite.File) error {
+	for _, source := range hclSources(f) {
+		if source.Type != "hyperv-vmcx" && source.Type != "hyperv-iso" {
+			continue
+		}
+
+		renameHCLAttribute(source.Body, "cpu", "cpus")
+		renameHCLAttribute(source.Body, "ram_size", "memory")
+	}
+	return nil
+}
+
 func (FizerHypervCPUandRAM) Synopsis() string {
 	return `Replaces "cpu" with "cpus" and "ram_size" with "memory"` +
 		`in Hyper-V VMCX builder templates`
@@ -4,6 +4,7 @@
 package fix
 
 import (
+	"github.com/hashicorp/hcl/v2/hclwrite"
 	"github.com/mitchellh/mapstructure"
 )
 
@@ -54,6 +55,17 @@ func (FixerHypervDeprecations) Fix(input map[string]interface{}) (map[string]int
 	return input, nil
 }
 
+func (FixerHypervDeprecations) FixHCL(f *hclwrite.File) error {
+	for _, source := range hclSources(f) {
+		if source.Type != "hyperv-iso" {
+			continue
+		}
+
+		source.Body.RemoveAttribute("vhd_temp_path")
+	}
+	return nil
+}
+
 func (FixerHypervDeprecations) Synopsis() string {
 	return `Removes the deprecated "vhd_temp_path" setting from Hyper-V ISO builder templates`
 }
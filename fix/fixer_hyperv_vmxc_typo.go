@@ -4,6 +4,7 @@
 package fix
 
 import (
+	"github.com/hashicorp/hcl/v2/hclwrite"
 	"github.com/mitchellh/mapstructure"
 )
 
@@ -55,6 +56,17 @@ func (FixerHypervVmxcTypo) Fix(input map[string]interface{}) (map[string]interfa
 	return input, nil
 }
 
+func (FixerHypervVmxcTypo) FixHCL(f *hclwrite.File) error {
+	for _, source := range hclSources(f) {
+		if source.Type != "hyperv-vmcx" {
+			continue
+		}
+
+		renameHCLAttribute(source.Body, "clone_from_vmxc_path", "clone_from_vmcx_path")
+	}
+	return nil
+}
+
 func (FixerHypervVmxcTypo) Synopsis() string {
 	return `Fixes a typo replacing "clone_from_vmxc_path" with "clone_from_vmcx_path" ` +
 		`in Hyper-V VMCX builder templates`
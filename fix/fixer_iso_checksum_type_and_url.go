@@ -4,7 +4,9 @@
 package fix
 
 import (
+	"github.com/hashicorp/hcl/v2/hclwrite"
 	"github.com/mitchellh/mapstructure"
+	"github.com/zclconf/go-cty/cty"
 )
 
 // FixerISOChecksumTypeAndURL is a Fixer that remove the "iso_checksum_url" and
@@ -61,6 +63,32 @@ func stringValue(v interface{}) string {
 	}
 }
 
+func (FixerISOChecksumTypeAndURL) FixHCL(f *hclwrite.File) error {
+	for _, source := range hclSources(f) {
+		checksumUrl := source.Body.GetAttribute("iso_checksum_url")
+		checksumType := source.Body.GetAttribute("iso_checksum_type")
+		checksum := source.Body.GetAttribute("iso_checksum")
+
+		var fixed hclwrite.Tokens
+		if checksumUrl != nil {
+			fixed = hclStringTemplate(hclwrite.TokensForValue(cty.StringVal("file:")), hclExprTokens(checksumUrl))
+		} else if checksum != nil && checksumType != nil {
+			fixed = hclStringTemplate(hclExprTokens(checksumType), hclwrite.TokensForValue(cty.StringVal(":")), hclExprTokens(checksum))
+		}
+
+		source.Body.RemoveAttribute("iso_checksum_type")
+		if fixed == nil {
+			continue
+		}
+		if checksum == nil {
+			renameHCLAttribute(source.Body, "iso_checksum_url", "iso_checksum")
+		}
+		source.Body.RemoveAttribute("iso_checksum_url")
+		source.Body.SetAttributeRaw("iso_checksum", fixed)
+	}
+	return nil
+}
+
 func (FixerISOChecksumTypeAndURL) Synopsis() string {
 	return `Puts content of potential "iso_checksum_url" and "iso_checksum_url" in "iso_checksum"`
 }
@@ -4,7 +4,9 @@
 package fix
 
 import (
+	"github.com/hashicorp/hcl/v2/hclwrite"
 	"github.com/mitchellh/mapstructure"
+	"github.com/zclconf/go-cty/cty"
 )
 
 // FixerISOMD5 is a Fixer that replaces the "iso_md5" configuration key
@@ -51,6 +53,15 @@ func (FixerISOMD5) Fix(input map[string]interface{}) (map[string]interface{}, er
 	return input, nil
 }
 
+func (FixerISOMD5) FixHCL(f *hclwrite.File) error {
+	for _, source := range hclSources(f) {
+		if renameHCLAttribute(source.Body, "iso_md5", "iso_checksum") != nil {
+			source.Body.SetAttributeValue("iso_checksum_type", cty.StringVal("md5"))
+		}
+	}
+	return nil
+}
+
 func (FixerISOMD5) Synopsis() string {
 	return `Replaces "iso_md5" in builders with "iso_checksum"`
 }
@@ -4,6 +4,7 @@
 package fix
 
 import (
+	"github.com/hashicorp/hcl/v2/hclwrite"
 	"github.com/mitchellh/mapstructure"
 )
 
@@ -62,6 +63,18 @@ func (FixerParallelsDeprecations) Fix(input map[string]interface{}) (map[string]
 	return input, nil
 }
 
+func (FixerParallelsDeprecations) FixHCL(f *hclwrite.File) error {
+	for _, source := range hclSources(f) {
+		if source.Type != "parallels-iso" && source.Type != "parallels-pvm" {
+			continue
+		}
+
+		source.Body.RemoveAttribute("parallels_tools_host_path")
+		renameHCLAttribute(source.Body, "guest_os_distribution", "guest_os_type")
+	}
+	return nil
+}
+
 func (FixerParallelsDeprecations) Synopsis() string {
 	return `Removes deprecated "parallels_tools_host_path" from Parallels builders and changes "guest_os_distribution" to "guest_os_type".`
 }
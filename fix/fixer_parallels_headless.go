@@ -4,6 +4,7 @@
 package fix
 
 import (
+	"github.com/hashicorp/hcl/v2/hclwrite"
 	"github.com/mitchellh/mapstructure"
 )
 
@@ -55,6 +56,17 @@ func (FixerParallelsHeadless) Fix(input map[string]interface{}) (map[string]inte
 	return input, nil
 }
 
+func (FixerParallelsHeadless) FixHCL(f *hclwrite.File) error {
+	for _, source := range hclSources(f) {
+		if source.Type != "parallels-iso" && source.Type != "parallels-pvm" {
+			continue
+		}
+
+		source.Body.RemoveAttribute("headless")
+	}
+	return nil
+}
+
 func (FixerParallelsHeadless) Synopsis() string {
 	return `Removes unused "headless" from Parallels builders`
 }
@@ -6,6 +6,7 @@ package fix
 import (
 	"strings"
 
+	"github.com/hashicorp/hcl/v2/hclwrite"
 	"github.com/mitchellh/mapstructure"
 )
 
@@ -76,6 +77,30 @@ func (FixerPowerShellEscapes) Fix(input map[string]interface{}) (map[string]inte
 	return input, nil
 }
 
+func (FixerPowerShellEscapes) FixHCL(f *hclwrite.File) error {
+	// The escapes as they are written in HCL2 strings, where double quotes
+	// are escaped too.
+	var psUnescape = strings.NewReplacer(
+		"`$", "$",
+		"`\\\"", "\\\"",
+		"``", "`",
+		"`'", "'",
+	)
+
+	for _, provisioner := range hclProvisioners(f) {
+		if provisioner.Type != "powershell" {
+			continue
+		}
+
+		for _, name := range []string{"elevated_user", "elevated_password", "environment_vars"} {
+			if attr := provisioner.Body.GetAttribute(name); attr != nil {
+				replaceInHCLStrings(attr.Expr().BuildTokens(nil), psUnescape.Replace)
+			}
+		}
+	}
+	return nil
+}
+
 func (FixerPowerShellEscapes) Synopsis() string {
 	return `Removes PowerShell escapes from user env vars and elevated username and password strings`
 }
@@ -6,7 +6,9 @@ package fix
 import (
 	"strings"
 
+	"github.com/hashicorp/hcl/v2/hclwrite"
 	"github.com/mitchellh/mapstructure"
+	"github.com/zclconf/go-cty/cty"
 )
 
 // FixerDockerTagtoTags renames tag to tags
@@ -110,6 +112,92 @@ func (FixerDockerTagtoTags) Fix(input map[string]interface{}) (map[string]interf
 	return input, nil
 }
 
+func (FixerDockerTagtoTags) FixHCL(f *hclwrite.File) error {
+	for _, pp := range hclPostProcessors(f) {
+		if pp.Type != "docker-tag" {
+			continue
+		}
+
+		tag := pp.Body.GetAttribute("tag")
+		if tag == nil {
+			continue
+		}
+
+		tags := pp.Body.GetAttribute("tags")
+		if tags == nil {
+			renameHCLAttribute(pp.Body, "tag", "tags")
+			pp.Body.SetAttributeRaw("tags", dockerTagsTokens(tag))
+			continue
+		}
+
+		// Merge tag and tags, deduplicating the tags right away when they
+		// are all known.
+		allTags, tagKnown := dockerTags(tag)
+		moreTags, tagsKnown := dockerTags(tags)
+		var finalTags hclwrite.Tokens
+		if tagKnown && tagsKnown {
+			deduplicater := map[string]bool{}
+			values := []cty.Value{}
+			for _, tag := range append(allTags, moreTags...) {
+				if found := deduplicater[tag]; found {
+					continue
+				}
+				deduplicater[tag] = true
+				values = append(values, cty.StringVal(tag))
+			}
+			finalTags = hclwrite.TokensForValue(cty.TupleVal(values))
+		} else {
+			finalTags = hclwrite.TokensForFunctionCall("distinct",
+				hclwrite.TokensForFunctionCall("concat", dockerTagsTokens(tag), dockerTagsTokens(tags)))
+		}
+
+		pp.Body.RemoveAttribute("tag")
+		pp.Body.SetAttributeRaw("tags", finalTags)
+	}
+	return nil
+}
+
+// dockerTags returns the tags set by attr when they are literal, splitting a
+// string of comma-separated tags.
+func dockerTags(attr *hclwrite.Attribute) ([]string, bool) {
+	val, ok := hclLiteral(attr)
+	if !ok {
+		return nil, false
+	}
+	if val.Type() == cty.String {
+		var tags []string
+		for _, tag := range strings.Split(val.AsString(), ",") {
+			tags = append(tags, strings.TrimSpace(tag))
+		}
+		return tags, true
+	}
+	if !val.CanIterateElements() {
+		return nil, false
+	}
+	var tags []string
+	for it := val.ElementIterator(); it.Next(); {
+		_, tag := it.Element()
+		if tag.Type() != cty.String {
+			return nil, false
+		}
+		tags = append(tags, tag.AsString())
+	}
+	return tags, true
+}
+
+// dockerTagsTokens returns the tokens of the list of tags set by attr.
+func dockerTagsTokens(attr *hclwrite.Attribute) hclwrite.Tokens {
+	if val, ok := hclLiteral(attr); ok && val.Type() == cty.String {
+		tags, _ := dockerTags(attr)
+		values := make([]cty.Value, 0, len(tags))
+		for _, tag := range tags {
+			values = append(values, cty.StringVal(tag))
+		}
+		return hclwrite.TokensForValue(cty.TupleVal(values))
+	}
+	return hclExprTokens(attr)
+}
+
 func (FixerDockerTagtoTags) Synopsis() string {
 	return `Updates "docker" post-processor so any "tag" field is renamed to "tags".`
 }
@@ -4,6 +4,7 @@
 package fix
 
 import (
+	"github.com/hashicorp/hcl/v2/hclwrite"
 	"github.com/mitchellh/mapstructure"
 )
 
@@ -63,6 +64,17 @@ func (FixerManifestFilename) Fix(input map[string]interface{}) (map[string]inter
 	return input, nil
 }
 
+func (FixerManifestFilename) FixHCL(f *hclwrite.File) error {
+	for _, pp := range hclPostProcessors(f) {
+		if pp.Type != "manifest" {
+			continue
+		}
+
+		renameHCLAttribute(pp.Body, "filename", "output")
+	}
+	return nil
+}
+
 func (FixerManifestFilename) Synopsis() string {
 	return `Updates "manifest" post-processor so any "filename" field is renamed to "output".`
 }
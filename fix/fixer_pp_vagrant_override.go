@@ -3,7 +3,10 @@
 
 package fix
 
-import "github.com/mitchellh/mapstructure"
+import (
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/mitchellh/mapstructure"
+)
 
 // FixerVagrantPPOverride is a Fixer that replaces the provider-specific
 // overrides for the Vagrant post-processor with the new style introduced
@@ -65,6 +68,34 @@ func (FixerVagrantPPOverride) Fix(input map[string]interface{}) (map[string]inte
 	return input, nil
 }
 
+func (FixerVagrantPPOverride) FixHCL(f *hclwrite.File) error {
+	possible := []string{"aws", "digitalocean", "virtualbox", "vmware"}
+	for _, pp := range hclPostProcessors(f) {
+		if pp.Type != "vagrant" || pp.Body.GetAttribute("override") != nil {
+			continue
+		}
+
+		var overrides []hclwrite.ObjectAttrTokens
+		for _, name := range possible {
+			attr := pp.Body.GetAttribute(name)
+			if attr == nil {
+				continue
+			}
+
+			overrides = append(overrides, hclwrite.ObjectAttrTokens{
+				Name:  hclwrite.TokensForIdentifier(name),
+				Value: hclExprTokens(attr),
+			})
+			pp.Body.RemoveAttribute(name)
+		}
+
+		if len(overrides) > 0 {
+			pp.Body.SetAttributeRaw("override", hclwrite.TokensForObject(overrides))
+		}
+	}
+	return nil
+}
+
 func (FixerVagrantPPOverride) Synopsis() string {
 	return `Fixes provider-specific overrides for Vagrant post-processor`
 }
@@ -4,6 +4,7 @@
 package fix
 
 import (
+	"github.com/hashicorp/hcl/v2/hclwrite"
 	"github.com/mitchellh/mapstructure"
 )
 
@@ -47,6 +48,11 @@ func (FixerProxmoxType) Fix(input map[string]interface{}) (map[string]interface{
 	return input, nil
 }
 
+func (FixerProxmoxType) FixHCL(f *hclwrite.File) error {
+	renameHCLSourceType(f, "proxmox", "proxmox-iso")
+	return nil
+}
+
 func (FixerProxmoxType) Synopsis() string {
 	return `Updates the builder type proxmox to proxmox-iso`
 }
@@ -6,7 +6,9 @@ package fix
 import (
 	"strconv"
 
+	"github.com/hashicorp/hcl/v2/hclwrite"
 	"github.com/mitchellh/mapstructure"
+	"github.com/zclconf/go-cty/cty"
 )
 
 // FixerQEMUDiskSize updates disk_size from a string to int for QEMU builders
@@ -55,6 +57,25 @@ func (FixerQEMUDiskSize) Fix(input map[string]interface{}) (map[string]interface
 	return input, nil
 }
 
+func (FixerQEMUDiskSize) FixHCL(f *hclwrite.File) error {
+	for _, source := range hclSources(f) {
+		if source.Type != "qemu" {
+			continue
+		}
+
+		diskSize := source.Body.GetAttribute("disk_size")
+		if diskSize == nil {
+			continue
+		}
+
+		if size, ok := hclLiteral(diskSize); ok && size.Type() == cty.Number {
+			megabytes, _ := size.AsBigFloat().Int64()
+			source.Body.SetAttributeValue("disk_size", cty.StringVal(strconv.FormatInt(megabytes, 10)+"M"))
+		}
+	}
+	return nil
+}
+
 func (FixerQEMUDiskSize) Synopsis() string {
 	return `Updates "disk_size" from int to string in QEMU builders.`
 }
@@ -4,6 +4,7 @@
 package fix
 
 import (
+	"github.com/hashicorp/hcl/v2/hclwrite"
 	"github.com/mitchellh/mapstructure"
 )
 
@@ -55,6 +56,18 @@ func (FixerQEMUHostPort) Fix(input map[string]interface{}) (map[string]interface
 	return input, nil
 }
 
+func (FixerQEMUHostPort) FixHCL(f *hclwrite.File) error {
+	for _, source := range hclSources(f) {
+		if source.Type != "qemu" {
+			continue
+		}
+
+		renameHCLAttribute(source.Body, "ssh_host_port_min", "host_port_min")
+		renameHCLAttribute(source.Body, "ssh_host_port_max", "host_port_max")
+	}
+	return nil
+}
+
 func (FixerQEMUHostPort) Synopsis() string {
 	return `Updates ssh_host_port_min and ssh_host_port_max to host_port_min and host_port_max`
 }
@@ -4,6 +4,7 @@
 package fix
 
 import (
+	"github.com/hashicorp/hcl/v2/hclwrite"
 	"github.com/mitchellh/mapstructure"
 )
 
@@ -58,6 +59,17 @@ func (FixerScalewayAccessKey) Fix(input map[string]interface{}) (map[string]inte
 	return input, nil
 }
 
+func (FixerScalewayAccessKey) FixHCL(f *hclwrite.File) error {
+	for _, source := range hclSources(f) {
+		if source.Type != "scaleway" {
+			continue
+		}
+
+		renameHCLAttribute(source.Body, "access_key", "organization_id")
+	}
+	return nil
+}
+
 func (FixerScalewayAccessKey) Synopsis() string {
 	return `Updates builders using "access_key" to use "organization_id"`
 }
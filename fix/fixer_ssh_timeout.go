@@ -4,6 +4,7 @@
 package fix
 
 import (
+	"github.com/hashicorp/hcl/v2/hclwrite"
 	"github.com/mitchellh/mapstructure"
 )
 
@@ -69,6 +70,13 @@ func (FixerSSHTimout) Fix(input map[string]interface{}) (map[string]interface{},
 	return input, nil
 }
 
+func (FixerSSHTimout) FixHCL(f *hclwrite.File) error {
+	for _, source := range hclSources(f) {
+		renameHCLAttribute(source.Body, "ssh_wait_timeout", "ssh_timeout")
+	}
+	return nil
+}
+
 func (FixerSSHTimout) Synopsis() string {
 	return `Replaces "ssh_wait_timeout" with "ssh_timeout"`
 }
@@ -4,6 +4,7 @@
 package fix
 
 import (
+	"github.com/hashicorp/hcl/v2/hclwrite"
 	"github.com/mitchellh/mapstructure"
 )
 
@@ -54,6 +55,13 @@ func (FixerSSHDisableAgent) Fix(input map[string]interface{}) (map[string]interf
 	return input, nil
 }
 
+func (FixerSSHDisableAgent) FixHCL(f *hclwrite.File) error {
+	for _, source := range hclSources(f) {
+		renameHCLAttribute(source.Body, "ssh_disable_agent", "ssh_disable_agent_forwarding")
+	}
+	return nil
+}
+
 func (FixerSSHDisableAgent) Synopsis() string {
 	return `Updates builders using "ssh_disable_agent" to use "ssh_disable_agent_forwarding"`
 }
@@ -4,6 +4,7 @@
 package fix
 
 import (
+	"github.com/hashicorp/hcl/v2/hclwrite"
 	"github.com/mitchellh/mapstructure"
 )
 
@@ -54,6 +55,13 @@ func (FixerSSHKeyPath) Fix(input map[string]interface{}) (map[string]interface{}
 	return input, nil
 }
 
+func (FixerSSHKeyPath) FixHCL(f *hclwrite.File) error {
+	for _, source := range hclSources(f) {
+		renameHCLAttribute(source.Body, "ssh_key_path", "ssh_private_key_file")
+	}
+	return nil
+}
+
 func (FixerSSHKeyPath) Synopsis() string {
 	return `Updates builders using "ssh_key_path" to use "ssh_private_key_file"`
 }
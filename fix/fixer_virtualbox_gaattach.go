@@ -4,6 +4,7 @@
 package fix
 
 import (
+	"github.com/hashicorp/hcl/v2/hclwrite"
 	"github.com/mitchellh/mapstructure"
 )
 
@@ -67,6 +68,20 @@ func (FixerVirtualBoxGAAttach) Fix(input map[string]interface{}) (map[string]int
 	return input, nil
 }
 
+func (FixerVirtualBoxGAAttach) FixHCL(f *hclwrite.File) error {
+	for _, source := range hclSources(f) {
+		if source.Type != "virtualbox" {
+			continue
+		}
+
+		attr := renameHCLAttribute(source.Body, "guest_additions_attach", "guest_additions_mode")
+		if attr != nil {
+			source.Body.SetAttributeRaw("guest_additions_mode", hclBoolChoice(attr, "attach", "upload"))
+		}
+	}
+	return nil
+}
+
 func (FixerVirtualBoxGAAttach) Synopsis() string {
 	return `Updates VirtualBox builders using "guest_additions_attach" to use "guest_additions_mode"`
 }
@@ -4,6 +4,7 @@
 package fix
 
 import (
+	"github.com/hashicorp/hcl/v2/hclwrite"
 	"github.com/mitchellh/mapstructure"
 )
 
@@ -80,6 +81,11 @@ func (FixerVirtualBoxRename) Fix(input map[string]interface{}) (map[string]inter
 	return input, nil
 }
 
+func (FixerVirtualBoxRename) FixHCL(f *hclwrite.File) error {
+	renameHCLSourceType(f, "virtualbox", "virtualbox-iso")
+	return nil
+}
+
 func (FixerVirtualBoxRename) Synopsis() string {
 	return `Updates "virtualbox" builders to "virtualbox-iso"`
 }
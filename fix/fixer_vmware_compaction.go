@@ -4,7 +4,9 @@
 package fix
 
 import (
+	"github.com/hashicorp/hcl/v2/hclwrite"
 	"github.com/mitchellh/mapstructure"
+	"github.com/zclconf/go-cty/cty"
 )
 
 // FixerVMwareCompaction adds "skip_compaction = true" to "vmware-iso" builders with incompatible disk_type_id
@@ -93,6 +95,40 @@ func (FixerVMwareCompaction) Fix(input map[string]interface{}) (map[string]inter
 	return input, nil
 }
 
+func (FixerVMwareCompaction) FixHCL(f *hclwrite.File) error {
+	for _, source := range hclSources(f) {
+		if source.Type != "vmware-iso" {
+			continue
+		}
+
+		remoteType := source.Body.GetAttribute("remote_type")
+		if remoteType == nil {
+			continue
+		}
+		if remoteType, ok := hclLiteralString(remoteType); !ok || remoteType != "esx5" {
+			continue
+		}
+
+		diskTypeId := source.Body.GetAttribute("disk_type_id")
+		if diskTypeId == nil {
+			// set to default when this fixer was added due to incompatibility of defaults
+			source.Body.SetAttributeValue("disk_type_id", cty.StringVal("zeroedthick"))
+		} else if diskTypeId, ok := hclLiteralString(diskTypeId); !ok || diskTypeId == "thin" {
+			continue
+		}
+
+		// already verified this is not creating a "thin" disk, will need to skip_compaction
+		skipCompaction := source.Body.GetAttribute("skip_compaction")
+		if skipCompaction != nil {
+			if skip, ok := hclLiteral(skipCompaction); !ok || skip.Type() != cty.Bool || skip.True() {
+				continue
+			}
+		}
+		source.Body.SetAttributeValue("skip_compaction", cty.True)
+	}
+	return nil
+}
+
 func (FixerVMwareCompaction) Synopsis() string {
 	return `Adds "skip_compaction = true" to "vmware-iso" builders with incompatible disk_type_id`
 }
@@ -4,6 +4,7 @@
 package fix
 
 import (
+	"github.com/hashicorp/hcl/v2/hclwrite"
 	"github.com/mitchellh/mapstructure"
 )
 
@@ -48,6 +49,11 @@ func (FixerVMwareRename) Fix(input map[string]interface{}) (map[string]interface
 	return input, nil
 }
 
+func (FixerVMwareRename) FixHCL(f *hclwrite.File) error {
+	renameHCLSourceType(f, "vmware", "vmware-iso")
+	return nil
+}
+
 func (FixerVMwareRename) Synopsis() string {
 	return `Updates "vmware" builders to "vmware-iso"`
 }
@@ -4,6 +4,7 @@
 package fix
 
 import (
+	"github.com/hashicorp/hcl/v2/hclwrite"
 	"github.com/mitchellh/mapstructure"
 )
 
@@ -125,6 +126,65 @@ func (FixerVSphereNetworkDisk) Fix(input map[string]interface{}) (map[string]int
 	return input, nil
 }
 
+func (FixerVSphereNetworkDisk) FixHCL(f *hclwrite.File) error {
+	for _, source := range hclSources(f) {
+		if source.Type != "vsphere-iso" {
+			continue
+		}
+
+		moveHCLAttributesToBlock(source.Body, "network_adapters", [][2]string{
+			{"network", "network"},
+			// legacy syntax from when VSphere was 3rd party
+			{"networkCard", "network_card"},
+			// underscored syntax used when Packer merged vSphere
+			{"network_card", "network_card"},
+		})
+		moveHCLAttributesToBlock(source.Body, "storage", [][2]string{
+			{"disk_size", "disk_size"},
+			{"disk_thin_provisioned", "disk_thin_provisioned"},
+			{"disk_eagerly_scrub", "disk_eagerly_scrub"},
+		})
+	}
+	return nil
+}
+
+// moveHCLAttributesToBlock moves the attributes of body to a new block of
+// type blockType, renaming them from the first to the second of each of the
+// names. The new block comes first of the blockType blocks of body.
+func moveHCLAttributesToBlock(body *hclwrite.Body, blockType string, names [][2]string) {
+	var block *hclwrite.Block
+	for _, name := range names {
+		attr := body.GetAttribute(name[0])
+		if attr == nil {
+			continue
+		}
+		if block == nil {
+			block = hclwrite.NewBlock(blockType, nil)
+		}
+		block.Body().SetAttributeRaw(name[1], hclExprTokens(attr))
+		body.RemoveAttribute(name[0])
+	}
+	if block == nil {
+		return
+	}
+
+	var existing []*hclwrite.Block
+	for _, b := range body.Blocks() {
+		if b.Type() == blockType {
+			existing = append(existing, b)
+			body.RemoveBlock(b)
+		}
+	}
+	if len(body.Blocks()) > 0 {
+		body.AppendNewline()
+	}
+	body.AppendBlock(block)
+	for _, b := range existing {
+		body.AppendNewline()
+		body.AppendBlock(b)
+	}
+}
+
 func (FixerVSphereNetworkDisk) Synopsis() string {
 	return `Removes deprecated network and disk fields from "vsphere-iso" builder`
 }
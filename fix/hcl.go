@@ -0,0 +1,384 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package fix
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+)
+
+// FixHCL runs the fixers, in FixerOrder, on the source of an HCL2 template
+// file and returns the fixed source. The fixers work on the syntax tree of the
+// file, so its comments are kept; the lines they changed are formatted like
+// `packer fmt` would, and the other lines are left as they are. The source is
+// returned unchanged when there is nothing to fix.
+func FixHCL(src []byte, filename string) ([]byte, hcl.Diagnostics) {
+	f, diags := hclwrite.ParseConfig(src, filename, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	// The tokens of the syntax tree only keep the number of spaces before
+	// them, not their kind, so the fixed tokens are compared with the tokens
+	// of src to know what was fixed. Unlike f.Bytes, their bytes are not
+	// formatted.
+	unfixed := f.BuildTokens(nil).Bytes()
+
+	for _, name := range FixerOrder {
+		fixer, ok := Fixers[name]
+		if !ok {
+			panic("fixer not found: " + name)
+		}
+
+		log.Printf("Running fixer on %s: %s", filename, name)
+		if err := fixer.FixHCL(f); err != nil {
+			return nil, append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  fmt.Sprintf("Failed to fix %s with the %s fixer", filename, name),
+				Detail:   err.Error(),
+			})
+		}
+	}
+
+	fixed := f.BuildTokens(nil).Bytes()
+	if bytes.Equal(unfixed, fixed) {
+		return src, diags
+	}
+	return formatFixedLines(src, unfixed, fixed, f.Bytes()), diags
+}
+
+// formatFixedLines returns the fixed source of src: the lines the fixers
+// changed are taken from formatted, and the other ones from src. unfixed and
+// fixed are the tokens of src before and after they were fixed, and formatted
+// is fixed once formatted. Neither writing tokens nor formatting them adds or
+// removes lines, so the lines of src and formatted match the ones of unfixed
+// and fixed.
+//
+// Formatting aligns the equal signs of consecutive attributes, so the lines
+// next to a changed line, with the same indentation, are formatted too.
+func formatFixedLines(src, unfixed, fixed, formatted []byte) []byte {
+	srcLines := strings.SplitAfter(string(src), "\n")
+	unfixedLines := strings.SplitAfter(string(unfixed), "\n")
+	fixedLines := strings.SplitAfter(string(fixed), "\n")
+	formattedLines := strings.SplitAfter(string(formatted), "\n")
+	if len(srcLines) != len(unfixedLines) || len(fixedLines) != len(formattedLines) {
+		return formatted
+	}
+
+	// the line of src of each line of fixed, or -1 when it was changed.
+	srcLine := make([]int, len(fixedLines))
+	var changed []int
+	matcher := difflib.NewMatcherWithJunk(unfixedLines, fixedLines, false, nil)
+	for _, op := range matcher.GetOpCodes() {
+		for j := op.J1; j < op.J2; j++ {
+			srcLine[j] = op.I1 + j - op.J1
+			if op.Tag != 'e' {
+				srcLine[j] = -1
+				changed = append(changed, j)
+			}
+		}
+		if op.Tag == 'd' {
+			// the lines around removed lines can be aligned differently.
+			for _, j := range []int{op.J1 - 1, op.J1} {
+				if j >= 0 && j < len(fixedLines) {
+					changed = append(changed, j)
+				}
+			}
+		}
+	}
+
+	indent := func(line string) int {
+		return len(line) - len(strings.TrimLeft(line, " "))
+	}
+	for _, j := range changed {
+		srcLine[j] = -1
+		for _, step := range []int{-1, 1} {
+			for k := j + step; k >= 0 && k < len(formattedLines); k += step {
+				if strings.TrimSpace(formattedLines[k]) == "" || indent(formattedLines[k]) != indent(formattedLines[j]) {
+					break
+				}
+				srcLine[k] = -1
+			}
+		}
+	}
+
+	out := &bytes.Buffer{}
+	for j := range fixedLines {
+		if srcLine[j] < 0 {
+			out.WriteString(formattedLines[j])
+		} else {
+			out.WriteString(srcLines[srcLine[j]])
+		}
+	}
+	return out.Bytes()
+}
+
+// hclComponent is a block of an HCL2 template configuring a builder, a
+// provisioner or a post-processor of type Type.
+type hclComponent struct {
+	Type string
+	Body *hclwrite.Body
+}
+
+// hclSources returns the top-level source blocks of f and the source blocks
+// of its builds, whose contents are merged with the top-level ones.
+func hclSources(f *hclwrite.File) []hclComponent {
+	var sources []hclComponent
+	for _, block := range f.Body().Blocks() {
+		switch block.Type() {
+		case "source":
+			if labels := block.Labels(); len(labels) == 2 {
+				sources = append(sources, hclComponent{Type: labels[0], Body: block.Body()})
+			}
+		case "build":
+			for _, inner := range block.Body().Blocks() {
+				if inner.Type() != "source" || len(inner.Labels()) != 1 {
+					continue
+				}
+				if sourceType, _, ok := splitHCLSourceRef(inner.Labels()[0]); ok {
+					sources = append(sources, hclComponent{Type: sourceType, Body: inner.Body()})
+				}
+			}
+		}
+	}
+	return sources
+}
+
+// hclProvisioners returns the provisioner blocks of the builds of f,
+// including the grouped and error-cleanup ones.
+func hclProvisioners(f *hclwrite.File) []hclComponent {
+	return hclBuildComponents(f, "provisioner", "provisioners")
+}
+
+// hclPostProcessors returns the post-processor blocks of the builds of f,
+// including the ones of post-processors sequences.
+func hclPostProcessors(f *hclwrite.File) []hclComponent {
+	return hclBuildComponents(f, "post-processor", "post-processors")
+}
+
+func hclBuildComponents(f *hclwrite.File, kind, group string) []hclComponent {
+	var components []hclComponent
+	add := func(block *hclwrite.Block) {
+		if labels := block.Labels(); len(labels) == 1 {
+			components = append(components, hclComponent{Type: labels[0], Body: block.Body()})
+		}
+	}
+	for _, build := range f.Body().Blocks() {
+		if build.Type() != "build" {
+			continue
+		}
+		for _, block := range build.Body().Blocks() {
+			switch block.Type() {
+			case kind, "error-cleanup-" + kind:
+				add(block)
+			case group:
+				for _, inner := range block.Body().Blocks() {
+					if inner.Type() == kind {
+						add(inner)
+					}
+				}
+			}
+		}
+	}
+	return components
+}
+
+// splitHCLSourceRef splits a reference to a source, like
+// `source.amazon-ebs.example` or `amazon-ebs.example`, into its type and name.
+func splitHCLSourceRef(ref string) (sourceType, name string, ok bool) {
+	parts := strings.Split(ref, ".")
+	if len(parts) == 3 && (parts[0] == "source" || parts[0] == "sources") {
+		parts = parts[1:]
+	}
+	if len(parts) != 2 {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+// renameHCLSourceType renames the from builder type to to in the source
+// blocks of f and in the references to these sources.
+func renameHCLSourceType(f *hclwrite.File, from, to string) {
+	renameRef := func(ref string) string {
+		sourceType, name, ok := splitHCLSourceRef(ref)
+		if !ok || sourceType != from {
+			return ref
+		}
+		return strings.Replace(ref, from+"."+name, to+"."+name, 1)
+	}
+
+	for _, block := range f.Body().Blocks() {
+		switch block.Type() {
+		case "source":
+			if labels := block.Labels(); len(labels) == 2 && labels[0] == from {
+				block.SetLabels([]string{to, labels[1]})
+			}
+		case "build":
+			if sources := block.Body().GetAttribute("sources"); sources != nil {
+				replaceInHCLStrings(sources.Expr().BuildTokens(nil), renameRef)
+			}
+			for _, inner := range block.Body().Blocks() {
+				if inner.Type() == "source" && len(inner.Labels()) == 1 {
+					inner.SetLabels([]string{renameRef(inner.Labels()[0])})
+				}
+			}
+		}
+	}
+
+	for _, component := range append(hclProvisioners(f), hclPostProcessors(f)...) {
+		for _, name := range []string{"only", "except"} {
+			if attr := component.Body.GetAttribute(name); attr != nil {
+				replaceInHCLStrings(attr.Expr().BuildTokens(nil), renameRef)
+			}
+		}
+	}
+}
+
+// renameHCLAttribute renames the from attribute of body to to, keeping its
+// position and comments, and returns it. When to is already set, the from
+// attribute is removed instead and nil is returned.
+func renameHCLAttribute(body *hclwrite.Body, from, to string) *hclwrite.Attribute {
+	attr := body.GetAttribute(from)
+	if attr == nil {
+		return nil
+	}
+	if body.GetAttribute(to) != nil {
+		body.RemoveAttribute(from)
+		return nil
+	}
+	for _, token := range attr.BuildTokens(nil) {
+		if token.Type == hclsyntax.TokenIdent && string(token.Bytes) == from {
+			token.Bytes = []byte(to)
+			break
+		}
+	}
+	return attr
+}
+
+// replaceInHCLStrings replaces the literal parts of the strings of tokens,
+// which are part of the syntax tree of a file, with the result of replace.
+// replace is given the parts as they are written, with their escape sequences.
+func replaceInHCLStrings(tokens hclwrite.Tokens, replace func(string) string) {
+	isLiteral := func(token *hclwrite.Token) bool {
+		return token.Type == hclsyntax.TokenQuotedLit || token.Type == hclsyntax.TokenStringLit
+	}
+	for i := 0; i < len(tokens); i++ {
+		if !isLiteral(tokens[i]) {
+			continue
+		}
+		// The scanner can split a literal in several tokens, around a `$`
+		// for example, so they are merged into the first one.
+		literal := tokens[i]
+		value := string(literal.Bytes)
+		for i+1 < len(tokens) && isLiteral(tokens[i+1]) {
+			i++
+			value += string(tokens[i].Bytes)
+			tokens[i].Bytes = nil
+		}
+		literal.Bytes = []byte(replace(value))
+	}
+}
+
+// hclExprTokens returns a copy of the tokens of the expression of attr, to be
+// used in a new expression.
+func hclExprTokens(attr *hclwrite.Attribute) hclwrite.Tokens {
+	var tokens hclwrite.Tokens
+	for _, token := range attr.Expr().BuildTokens(nil) {
+		copied := *token
+		copied.Bytes = append([]byte(nil), token.Bytes...)
+		tokens = append(tokens, &copied)
+	}
+	if len(tokens) > 0 {
+		tokens[0].SpacesBefore = 0
+	}
+	return tokens
+}
+
+// hclLiteral returns the value of the expression of attr when it can be
+// known without evaluating variables or functions, like a string or a number.
+func hclLiteral(attr *hclwrite.Attribute) (cty.Value, bool) {
+	expr, diags := hclsyntax.ParseExpression(attr.Expr().BuildTokens(nil).Bytes(), "", hcl.InitialPos)
+	if diags.HasErrors() || len(expr.Variables()) > 0 {
+		return cty.NilVal, false
+	}
+	val, diags := expr.Value(nil)
+	if diags.HasErrors() || !val.IsWhollyKnown() || val.IsNull() {
+		return cty.NilVal, false
+	}
+	return val, true
+}
+
+// hclLiteralString returns the value of the expression of attr when it is a
+// literal string, or can be converted to one.
+func hclLiteralString(attr *hclwrite.Attribute) (string, bool) {
+	val, ok := hclLiteral(attr)
+	if !ok {
+		return "", false
+	}
+	val, err := convert.Convert(val, cty.String)
+	if err != nil {
+		return "", false
+	}
+	return val.AsString(), true
+}
+
+// hclBoolChoice returns the tokens of an expression choosing between
+// ifTrue and ifFalse depending on the boolean expression of attr. The choice
+// is made right away when the expression is a literal.
+func hclBoolChoice(attr *hclwrite.Attribute, ifTrue, ifFalse string) hclwrite.Tokens {
+	if val, ok := hclLiteral(attr); ok {
+		if val, err := convert.Convert(val, cty.Bool); err == nil {
+			if val.True() {
+				return hclwrite.TokensForValue(cty.StringVal(ifTrue))
+			}
+			return hclwrite.TokensForValue(cty.StringVal(ifFalse))
+		}
+	}
+
+	tokens := hclExprTokens(attr)
+	tokens = append(tokens, &hclwrite.Token{Type: hclsyntax.TokenQuestion, Bytes: []byte("?")})
+	tokens = append(tokens, hclwrite.TokensForValue(cty.StringVal(ifTrue))...)
+	tokens = append(tokens, &hclwrite.Token{Type: hclsyntax.TokenColon, Bytes: []byte(":")})
+	return append(tokens, hclwrite.TokensForValue(cty.StringVal(ifFalse))...)
+}
+
+// hclStringTemplate returns the tokens of a string concatenating the results
+// of the expressions of parts: quoted strings are merged in the template and
+// the other expressions are interpolated.
+func hclStringTemplate(parts ...hclwrite.Tokens) hclwrite.Tokens {
+	tokens := hclwrite.Tokens{{Type: hclsyntax.TokenOQuote, Bytes: []byte(`"`)}}
+	for _, part := range parts {
+		if isHCLQuotedTemplate(part) {
+			tokens = append(tokens, part[1:len(part)-1]...)
+			continue
+		}
+		tokens = append(tokens, &hclwrite.Token{Type: hclsyntax.TokenTemplateInterp, Bytes: []byte("${")})
+		tokens = append(tokens, part...)
+		tokens = append(tokens, &hclwrite.Token{Type: hclsyntax.TokenTemplateSeqEnd, Bytes: []byte("}")})
+	}
+	return append(tokens, &hclwrite.Token{Type: hclsyntax.TokenCQuote, Bytes: []byte(`"`)})
+}
+
+func isHCLQuotedTemplate(tokens hclwrite.Tokens) bool {
+	if len(tokens) < 2 || tokens[0].Type != hclsyntax.TokenOQuote {
+		return false
+	}
+	expr, diags := hclsyntax.ParseExpression(tokens.Bytes(), "", hcl.InitialPos)
+	if diags.HasErrors() {
+		return false
+	}
+	switch expr.(type) {
+	case *hclsyntax.TemplateExpr, *hclsyntax.TemplateWrapExpr:
+		return true
+	}
+	return false
+}
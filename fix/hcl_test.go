@@ -0,0 +1,244 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package fix
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFixHCL(t *testing.T) {
+	cases := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name: "sources",
+			input: `# The builder of the example.
+source "amazon-ebs" "example" {
+  ami_name         = "example-{{ .CreateTime }}"
+  ssh_wait_timeout = "10m" // kept as is
+  ssh_private_ip   = true
+  temporary_security_group_source_cidr = "10.0.0.0/8"
+}
+
+build {
+  sources = ["source.amazon-ebs.example"]
+
+  source "amazon-ebs.example" {
+    name           = "other"
+    ssh_private_ip = var.private
+  }
+}
+`,
+			expected: `# The builder of the example.
+source "amazon-ebs" "example" {
+  ami_name                              = "example-{{timestamp}}"
+  ssh_timeout                           = "10m" // kept as is
+  ssh_interface                         = "private_ip"
+  temporary_security_group_source_cidrs = ["10.0.0.0/8"]
+}
+
+build {
+  sources = ["source.amazon-ebs.example"]
+
+  source "amazon-ebs.example" {
+    name          = "other"
+    ssh_interface = var.private ? "private_ip" : "public_ip"
+  }
+}
+`,
+		},
+		{
+			name: "renamed builder types and checksums",
+			input: `source "proxmox" "example" {
+  iso_md5 = "abc"
+}
+
+source "qemu" "example" {
+  iso_checksum_type = "sha256"
+  iso_checksum      = var.checksum
+  disk_size         = 10000
+  ssh_host_port_min = 2222
+}
+
+build {
+  sources = ["source.proxmox.example", "sources.qemu.example"]
+
+  source "source.proxmox.example" {
+    name = "other"
+  }
+
+  provisioner "shell" {
+    only = ["proxmox.example"]
+  }
+}
+`,
+			expected: `source "proxmox-iso" "example" {
+  iso_checksum = "md5:abc"
+}
+
+source "qemu" "example" {
+  iso_checksum  = "sha256:${var.checksum}"
+  disk_size     = "10000M"
+  host_port_min = 2222
+}
+
+build {
+  sources = ["source.proxmox-iso.example", "sources.qemu.example"]
+
+  source "source.proxmox-iso.example" {
+    name = "other"
+  }
+
+  provisioner "shell" {
+    only = ["proxmox-iso.example"]
+  }
+}
+`,
+		},
+		{
+			name: "blocks, provisioners and post-processors",
+			input: `source "vsphere-iso" "example" {
+  network      = "VM Network"
+  network_card = "vmxnet3"
+  disk_size    = 32768
+
+  network_adapters {
+    network = "Other Network"
+  }
+}
+
+source "vmware-iso" "example" {
+  remote_type = "esx5"
+}
+
+build {
+  sources = ["source.vsphere-iso.example"]
+
+  provisioner "powershell" {
+    environment_vars = ["PASSWORD=p` + "`" + `$ss", "QUOTE=` + "`" + `\"q` + "`" + `\""]
+  }
+
+  post-processors {
+    post-processor "docker-tag" {
+      tag  = "1.0,latest"
+      tags = ["latest", "stable"]
+    }
+
+    post-processor "vagrant" {
+      virtualbox = {
+        vagrantfile_template = "vbox.rb"
+      }
+    }
+  }
+}
+`,
+			expected: `source "vsphere-iso" "example" {
+
+  network_adapters {
+    network      = "VM Network"
+    network_card = "vmxnet3"
+  }
+
+  network_adapters {
+    network = "Other Network"
+  }
+
+  storage {
+    disk_size = 32768
+  }
+}
+
+source "vmware-iso" "example" {
+  remote_type     = "esx5"
+  disk_type_id    = "zeroedthick"
+  skip_compaction = true
+}
+
+build {
+  sources = ["source.vsphere-iso.example"]
+
+  provisioner "powershell" {
+    environment_vars = ["PASSWORD=p$ss", "QUOTE=\"q\""]
+  }
+
+  post-processors {
+    post-processor "docker-tag" {
+      tags = ["1.0", "latest", "stable"]
+    }
+
+    post-processor "vagrant" {
+      override = {
+        virtualbox = {
+          vagrantfile_template = "vbox.rb"
+        }
+      }
+    }
+  }
+}
+`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			output, diags := FixHCL([]byte(tc.input), "template.pkr.hcl")
+			if diags.HasErrors() {
+				t.Fatal(diags)
+			}
+			if diff := cmp.Diff(tc.expected, string(output)); diff != "" {
+				t.Errorf("unexpected fixed template: %s", diff)
+			}
+		})
+	}
+}
+
+func TestFixHCL_nothingToFix(t *testing.T) {
+	input := `source "amazon-ebs" "example" {
+  ssh_timeout="10m"
+}
+`
+	output, diags := FixHCL([]byte(input), "template.pkr.hcl")
+	if diags.HasErrors() {
+		t.Fatal(diags)
+	}
+	if string(output) != input {
+		t.Errorf("expected a template with nothing to fix to be left as is, got:\n%s", output)
+	}
+}
+
+func TestFixHCL_keepsUnfixedLines(t *testing.T) {
+	// Only the fixed attribute and the attributes aligned with it are
+	// formatted, the rest of the file is left as it was written.
+	input := "variable \"region\" {\n\tdefault=\"us-east-1\"\n}\n\n" +
+		"source \"amazon-ebs\" \"example\" {\n" +
+		"  region = var.region\n\n" +
+		"  ssh_wait_timeout = \"10m\"\n" +
+		"  ssh_username = \"ubuntu\"\n" +
+		"}\n"
+	expected := "variable \"region\" {\n\tdefault=\"us-east-1\"\n}\n\n" +
+		"source \"amazon-ebs\" \"example\" {\n" +
+		"  region = var.region\n\n" +
+		"  ssh_timeout  = \"10m\"\n" +
+		"  ssh_username = \"ubuntu\"\n" +
+		"}\n"
+
+	output, diags := FixHCL([]byte(input), "template.pkr.hcl")
+	if diags.HasErrors() {
+		t.Fatal(diags)
+	}
+	if diff := cmp.Diff(expected, string(output)); diff != "" {
+		t.Errorf("unexpected fixed template: %s", diff)
+	}
+}
+
+func TestFixHCL_invalid(t *testing.T) {
+	_, diags := FixHCL([]byte(`source "amazon-ebs" {`), "template.pkr.hcl")
+	if !diags.HasErrors() {
+		t.Error("expected an invalid template not to be fixed")
+	}
+}
@@ -93,6 +93,38 @@ func IsPluginPartNormalized(str string) (bool, error) {
 	return false, nil
 }
 
+// ParsePluginHostname processes the hostname of a plugin source configured in
+// the `sources` setting, producing a normalized version if possible or an
+// error if the string is not a valid hostname.
+//
+// The hostname is transformed to lowercase per the usual DNS case mapping and
+// normalization rules. Ports are not allowed, because the hostname is part of
+// the path plugins are installed in.
+func ParsePluginHostname(given string) (string, error) {
+	if len(given) == 0 {
+		return "", fmt.Errorf("must have at least one character")
+	}
+	if strings.ContainsRune(given, ':') {
+		return "", fmt.Errorf("ports are not allowed")
+	}
+
+	result, err := idna.Lookup.ToUnicode(given)
+	if err != nil {
+		return "", fmt.Errorf("must be a valid hostname: %w", err)
+	}
+	labels := strings.Split(result, ".")
+	if len(labels) < 2 {
+		return "", fmt.Errorf("must be a fully qualified hostname, like github.com")
+	}
+	for _, label := range labels {
+		if label == "" {
+			return "", fmt.Errorf("must be a valid hostname: empty label")
+		}
+	}
+
+	return result, nil
+}
+
 // ParsePluginSourceString parses the source attribute and returns a plugin.
 // This is intended primarily to parse the FQN-like strings
 //
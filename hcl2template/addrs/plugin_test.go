@@ -20,6 +20,8 @@ func TestParsePluginSourceString(t *testing.T) {
 		{args{"potato"}, nil, true},
 		{args{"hashicorp/azr"}, nil, true},
 		{args{"github.com/hashicorp/azr"}, &Plugin{"github.com", "hashicorp", "azr"}, false},
+		{args{"localhost/infra/azr"}, &Plugin{"localhost", "infra", "azr"}, false},
+		{args{"gitlab.example.com:8443/infra/azr"}, &Plugin{"gitlab.example.com:8443", "infra", "azr"}, false},
 	}
 	for _, tt := range tests {
 		t.Run(tt.args.str, func(t *testing.T) {
@@ -33,3 +35,29 @@ func TestParsePluginSourceString(t *testing.T) {
 		})
 	}
 }
+
+func TestParsePluginHostname(t *testing.T) {
+	tests := []struct {
+		given   string
+		want    string
+		wantErr bool
+	}{
+		{"gitlab.example.com", "gitlab.example.com", false},
+		{"GitLab.Example.com", "gitlab.example.com", false},
+		{"gitlab.example.com:8443", "", true},
+		{"localhost", "", true},
+		{"gitlab..com", "", true},
+		{"", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.given, func(t *testing.T) {
+			got, err := ParsePluginHostname(tt.given)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePluginHostname() unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ParsePluginHostname() got = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
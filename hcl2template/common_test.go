@@ -4,6 +4,7 @@
 package hcl2template
 
 import (
+	"strings"
 	"testing"
 	"time"
 
@@ -153,6 +154,77 @@ func testParse_only_Parse(t *testing.T, tests []parseTest) {
 	}
 }
 
+// parseErrorTest is a test parsing and initializing filename, expecting it
+// to fail with an error containing wantErr, or to succeed when it is empty.
+type parseErrorTest struct {
+	filename string
+	wantErr  string
+}
+
+func testParseErrors(t *testing.T, tests []parseErrorTest) {
+	t.Helper()
+
+	for _, tt := range tests {
+		t.Run(tt.filename, func(t *testing.T) {
+			_, diags := parseAndInitialize(tt.filename)
+			if tt.wantErr == "" {
+				if diags.HasErrors() {
+					t.Fatalf("unexpected diagnostics: %s", diags)
+				}
+				return
+			}
+			if !diags.HasErrors() || !strings.Contains(diags.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got: %s", tt.wantErr, diags)
+			}
+		})
+	}
+}
+
+// parseAndInitialize parses filename with the basic parser and initializes
+// the config, unless parsing it failed.
+func parseAndInitialize(filename string) (*PackerConfig, hcl.Diagnostics) {
+	cfg, diags := getBasicParser().Parse(filename, nil, nil)
+	if diags.HasErrors() {
+		return cfg, diags
+	}
+	return cfg, append(diags, cfg.Initialize(packer.InitializeOptions{})...)
+}
+
+// getBuilds returns the builds of the config in filename, which must be
+// parsed and initialized without errors.
+func getBuilds(t *testing.T, filename string, opts packer.GetBuildsOptions) ([]packersdk.Build, hcl.Diagnostics) {
+	t.Helper()
+
+	cfg, diags := parseAndInitialize(filename)
+	if diags.HasErrors() {
+		t.Fatal(diags)
+	}
+	return cfg.GetBuilds(opts)
+}
+
+// getBuildsWithoutErrors returns the builds of the config in filename, which
+// must be created without errors.
+func getBuildsWithoutErrors(t *testing.T, filename string, opts packer.GetBuildsOptions) []packersdk.Build {
+	t.Helper()
+
+	builds, diags := getBuilds(t, filename, opts)
+	if diags.HasErrors() {
+		t.Fatal(diags)
+	}
+	return builds
+}
+
+// testGetBuildsError checks that creating the builds of the config in
+// filename fails with a single error with the given summary.
+func testGetBuildsError(t *testing.T, filename, summary string) {
+	t.Helper()
+
+	_, diags := getBuilds(t, filename, packer.GetBuildsOptions{})
+	if len(diags) != 1 || diags[0].Summary != summary {
+		t.Errorf("%s: expected a %q error, got %s", filename, summary, diags)
+	}
+}
+
 var (
 	// everything in the tests is a basicNestedMockConfig this allow to test
 	// each known type to packer ( and embedding ) in one go.
@@ -349,6 +421,7 @@ var cmpOpts = []cmp.Option{
 		PackerConfig{},
 		Variable{},
 		SourceBlock{},
+		SourceUseBlock{},
 		DatasourceBlock{},
 		ProvisionerBlock{},
 		PostProcessorBlock{},
@@ -358,6 +431,7 @@ var cmpOpts = []cmp.Option{
 		packer.CoreBuildPostProcessor{},
 		packer.CoreBuildProvisioner{},
 		packer.CoreBuildPostProcessor{},
+		packer.RetriedProvisioner{},
 		null.Builder{},
 	),
 	cmpopts.IgnoreFields(PackerConfig{},
@@ -376,6 +450,15 @@ var cmpOpts = []cmp.Option{
 	cmpopts.IgnoreFields(packer.CoreBuildPostProcessor{},
 		"HCLConfig",
 	),
+	cmpopts.IgnoreFields(packer.Condition{},
+		"Evaluate", // its a func
+	),
+	cmpopts.IgnoreFields(ProvisionerBlock{},
+		"When", // its an interface
+	),
+	cmpopts.IgnoreFields(PostProcessorBlock{},
+		"When", // its an interface
+	),
 	cmpopts.IgnoreTypes(hcl2template.MockBuilder{}),
 	cmpopts.IgnoreTypes(HCL2Ref{}),
 	cmpopts.IgnoreTypes([]*LocalBlock{}),
@@ -0,0 +1,110 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package hcl2template
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// ExplainVariable explains where the value of the input variable ref, like
+// `var.region`, comes from: every assignment of the variable, in precedence
+// order.
+func (cfg *PackerConfig) ExplainVariable(ref string) (string, hcl.Diagnostics) {
+	ref = strings.TrimSpace(ref)
+	name := strings.TrimPrefix(ref, inputVariablesAccessor+".")
+	variable, found := cfg.InputVariables[name]
+	if !found || name == ref {
+		return "", hcl.Diagnostics{&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  fmt.Sprintf("Unknown input variable %q", ref),
+			Detail:   "Only input variables, like var.region, can be explained.",
+		}}
+	}
+	return variable.Explain(), nil
+}
+
+// Explain lists the assignments of the variable in precedence order: its
+// default, the PKR_VAR_ environment variables, the auto var files, the var
+// files and the -var arguments. The last assignment is the one used. The
+// assignments that failed their type conversion or the validation of the
+// variable are marked so.
+func (v *Variable) Explain() string {
+	out := &strings.Builder{}
+	fmt.Fprintf(out, "var.%s: %s\n", v.Name, v.explainValue(v.Value()))
+	if len(v.Values) == 0 {
+		out.WriteString("\nThe variable is not set and has no default value.\n")
+		return out.String()
+	}
+
+	out.WriteString("\nAssignments, in precedence order:\n")
+	for i, assignment := range v.Values {
+		fmt.Fprintf(out, "\n  %d. %s", i+1, assignment.origin(v.Name))
+		if assignment.explainFilename() != "" {
+			fmt.Fprintf(out, " at %s", assignment.Expr.Range())
+		}
+		if i == len(v.Values)-1 {
+			out.WriteString(" (used)")
+		}
+		fmt.Fprintf(out, "\n     value: %s\n", v.explainValue(assignment.Value))
+
+		convDiags := v.conversionDiags[i]
+		diags := convDiags
+		if !diags.HasErrors() {
+			diags = v.validateValue(assignment)
+		}
+		for _, diag := range diags {
+			if diag.Severity != hcl.DiagError {
+				continue
+			}
+			reason := "failed validation"
+			if convDiags.HasErrors() {
+				reason = "failed type conversion"
+			}
+			fmt.Fprintf(out, "     %s: %s\n", reason, strings.TrimSpace(strings.SplitN(diag.Detail, "\n", 2)[0]))
+		}
+	}
+	return out.String()
+}
+
+// explainValue prints value like inspect, unless the variable is sensitive.
+func (v *Variable) explainValue(value cty.Value) string {
+	if v.Sensitive {
+		return fmt.Sprintf("%q", sensitiveValue)
+	}
+	return fmt.Sprintf("%q", PrintableCtyValue(value))
+}
+
+// origin tells where the assignment of the variable name was made.
+func (a VariableAssignment) origin(name string) string {
+	switch a.From {
+	case "env":
+		return "environment variable " + VarEnvPrefix + name
+	case "varfile":
+		filename := a.explainFilename()
+		if strings.HasSuffix(filename, hcl2AutoVarFileExt) || strings.HasSuffix(filename, hcl2AutoVarJsonFileExt) {
+			return "auto var file"
+		}
+		return "-var-file"
+	case "cmd":
+		return "-var"
+	}
+	return a.From
+}
+
+// explainFilename returns the file of the assignment, the values of the
+// environment and of the arguments having none.
+func (a VariableAssignment) explainFilename() string {
+	if a.Expr == nil {
+		return ""
+	}
+	filename := a.Expr.Range().Filename
+	if strings.HasPrefix(filename, "<") {
+		return ""
+	}
+	return filename
+}
@@ -0,0 +1,97 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package hcl2template
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestPackerConfig_ExplainVariable(t *testing.T) {
+	t.Setenv("PKR_VAR_region", "eu-central-1")
+	t.Setenv("PKR_VAR_token", "secret")
+
+	dir := filepath.Join("testdata", "explain")
+	cfg, _ := getBasicParser().Parse(dir,
+		[]string{filepath.Join(dir, "prod.pkrvars.hcl")},
+		map[string]string{"region": "us-west-2"})
+
+	tests := []struct {
+		ref      string
+		expected string
+		wantErr  bool
+	}{
+		{"var.region", `var.region: "us-west-2"
+
+Assignments, in precedence order:
+
+  1. default at testdata/explain/variables.pkr.hcl:4,13-24
+     value: "eu-west-1"
+
+  2. environment variable PKR_VAR_region
+     value: "eu-central-1"
+
+  3. auto var file at testdata/explain/region.auto.pkrvars.hcl:1,10-21
+     value: "us-east-1"
+
+  4. -var-file at testdata/explain/prod.pkrvars.hcl:1,10-14
+     value: "us"
+     failed validation: The region must be a valid region.
+
+  5. -var (used)
+     value: "us-west-2"
+`, false},
+		{"var.zones", `var.zones: "<unknown>"
+
+Assignments, in precedence order:
+
+  1. default at testdata/explain/variables.pkr.hcl:14,13-14
+     value: "3"
+
+  2. auto var file at testdata/explain/region.auto.pkrvars.hcl:2,10-17 (used)
+     value: "<unknown>"
+     failed type conversion: The value for zones is not compatible with the variable's type constraint: a number is required.
+`, false},
+		{"var.token", `var.token: "<sensitive>"
+
+Assignments, in precedence order:
+
+  1. environment variable PKR_VAR_token (used)
+     value: "<sensitive>"
+`, false},
+		{"var.unset", `var.unset: "<unknown>"
+
+The variable is not set and has no default value.
+`, false},
+		{"region", "", true},
+		{"local.region", "", true},
+		{"var.unknown", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			out, diags := cfg.ExplainVariable(tt.ref)
+			if diags.HasErrors() != tt.wantErr {
+				t.Fatalf("ExplainVariable() unexpected diagnostics: %s", diags)
+			}
+			if diff := cmp.Diff(tt.expected, filepath.ToSlash(out)); diff != "" {
+				t.Errorf("unexpected explanation: %s", diff)
+			}
+		})
+	}
+
+	out, _, diags := cfg.EvaluateExpression(":explain var.token")
+	if diags.HasErrors() {
+		t.Fatalf("EvaluateExpression() unexpected diagnostics: %s", diags)
+	}
+	if expected := `var.token: "<sensitive>"
+
+Assignments, in precedence order:
+
+  1. environment variable PKR_VAR_token (used)
+     value: "<sensitive>"`; out != expected {
+		t.Errorf("unexpected console output: %s", cmp.Diff(expected, out))
+	}
+}
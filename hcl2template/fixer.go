@@ -0,0 +1,160 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package hcl2template
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/packer/fix"
+	"github.com/hashicorp/packer/packer"
+)
+
+// HCL2Fixer runs the fixers of the fix package on HCL2 template files.
+type HCL2Fixer struct {
+	ShowDiff, Write bool
+	Output          io.Writer
+}
+
+// Fix runs the fixers on the HCL2 template files in path and returns the
+// number of files that needed fixing. Path can be a directory or a file.
+//
+// The fixed files are overwritten when f.Write is true, and a diff of the
+// changes is outputted when f.ShowDiff is true. Otherwise, the fixed template
+// is outputted, which only works when path is a file.
+func (f *HCL2Fixer) Fix(path string) (int, hcl.Diagnostics) {
+	if f.Output == nil {
+		f.Output = os.Stdout
+	}
+
+	hclFiles, jsonFiles, diags := GetHCL2Files(path, hcl2FileExt, hcl2JsonFileExt)
+	if diags.HasErrors() {
+		return 0, diags
+	}
+	for _, filename := range jsonFiles {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagWarning,
+			Summary:  fmt.Sprintf("%s was not fixed", filename),
+			Detail:   "Only HCL2 templates written in the native syntax can be fixed.",
+		})
+	}
+	if !f.Write && !f.ShowDiff && len(hclFiles) > 1 {
+		return 0, append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Cannot output the fixed templates of a directory",
+			Detail: "Only the template of a single file can be outputted. Use " +
+				"-inplace to fix the files of a directory, or -diff to show " +
+				"their changes.",
+		})
+	}
+
+	var fixedFiles int
+	for _, filename := range hclFiles {
+		fixed, moreDiags := f.fixFile(filename)
+		diags = append(diags, moreDiags...)
+		if fixed {
+			fixedFiles++
+		}
+	}
+	return fixedFiles, diags
+}
+
+func (f *HCL2Fixer) fixFile(filename string) (bool, hcl.Diagnostics) {
+	src, err := os.ReadFile(filename)
+	if err != nil {
+		return false, hcl.Diagnostics{&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  fmt.Sprintf("Failed to read %s", filename),
+			Detail:   err.Error(),
+		}}
+	}
+
+	fixed, diags := fix.FixHCL(src, filename)
+	if diags.HasErrors() {
+		return false, diags
+	}
+
+	if !f.Write && !f.ShowDiff {
+		_, _ = f.Output.Write(fixed)
+	}
+	if bytes.Equal(src, fixed) {
+		return false, diags
+	}
+
+	if f.Write {
+		if err := os.WriteFile(filename, fixed, 0644); err != nil {
+			return true, append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  fmt.Sprintf("Failed to write %s", filename),
+				Detail:   err.Error(),
+			})
+		}
+		_, _ = fmt.Fprintf(f.Output, "%s\n", filename)
+	}
+
+	if f.ShowDiff {
+		diff, err := bytesDiff(src, fixed, filename)
+		if err != nil {
+			return true, append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  fmt.Sprintf("Failed to generate diff for %s", filename),
+				Detail:   err.Error(),
+			})
+		}
+		_, _ = f.Output.Write(diff)
+	}
+
+	return true, diags
+}
+
+// FixConfig reports, as errors, the changes the fixers would make to the
+// HCL2 template files of the config, with their diff.
+func (p *PackerConfig) FixConfig(opts packer.FixConfigOptions) (diags hcl.Diagnostics) {
+	// The files are fixed by `packer fix`, which only needs their syntax.
+	if opts.Mode != packer.Diff {
+		return append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  fmt.Sprintf("FixConfig only supports template diff; FixConfigMode %d not supported", opts.Mode),
+		})
+	}
+
+	var filenames []string
+	files := map[string]*hcl.File{}
+	for filename, file := range p.parser.Files() {
+		for _, configFile := range p.files {
+			if file == configFile && strings.HasSuffix(filename, hcl2FileExt) {
+				filenames = append(filenames, filename)
+				files[filename] = file
+			}
+		}
+	}
+	sort.Strings(filenames)
+
+	for _, filename := range filenames {
+		src := files[filename].Bytes
+		fixed, moreDiags := fix.FixHCL(src, filename)
+		diags = append(diags, moreDiags...)
+		if moreDiags.HasErrors() || bytes.Equal(src, fixed) {
+			continue
+		}
+
+		diff, err := bytesDiff(src, fixed, filename)
+		if err != nil {
+			// Without the diff command, the fixed file is shown instead.
+			diff = fixed
+		}
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary: fmt.Sprintf("Fixable configuration found in %s.\n"+
+				"Please run `packer fix` to get your build to run correctly.", filename),
+			Detail: string(diff),
+		})
+	}
+	return diags
+}
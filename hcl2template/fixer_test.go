@@ -0,0 +1,104 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package hcl2template
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/packer/packer"
+)
+
+func TestHCL2Fixer_Fix(t *testing.T) {
+	fixed, err := os.ReadFile("testdata/fix/fixed.pkr.hcl")
+	if err != nil {
+		t.Fatalf("failed to open the fixed fixture %s", err)
+	}
+
+	var buf bytes.Buffer
+	f := HCL2Fixer{Output: &buf}
+	fixedFiles, diags := f.Fix("testdata/fix/fixable.pkr.hcl")
+	if diags.HasErrors() {
+		t.Fatalf("the call to Fix failed unexpectedly %s", diags.Error())
+	}
+	if fixedFiles != 1 {
+		t.Errorf("expected 1 fixed file, got %d", fixedFiles)
+	}
+	if diff := cmp.Diff(string(fixed), buf.String()); diff != "" {
+		t.Errorf("unexpected fixed template: %s", diff)
+	}
+
+	buf.Reset()
+	fixedFiles, diags = f.Fix("testdata/fix/fixed.pkr.hcl")
+	if diags.HasErrors() {
+		t.Fatalf("the call to Fix failed unexpectedly %s", diags.Error())
+	}
+	if fixedFiles != 0 || buf.String() != string(fixed) {
+		t.Errorf("expected the fixed template to be left as is, got %d fixed files and %q", fixedFiles, buf.String())
+	}
+
+	if _, diags := f.Fix("testdata/fix"); !diags.HasErrors() {
+		t.Error("expected an error when outputting the fixed templates of a directory")
+	}
+}
+
+func TestHCL2Fixer_Fix_Write(t *testing.T) {
+	fixable, err := os.ReadFile("testdata/fix/fixable.pkr.hcl")
+	if err != nil {
+		t.Fatalf("failed to open the fixable fixture %s", err)
+	}
+	fixed, err := os.ReadFile("testdata/fix/fixed.pkr.hcl")
+	if err != nil {
+		t.Fatalf("failed to open the fixed fixture %s", err)
+	}
+
+	filename := filepath.Join(t.TempDir(), "template.pkr.hcl")
+	if err := os.WriteFile(filename, fixable, 0644); err != nil {
+		t.Fatalf("failed to write the template %s", err)
+	}
+
+	var buf bytes.Buffer
+	f := HCL2Fixer{Output: &buf, Write: true}
+	if _, diags := f.Fix(filepath.Dir(filename)); diags.HasErrors() {
+		t.Fatalf("the call to Fix failed unexpectedly %s", diags.Error())
+	}
+	if buf.String() != filename+"\n" {
+		t.Errorf("expected the name of the fixed file to be outputted, got %q", buf.String())
+	}
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("failed to open the fixed template %s", err)
+	}
+	if diff := cmp.Diff(string(fixed), string(data)); diff != "" {
+		t.Errorf("unexpected fixed template: %s", diff)
+	}
+}
+
+func TestPackerConfig_FixConfig(t *testing.T) {
+	cfg, diags := getBasicParser().Parse("testdata/fix/fixable.pkr.hcl", nil, nil)
+	if diags.HasErrors() {
+		t.Fatalf("failed to parse the template %s", diags.Error())
+	}
+
+	diags = cfg.FixConfig(packer.FixConfigOptions{Mode: packer.Diff})
+	if len(diags) != 1 || !strings.HasPrefix(diags[0].Summary, "Fixable configuration found in testdata/fix/fixable.pkr.hcl") {
+		t.Fatalf("expected the fixable configuration to be reported, got %s", diags.Error())
+	}
+	if !strings.Contains(diags[0].Detail, `ssh_timeout  = "5m"`) {
+		t.Errorf("expected the fix in the details, got %q", diags[0].Detail)
+	}
+
+	cfg, diags = getBasicParser().Parse("testdata/fix/fixed.pkr.hcl", nil, nil)
+	if diags.HasErrors() {
+		t.Fatalf("failed to parse the template %s", diags.Error())
+	}
+	if diags := cfg.FixConfig(packer.FixConfigOptions{Mode: packer.Diff}); len(diags) > 0 {
+		t.Errorf("expected nothing to fix, got %s", diags.Error())
+	}
+}
@@ -0,0 +1,7 @@
+provisioner "shell-local" {
+  inline      = ["echo 'hi'"]
+  max_retries = 3
+  retry {
+    max_retries = 5
+  }
+}
@@ -0,0 +1,7 @@
+provisioner "shell-local" {
+  inline = ["echo 'hi'"]
+  retry {
+    max_retries = 5
+    jitter      = 2
+  }
+}
@@ -0,0 +1,13 @@
+provisioner "shell-local" {
+  inline = ["apt-get update"]
+  retry {
+    max_retries         = 5
+    initial_backoff     = "2s"
+    max_backoff         = "30s"
+    multiplier          = 3
+    jitter              = 0.2
+    max_duration        = "5m"
+    retry_on            = ["Could not resolve host", "50[0-9] "]
+    retry_on_exit_codes = [100]
+  }
+}
@@ -0,0 +1,379 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package hcl2template
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/ext/typeexpr"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/packer/packer"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// sensitiveValue replaces the sensitive values in the resolved config.
+const sensitiveValue = "<sensitive>"
+
+// inspectJSON is the resolved config, as outputted by `packer inspect
+// -format=json`.
+type inspectJSON struct {
+	Variables   map[string]inspectVariableJSON `json:"variables"`
+	Locals      map[string]inspectLocalJSON    `json:"locals"`
+	DataSources map[string]inspectLocalJSON    `json:"data_sources"`
+	Builds      []inspectBuildJSON             `json:"builds"`
+}
+
+type inspectVariableJSON struct {
+	Type        string      `json:"type"`
+	Description string      `json:"description,omitempty"`
+	Sensitive   bool        `json:"sensitive,omitempty"`
+	Value       interface{} `json:"value"`
+	// From tells where the value was set: default, env, varfile or cmd.
+	From string `json:"from,omitempty"`
+}
+
+type inspectLocalJSON struct {
+	Sensitive bool        `json:"sensitive,omitempty"`
+	Value     interface{} `json:"value"`
+}
+
+type inspectBuildJSON struct {
+	Name        string              `json:"name,omitempty"`
+	Description string              `json:"description,omitempty"`
+	Sources     []inspectSourceJSON `json:"sources"`
+}
+
+// inspectSourceJSON is a source of a build, with the provisioners and
+// post-processors that run for it.
+type inspectSourceJSON struct {
+	Name                    string                   `json:"name"`
+	Type                    string                   `json:"type"`
+	Config                  interface{}              `json:"config"`
+	Provisioners            []inspectComponentJSON   `json:"provisioners"`
+	ErrorCleanupProvisioner *inspectComponentJSON    `json:"error_cleanup_provisioner,omitempty"`
+	PostProcessors          [][]inspectComponentJSON `json:"post_processors"`
+}
+
+// inspectComponentJSON is a provisioner or a post-processor, or a group of
+// provisioners.
+type inspectComponentJSON struct {
+	Type         string                 `json:"type"`
+	Name         string                 `json:"name,omitempty"`
+	Config       interface{}            `json:"config,omitempty"`
+	Parallel     bool                   `json:"parallel,omitempty"`
+	Provisioners []inspectComponentJSON `json:"provisioners,omitempty"`
+}
+
+// inspectJSON resolves the config: the values of its variables, locals and
+// data sources, and the configuration of each source of its builds with their
+// provisioners and post-processors.
+//
+// The arguments are evaluated as they would be for a build, without starting
+// the plugins; the values only known during a build, like the ones of
+// `build`, are null. The values of sensitive variables and locals are
+// redacted, in the values of the config too. So are the outputs of the data
+// sources, which can be secrets, unless showDataSources is set.
+func (cfg *PackerConfig) inspectJSON(showDataSources bool) inspectJSON {
+	redact := cfg.inspectRedactor(showDataSources)
+	out := inspectJSON{
+		Variables:   map[string]inspectVariableJSON{},
+		Locals:      map[string]inspectLocalJSON{},
+		DataSources: map[string]inspectLocalJSON{},
+		Builds:      []inspectBuildJSON{},
+	}
+
+	for name, v := range cfg.InputVariables {
+		variable := inspectVariableJSON{
+			Type:        typeexpr.TypeString(v.Type),
+			Description: v.Description,
+			Sensitive:   v.Sensitive,
+			Value:       inspectValueJSON(v.Value(), v.Sensitive, redact),
+		}
+		if len(v.Values) > 0 {
+			variable.From = v.Values[len(v.Values)-1].From
+		}
+		out.Variables[name] = variable
+	}
+	for name, v := range cfg.LocalVariables {
+		out.Locals[name] = inspectLocalJSON{
+			Sensitive: v.Sensitive,
+			Value:     inspectValueJSON(v.Value(), v.Sensitive, redact),
+		}
+	}
+	for ref, datasource := range cfg.Datasources {
+		value := datasource.value
+		if value.Type() == cty.NilType {
+			// the data source was not executed
+			value = cty.NullVal(cty.DynamicPseudoType)
+		}
+		out.DataSources[ref.Type+"."+ref.Name] = inspectLocalJSON{
+			Sensitive: !showDataSources,
+			Value:     inspectValueJSON(value, !showDataSources, redact),
+		}
+	}
+
+	for _, build := range cfg.Builds {
+		b := inspectBuildJSON{
+			Name:        build.Name,
+			Description: build.Description,
+			Sources:     []inspectSourceJSON{},
+		}
+		for _, srcUsage := range build.Sources {
+			b.Sources = append(b.Sources, cfg.inspectSourceJSON(build, srcUsage, redact))
+		}
+		out.Builds = append(out.Builds, b)
+	}
+	return out
+}
+
+func (cfg *PackerConfig) inspectSourceJSON(build *BuildBlock, srcUsage SourceUseBlock, redact func(string) string) inspectSourceJSON {
+	unknownBuildValues := map[string]cty.Value{}
+	for _, k := range packer.BuilderDataCommonKeys {
+		unknownBuildValues[k] = cty.UnknownVal(cty.String)
+	}
+	unknownBuildValues["name"] = cty.StringVal(build.Name)
+	ectx := cfg.EvalContext(BuildContext, map[string]cty.Value{
+		sourcesAccessor: cty.ObjectVal(srcUsage.ctyValues()),
+		buildAccessor:   cty.ObjectVal(unknownBuildValues),
+	})
+	for k, v := range srcUsage.variables {
+		ectx.Variables[k] = v
+	}
+
+	src := inspectSourceJSON{
+		Name:           srcUsage.String(),
+		Type:           srcUsage.Type,
+		Provisioners:   []inspectComponentJSON{},
+		PostProcessors: [][]inspectComponentJSON{},
+	}
+	if source, found := cfg.Sources[srcUsage.SourceRef]; found && srcUsage.Body != nil {
+		// The body of a source use is merged with the body of the source
+		// block, which has its syntax.
+		value := cfg.inspectBodyValue(srcUsage.Body, source.block.DefRange, ectx)
+		src.Config = inspectValueJSON(value, false, redact)
+	}
+
+	var provisioners func(blocks []*ProvisionerBlock) []inspectComponentJSON
+	provisioners = func(blocks []*ProvisionerBlock) []inspectComponentJSON {
+		res := []inspectComponentJSON{}
+		for _, pb := range blocks {
+			if pb.OnlyExcept.Skip(srcUsage.String()) {
+				continue
+			}
+			if pb.PType == buildProvisionersLabel {
+				res = append(res, inspectComponentJSON{
+					Type:         pb.PType,
+					Name:         pb.PName,
+					Parallel:     pb.Parallel,
+					Provisioners: provisioners(pb.Provisioners),
+				})
+				continue
+			}
+			res = append(res, cfg.inspectComponentJSON(pb.PType, pb.PName, pb.HCL2Ref, ectx, redact))
+		}
+		return res
+	}
+	src.Provisioners = provisioners(build.ProvisionerBlocks)
+	if pb := build.ErrorCleanupProvisionerBlock; pb != nil && !pb.OnlyExcept.Skip(srcUsage.String()) {
+		component := cfg.inspectComponentJSON(pb.PType, pb.PName, pb.HCL2Ref, ectx, redact)
+		src.ErrorCleanupProvisioner = &component
+	}
+
+	for _, ppList := range build.PostProcessorsLists {
+		list := []inspectComponentJSON{}
+		for _, pp := range ppList {
+			if pp.OnlyExcept.Skip(srcUsage.String()) {
+				continue
+			}
+			list = append(list, cfg.inspectComponentJSON(pp.PType, pp.PName, pp.HCL2Ref, ectx, redact))
+		}
+		if len(list) > 0 {
+			src.PostProcessors = append(src.PostProcessors, list)
+		}
+	}
+	return src
+}
+
+func (cfg *PackerConfig) inspectComponentJSON(pType, pName string, ref HCL2Ref, ectx *hcl.EvalContext, redact func(string) string) inspectComponentJSON {
+	component := inspectComponentJSON{
+		Type:   pType,
+		Name:   pName,
+		Config: map[string]interface{}{},
+	}
+	if ref.Rest != nil {
+		value := cfg.inspectBodyValue(ref.Rest, ref.DefRange, ectx)
+		component.Config = inspectValueJSON(value, false, redact)
+	}
+	return component
+}
+
+// inspectBodyValue evaluates the arguments and the blocks of body into an
+// object; the blocks of a type are a list of objects. Without a schema, the
+// block types of body are found in the syntax of the block defined at
+// defRange. The arguments that fail to evaluate are null.
+func (cfg *PackerConfig) inspectBodyValue(body hcl.Body, defRange hcl.Range, ectx *hcl.EvalContext) cty.Value {
+	values := map[string]cty.Value{}
+
+	attrs, _ := body.JustAttributes()
+	for name, attr := range attrs {
+		value, diags := attr.Expr.Value(ectx)
+		if diags.HasErrors() {
+			value = cty.NullVal(cty.DynamicPseudoType)
+		}
+		values[name] = value
+	}
+
+	if syntax := cfg.syntaxBlockBody(defRange); syntax != nil {
+		schema := &hcl.BodySchema{}
+		seen := map[string]bool{}
+		for _, block := range syntax.Blocks {
+			blockType, labels := block.Type, len(block.Labels)
+			if blockType == "dynamic" && len(block.Labels) == 1 {
+				// dynamic blocks are expanded in blocks of their label.
+				blockType, labels = block.Labels[0], 0
+				if attr, found := block.Body.Attributes["labels"]; found {
+					if tuple, ok := attr.Expr.(*hclsyntax.TupleConsExpr); ok {
+						labels = len(tuple.Exprs)
+					}
+				}
+			}
+			if seen[blockType] {
+				continue
+			}
+			seen[blockType] = true
+			schema.Blocks = append(schema.Blocks, hcl.BlockHeaderSchema{
+				Type:       blockType,
+				LabelNames: make([]string, labels),
+			})
+		}
+
+		content, _, _ := body.PartialContent(schema)
+		blocks := map[string][]cty.Value{}
+		for _, block := range content.Blocks {
+			blocks[block.Type] = append(blocks[block.Type], cfg.inspectBodyValue(block.Body, block.DefRange, ectx))
+		}
+		for blockType, vals := range blocks {
+			values[blockType] = cty.TupleVal(vals)
+		}
+	}
+
+	return cty.ObjectVal(values)
+}
+
+// syntaxBlockBody returns the body of the block of the config files defined at
+// defRange, if it is written in the native syntax.
+func (cfg *PackerConfig) syntaxBlockBody(defRange hcl.Range) *hclsyntax.Body {
+	var find func(body *hclsyntax.Body) *hclsyntax.Body
+	find = func(body *hclsyntax.Body) *hclsyntax.Body {
+		for _, block := range body.Blocks {
+			if block.DefRange() == defRange {
+				return block.Body
+			}
+			if found := find(block.Body); found != nil {
+				return found
+			}
+		}
+		return nil
+	}
+	for _, file := range cfg.files {
+		if body, ok := file.Body.(*hclsyntax.Body); ok {
+			if found := find(body); found != nil {
+				return found
+			}
+		}
+	}
+	return nil
+}
+
+// inspectRedactor returns a function replacing the string values of the
+// sensitive variables and locals with <sensitive>, like Packer filters them
+// from its logs, and the ones of the data sources unless showDataSources is
+// set.
+func (cfg *PackerConfig) inspectRedactor(showDataSources bool) func(string) string {
+	var secrets []string
+	addSecrets := func(value cty.Value) {
+		_ = cty.Walk(value, func(_ cty.Path, nested cty.Value) (bool, error) {
+			if nested.IsWhollyKnown() && !nested.IsNull() && nested.Type().Equals(cty.String) && nested.AsString() != "" {
+				secrets = append(secrets, nested.AsString())
+			}
+			return true, nil
+		})
+	}
+	for _, variables := range []Variables{cfg.InputVariables, cfg.LocalVariables} {
+		for _, variable := range variables {
+			if variable.Sensitive {
+				addSecrets(variable.Value())
+			}
+		}
+	}
+	if !showDataSources {
+		for _, datasource := range cfg.Datasources {
+			if datasource.value.Type() != cty.NilType {
+				addSecrets(datasource.value)
+			}
+		}
+	}
+	// Longer secrets first, so that a secret containing another one is
+	// replaced as a whole.
+	sort.Slice(secrets, func(i, j int) bool { return len(secrets[i]) > len(secrets[j]) })
+
+	return func(s string) string {
+		for _, secret := range secrets {
+			s = strings.ReplaceAll(s, secret, sensitiveValue)
+		}
+		return s
+	}
+}
+
+// marshalInspectJSON returns the indented JSON encoding of the resolved
+// config. Unlike json.MarshalIndent, it does not escape the <, > and &
+// characters, common in commands and redacted values.
+func (cfg *PackerConfig) marshalInspectJSON(showDataSources bool) ([]byte, error) {
+	out := &bytes.Buffer{}
+	enc := json.NewEncoder(out)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	err := enc.Encode(cfg.inspectJSON(showDataSources))
+	return out.Bytes(), err
+}
+
+// inspectValueJSON returns value as a value to encode in JSON, with its
+// unknown values set to null and its strings redacted. A sensitive value is
+// replaced as a whole.
+func inspectValueJSON(value cty.Value, sensitive bool, redact func(string) string) interface{} {
+	if sensitive {
+		value = cty.StringVal(sensitiveValue)
+	}
+	value, err := cty.Transform(value, func(_ cty.Path, v cty.Value) (cty.Value, error) {
+		switch {
+		case !v.IsKnown():
+			return cty.NullVal(v.Type()), nil
+		case v.IsNull():
+			return v, nil
+		case v.Type() == cty.String:
+			return cty.StringVal(redact(v.AsString())), nil
+		}
+		return v, nil
+	})
+	if err != nil {
+		return nil
+	}
+	b, err := ctyjson.SimpleJSONValue{Value: value}.MarshalJSON()
+	if err != nil {
+		return nil
+	}
+	// numbers are decoded as json.Number to keep their precision.
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var out interface{}
+	if err := dec.Decode(&out); err != nil {
+		return nil
+	}
+	return out
+}
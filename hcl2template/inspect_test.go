@@ -0,0 +1,42 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package hcl2template
+
+import (
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/packer/packer"
+)
+
+func TestPackerConfig_inspectJSON(t *testing.T) {
+	cfg, diags := getBasicParser().Parse("testdata/inspect/inspect.pkr.hcl", nil, nil)
+	if diags.HasErrors() {
+		t.Fatalf("failed to parse the template %s", diags.Error())
+	}
+	// like inspect, the unset variables are ignored
+	_ = cfg.Initialize(packer.InitializeOptions{})
+
+	tests := []struct {
+		showDataSources bool
+		expected        string
+	}{
+		{false, "testdata/inspect/expected.json"},
+		{true, "testdata/inspect/expected_data_sources.json"},
+	}
+	for _, tt := range tests {
+		out, err := cfg.marshalInspectJSON(tt.showDataSources)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected, err := os.ReadFile(tt.expected)
+		if err != nil {
+			t.Fatalf("failed to open the expected output %s", err)
+		}
+		if diff := cmp.Diff(string(expected), string(out)); diff != "" {
+			t.Errorf("unexpected output for %s: %s", tt.expected, diff)
+		}
+	}
+}
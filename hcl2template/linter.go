@@ -0,0 +1,266 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package hcl2template
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// A LintRule checks a template for a kind of issue that does not make it
+// invalid, but is likely to be a mistake or a bad practice.
+type LintRule interface {
+	// Lint returns the issues found in the config, as diagnostics whose
+	// Subject is the range of the issue.
+	Lint(cfg *PackerConfig) hcl.Diagnostics
+
+	// Synopsis returns a string description of what the rule checks.
+	Synopsis() string
+}
+
+// LintRules is the map of all available lint rules, by name.
+var LintRules map[string]LintRule
+
+// LintRuleOrder is the order the lint rules are run, and their issues
+// reported for a same range.
+var LintRuleOrder []string
+
+func init() {
+	LintRules = map[string]LintRule{
+		"unused-declarations":        new(lintUnusedDeclarations),
+		"sensitive-inline":           new(lintSensitiveInline),
+		"provisioner-timeout":        new(lintProvisionerTimeout),
+		"discarded-artifacts":        new(lintDiscardedArtifacts),
+		"hard-coded-credentials":     new(lintHardCodedCredentials),
+		"plugin-version-constraints": new(lintPluginVersionConstraints),
+	}
+
+	LintRuleOrder = []string{
+		"unused-declarations",
+		"sensitive-inline",
+		"provisioner-timeout",
+		"discarded-artifacts",
+		"hard-coded-credentials",
+		"plugin-version-constraints",
+	}
+}
+
+// lintIgnoreDirective is the comment ignoring the issues of a line: the next
+// one when the comment is on its own line, or its line otherwise. It can be
+// followed by a comma separated list of the rules to ignore, all of them are
+// ignored otherwise.
+const lintIgnoreDirective = "packer-lint-ignore"
+
+// LintIssue is an issue found by the Rule lint rule.
+type LintIssue struct {
+	Rule string
+	*hcl.Diagnostic
+}
+
+// LintConfig configures the lint rules, it is decoded from a lint config file
+// like:
+//
+//	rule "provisioner-timeout" {
+//	  enabled = false
+//	}
+type LintConfig struct {
+	Rules []LintRuleConfig
+}
+
+// LintRuleConfig configures the Name lint rule.
+type LintRuleConfig struct {
+	Name    string
+	Enabled bool `hcl:"enabled"`
+}
+
+// Enabled tells whether the rule is enabled, all the rules are enabled unless
+// disabled by the config.
+func (c *LintConfig) Enabled(rule string) bool {
+	if c == nil {
+		return true
+	}
+	for _, ruleConfig := range c.Rules {
+		if ruleConfig.Name == rule {
+			return ruleConfig.Enabled
+		}
+	}
+	return true
+}
+
+var lintConfigSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{
+		{Type: "rule", LabelNames: []string{"name"}},
+	},
+}
+
+// DecodeLintConfigFile decodes the lint config file filename.
+func DecodeLintConfigFile(filename string) (*LintConfig, hcl.Diagnostics) {
+	f, diags := hclparse.NewParser().ParseHCLFile(filename)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	content, moreDiags := f.Body.Content(lintConfigSchema)
+	diags = append(diags, moreDiags...)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	config := &LintConfig{}
+	for _, block := range content.Blocks {
+		ruleConfig := LintRuleConfig{Name: block.Labels[0]}
+		if _, found := LintRules[ruleConfig.Name]; !found {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  fmt.Sprintf("Unknown lint rule %q", ruleConfig.Name),
+				Detail:   fmt.Sprintf("Known rules: %v", LintRuleOrder),
+				Subject:  block.LabelRanges[0].Ptr(),
+			})
+			continue
+		}
+		diags = append(diags, gohcl.DecodeBody(block.Body, nil, &ruleConfig)...)
+		config.Rules = append(config.Rules, ruleConfig)
+	}
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	return config, diags
+}
+
+// Lint runs the lint rules enabled by config on the config files and returns
+// the issues they found, sorted by position. The issues of the lines ignored
+// with a `packer-lint-ignore` comment are left out.
+//
+// Lint is meant to run on an initialized config; to find issues even when
+// plugins are missing, the initialization errors can be ignored.
+func (cfg *PackerConfig) Lint(config *LintConfig) []LintIssue {
+	ignored := cfg.lintIgnoredLines()
+
+	var issues []LintIssue
+	for _, rule := range LintRuleOrder {
+		if !config.Enabled(rule) {
+			continue
+		}
+		for _, diag := range LintRules[rule].Lint(cfg) {
+			if diag.Subject != nil && ignored.ignores(rule, diag.Subject) {
+				continue
+			}
+			issues = append(issues, LintIssue{Rule: rule, Diagnostic: diag})
+		}
+	}
+
+	order := map[string]int{}
+	for i, rule := range LintRuleOrder {
+		order[rule] = i
+	}
+	sort.SliceStable(issues, func(i, j int) bool {
+		a, b := issues[i].Subject, issues[j].Subject
+		switch {
+		case a == nil || b == nil:
+			return a == nil && b != nil
+		case a.Filename != b.Filename:
+			return a.Filename < b.Filename
+		case a.Start.Line != b.Start.Line:
+			return a.Start.Line < b.Start.Line
+		case a.Start.Column != b.Start.Column:
+			return a.Start.Column < b.Start.Column
+		}
+		return order[issues[i].Rule] < order[issues[j].Rule]
+	})
+	return issues
+}
+
+// lintIgnores are the rules ignored for each line of each file, a nil list of
+// rules ignoring all of them.
+type lintIgnores map[string]map[int][]string
+
+func (ignores lintIgnores) ignores(rule string, subject *hcl.Range) bool {
+	rules, found := ignores[subject.Filename][subject.Start.Line]
+	if !found {
+		return false
+	}
+	if rules == nil {
+		return true
+	}
+	for _, ignored := range rules {
+		if ignored == rule {
+			return true
+		}
+	}
+	return false
+}
+
+// lintIgnoredLines finds the `packer-lint-ignore` comments of the config files
+// written in the native syntax.
+func (cfg *PackerConfig) lintIgnoredLines() lintIgnores {
+	ignores := lintIgnores{}
+	for filename, file := range cfg.parser.Files() {
+		if _, ok := file.Body.(*hclsyntax.Body); !ok {
+			continue
+		}
+		tokens, _ := hclsyntax.LexConfig(file.Bytes, filename, hcl.InitialPos)
+
+		// A comment is on its own line when it is the first token of the
+		// line.
+		lastLine := 0
+		for _, token := range tokens {
+			if token.Type != hclsyntax.TokenComment {
+				if token.Type != hclsyntax.TokenNewline {
+					lastLine = token.Range.End.Line
+				}
+				continue
+			}
+
+			rules, ok := parseLintIgnoreComment(string(token.Bytes))
+			if !ok {
+				continue
+			}
+			line := token.Range.Start.Line
+			if lastLine != line {
+				line++
+			}
+			if ignores[filename] == nil {
+				ignores[filename] = map[int][]string{}
+			}
+			ignores[filename][line] = rules
+		}
+	}
+	return ignores
+}
+
+// parseLintIgnoreComment returns the rules ignored by comment, which are nil
+// when all of them are ignored, and whether comment is an ignore directive.
+func parseLintIgnoreComment(comment string) ([]string, bool) {
+	comment = strings.TrimSpace(comment)
+	switch {
+	case strings.HasPrefix(comment, "#"):
+		comment = strings.TrimPrefix(comment, "#")
+	case strings.HasPrefix(comment, "//"):
+		comment = strings.TrimPrefix(comment, "//")
+	case strings.HasPrefix(comment, "/*"):
+		comment = strings.TrimSuffix(strings.TrimPrefix(comment, "/*"), "*/")
+	}
+	comment = strings.TrimSpace(comment)
+	if !strings.HasPrefix(comment, lintIgnoreDirective) {
+		return nil, false
+	}
+	comment = strings.TrimPrefix(comment, lintIgnoreDirective)
+	if comment != "" && comment[0] != ' ' && comment[0] != '\t' {
+		// packer-lint-ignored or the like.
+		return nil, false
+	}
+
+	var rules []string
+	for _, rule := range strings.Split(comment, ",") {
+		if rule = strings.TrimSpace(rule); rule != "" {
+			rules = append(rules, rule)
+		}
+	}
+	return rules, true
+}
@@ -0,0 +1,443 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package hcl2template
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// lintUnusedDeclarations reports the variables, locals and data sources that
+// are never referenced.
+type lintUnusedDeclarations struct{}
+
+func (*lintUnusedDeclarations) Synopsis() string {
+	return "Reports the variables, locals and data sources that are declared but never used."
+}
+
+func (*lintUnusedDeclarations) Lint(cfg *PackerConfig) hcl.Diagnostics {
+	var traversals []hcl.Traversal
+	for _, file := range cfg.files {
+		body, ok := file.Body.(*hclsyntax.Body)
+		if !ok {
+			// References can't be found in JSON files, so everything could
+			// be used there.
+			return nil
+		}
+		traversals = append(traversals, lintBodyTraversals(body)...)
+	}
+
+	// References are kept up to the declaration they use, like `var.name`
+	// or `data.type.name`; `var` alone uses all the variables.
+	used := map[string]bool{}
+	for _, traversal := range traversals {
+		names := []string{traversal.RootName()}
+		for _, step := range traversal[1:] {
+			name, ok := lintTraversalStepName(step)
+			if !ok {
+				break
+			}
+			names = append(names, name)
+		}
+		depth := 2
+		if names[0] == dataAccessor {
+			depth = 3
+		}
+		if len(names) > depth {
+			names = names[:depth]
+		}
+		used[strings.Join(names, ".")] = true
+	}
+	isUsed := func(accessor string, names ...string) bool {
+		ref := accessor
+		for _, name := range names {
+			if used[ref] {
+				return true
+			}
+			ref += "." + name
+		}
+		return used[ref]
+	}
+
+	var diags hcl.Diagnostics
+	for _, name := range cfg.InputVariables.Keys() {
+		if !isUsed(inputVariablesAccessor, name) {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagWarning,
+				Summary:  fmt.Sprintf("Unused variable %q", name),
+				Detail:   "The variable is declared but never used, it can be removed.",
+				Subject:  cfg.InputVariables[name].Range.Ptr(),
+			})
+		}
+	}
+	for _, local := range cfg.LocalBlocks {
+		if local.Expr != nil && !isUsed(localsAccessor, local.Name) {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagWarning,
+				Summary:  fmt.Sprintf("Unused local %q", local.Name),
+				Detail:   "The local is declared but never used, it can be removed.",
+				Subject:  local.Expr.Range().Ptr(),
+			})
+		}
+	}
+	for _, ref := range sortedDatasourceRefs(cfg.Datasources) {
+		if !isUsed(dataAccessor, ref.Type, ref.Name) {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagWarning,
+				Summary:  fmt.Sprintf("Unused data source %q", ref.Type+"."+ref.Name),
+				Detail: "The data source is declared but never used, it can be " +
+					"removed. It still runs, unless validating the template.",
+				Subject: cfg.Datasources[ref].block.DefRange.Ptr(),
+			})
+		}
+	}
+	return diags
+}
+
+// lintBodyTraversals returns the traversals of the expressions of body and of
+// its blocks, except for the ones of variable blocks, whose validations
+// reference the variable itself.
+func lintBodyTraversals(body *hclsyntax.Body) []hcl.Traversal {
+	var traversals []hcl.Traversal
+	for _, attr := range body.Attributes {
+		traversals = append(traversals, attr.Expr.Variables()...)
+	}
+	for _, block := range body.Blocks {
+		if block.Type == variableLabel {
+			continue
+		}
+		traversals = append(traversals, lintBodyTraversals(block.Body)...)
+	}
+	return traversals
+}
+
+// lintTraversalStepName returns the name of the attribute step selects, like
+// `name` for `.name` or `["name"]`.
+func lintTraversalStepName(step hcl.Traverser) (string, bool) {
+	switch step := step.(type) {
+	case hcl.TraverseAttr:
+		return step.Name, true
+	case hcl.TraverseIndex:
+		if step.Key.Type() == cty.String && step.Key.IsKnown() && !step.Key.IsNull() {
+			return step.Key.AsString(), true
+		}
+	}
+	return "", false
+}
+
+func sortedDatasourceRefs(datasources Datasources) []DatasourceRef {
+	refs := make([]DatasourceRef, 0, len(datasources))
+	for ref := range datasources {
+		refs = append(refs, ref)
+	}
+	sort.Slice(refs, func(i, j int) bool {
+		if refs[i].Type != refs[j].Type {
+			return refs[i].Type < refs[j].Type
+		}
+		return refs[i].Name < refs[j].Name
+	})
+	return refs
+}
+
+// lintSensitiveInline reports the sensitive variables and locals used in the
+// inline commands of provisioners, which are often shown in logs.
+type lintSensitiveInline struct{}
+
+func (*lintSensitiveInline) Synopsis() string {
+	return "Reports the sensitive variables interpolated in the inline commands of provisioners."
+}
+
+func (*lintSensitiveInline) Lint(cfg *PackerConfig) hcl.Diagnostics {
+	sensitive := map[string]bool{}
+	for name, variable := range cfg.InputVariables {
+		if variable.Sensitive {
+			sensitive[inputVariablesAccessor+"."+name] = true
+		}
+	}
+	isSensitive := func(traversal hcl.Traversal) (string, bool) {
+		if len(traversal) < 2 {
+			return "", false
+		}
+		name, ok := lintTraversalStepName(traversal[1])
+		ref := traversal.RootName() + "." + name
+		return ref, ok && sensitive[ref]
+	}
+	usesSensitive := func(expr hcl.Expression) bool {
+		for _, traversal := range expr.Variables() {
+			if _, ok := isSensitive(traversal); ok {
+				return true
+			}
+		}
+		return false
+	}
+	// A local computed from a sensitive value is sensitive too.
+	for changed := true; changed; {
+		changed = false
+		for _, local := range cfg.LocalBlocks {
+			ref := localsAccessor + "." + local.Name
+			if sensitive[ref] {
+				continue
+			}
+			if local.Sensitive || (local.Expr != nil && usesSensitive(local.Expr)) {
+				sensitive[ref] = true
+				changed = true
+			}
+		}
+	}
+
+	var diags hcl.Diagnostics
+	for _, build := range cfg.Builds {
+		for _, provisioner := range lintBuildProvisioners(build) {
+			if provisioner.Rest == nil {
+				continue
+			}
+			content, _, _ := provisioner.Rest.PartialContent(&hcl.BodySchema{
+				Attributes: []hcl.AttributeSchema{{Name: "inline"}},
+			})
+			inline, found := content.Attributes["inline"]
+			if !found {
+				continue
+			}
+			for _, traversal := range inline.Expr.Variables() {
+				if ref, ok := isSensitive(traversal); ok {
+					diags = append(diags, &hcl.Diagnostic{
+						Severity: hcl.DiagWarning,
+						Summary:  fmt.Sprintf("Sensitive %s used in inline commands", ref),
+						Detail: fmt.Sprintf("The commands of the %s provisioner can "+
+							"be shown in logs, or kept on the machine it provisions. "+
+							"Pass the sensitive value to the commands in an "+
+							"environment variable instead.", provisioner.PType),
+						Subject: traversal.SourceRange().Ptr(),
+					})
+				}
+			}
+		}
+	}
+	return diags
+}
+
+// lintBuildProvisioners returns the provisioners of build, including the
+// grouped and error-cleanup ones.
+func lintBuildProvisioners(build *BuildBlock) []*ProvisionerBlock {
+	provisioners := flattenProvisionerBlocks(build.ProvisionerBlocks)
+	if build.ErrorCleanupProvisionerBlock != nil {
+		provisioners = append(provisioners, build.ErrorCleanupProvisionerBlock)
+	}
+	return provisioners
+}
+
+// lintProvisionerTimeout reports the provisioners without a timeout.
+type lintProvisionerTimeout struct{}
+
+func (*lintProvisionerTimeout) Synopsis() string {
+	return "Reports the provisioners without a timeout."
+}
+
+func (*lintProvisionerTimeout) Lint(cfg *PackerConfig) hcl.Diagnostics {
+	var diags hcl.Diagnostics
+	for _, build := range cfg.Builds {
+		for _, provisioner := range lintBuildProvisioners(build) {
+			if provisioner.Timeout != 0 {
+				continue
+			}
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagWarning,
+				Summary:  fmt.Sprintf("The %s provisioner has no timeout", provisioner.PType),
+				Detail: "A provisioner that hangs blocks its build until the build " +
+					"is cancelled. Set how long it can run with the timeout " +
+					"argument, for example `timeout = \"30m\"`.",
+				Subject: provisioner.DefRange.Ptr(),
+			})
+		}
+	}
+	return diags
+}
+
+// lintDiscardedArtifacts reports the post-processors deleting the artifacts
+// they are given.
+type lintDiscardedArtifacts struct{}
+
+func (*lintDiscardedArtifacts) Synopsis() string {
+	return "Reports the post-processor chains that delete the artifacts of their builds."
+}
+
+func (*lintDiscardedArtifacts) Lint(cfg *PackerConfig) hcl.Diagnostics {
+	discards := func(pp *PostProcessorBlock) bool {
+		return pp.KeepInputArtifact != nil && !*pp.KeepInputArtifact
+	}
+
+	var diags hcl.Diagnostics
+	for _, build := range cfg.Builds {
+		var lists [][]*PostProcessorBlock
+		for _, list := range build.PostProcessorsLists {
+			if len(list) > 0 {
+				lists = append(lists, list)
+			}
+		}
+		if len(lists) == 0 {
+			continue
+		}
+
+		// The artifacts of the builders are kept as long as one of the
+		// first post-processors of the chains keeps them.
+		discardsBuilderArtifacts := true
+		for _, list := range lists {
+			discardsBuilderArtifacts = discardsBuilderArtifacts && discards(list[0])
+		}
+		if discardsBuilderArtifacts {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagWarning,
+				Summary:  "The post-processors delete the artifacts of the builders",
+				Detail: "The first post-processor of every chain sets " +
+					"keep_input_artifact to false, so the artifacts of the " +
+					"builders are deleted once post-processed.",
+				Subject: lists[0][0].DefRange.Ptr(),
+			})
+		}
+
+		for _, list := range lists {
+			for i := 1; i < len(list); i++ {
+				if discards(list[i]) {
+					diags = append(diags, &hcl.Diagnostic{
+						Severity: hcl.DiagWarning,
+						Summary: fmt.Sprintf("The %s post-processor deletes the artifact of the %s post-processor",
+							list[i].PType, list[i-1].PType),
+						Detail: "The post-processor sets keep_input_artifact to " +
+							"false, so the artifact it is given is deleted once " +
+							"post-processed.",
+						Subject: list[i].DefRange.Ptr(),
+					})
+				}
+			}
+		}
+	}
+	return diags
+}
+
+// lintHardCodedCredentials reports the credentials written as literal strings
+// in the config files.
+type lintHardCodedCredentials struct{}
+
+// lintCredentialName matches the names of the arguments and variables usually
+// holding credentials, like `ssh_password` or `secret_key`.
+var lintCredentialName = regexp.MustCompile(`^(.+_)?(password|passwd|secret|secret_key|token|api_key|api_token|access_key|access_token)$`)
+
+func (*lintHardCodedCredentials) Synopsis() string {
+	return "Reports the credentials, like passwords or tokens, hard-coded in the template."
+}
+
+func (*lintHardCodedCredentials) Lint(cfg *PackerConfig) hcl.Diagnostics {
+	var diags hcl.Diagnostics
+	report := func(name string, expr hcl.Expression) {
+		if !isLintLiteralString(expr) {
+			return
+		}
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagWarning,
+			Summary:  fmt.Sprintf("Hard-coded credentials in %s", name),
+			Detail: "Credentials written in a template can leak with it. Set " +
+				"them with a sensitive variable instead, from an environment " +
+				"variable or a variables file that is kept secret.",
+			Subject: expr.Range().Ptr(),
+		})
+	}
+
+	var walk func(body *hclsyntax.Body)
+	walk = func(body *hclsyntax.Body) {
+		for _, name := range sortedAttributeNames(body.Attributes) {
+			if lintCredentialName.MatchString(name) {
+				report(name, body.Attributes[name].Expr)
+			}
+		}
+		for _, block := range body.Blocks {
+			if block.Type != variableLabel {
+				walk(block.Body)
+				continue
+			}
+			def, found := block.Body.Attributes["default"]
+			if found && lintCredentialName.MatchString(block.Labels[0]) {
+				report("the default of the "+block.Labels[0]+" variable", def.Expr)
+			}
+		}
+	}
+	for _, file := range cfg.files {
+		if body, ok := file.Body.(*hclsyntax.Body); ok {
+			walk(body)
+		}
+	}
+	return diags
+}
+
+// isLintLiteralString tells whether expr is a non-empty string that does not
+// use variables or functions.
+func isLintLiteralString(expr hcl.Expression) bool {
+	if len(expr.Variables()) > 0 {
+		return false
+	}
+	val, diags := expr.Value(nil)
+	if diags.HasErrors() || !val.IsKnown() || val.IsNull() || val.Type() != cty.String {
+		return false
+	}
+	return val.AsString() != ""
+}
+
+func sortedAttributeNames(attrs hclsyntax.Attributes) []string {
+	names := make([]string, 0, len(attrs))
+	for name := range attrs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// lintPluginVersionConstraints reports the required plugins whose version
+// constraints let `packer init` install a new major version.
+type lintPluginVersionConstraints struct{}
+
+func (*lintPluginVersionConstraints) Synopsis() string {
+	return "Reports the required_plugins whose version constraints have no upper bound."
+}
+
+func (*lintPluginVersionConstraints) Lint(cfg *PackerConfig) hcl.Diagnostics {
+	var diags hcl.Diagnostics
+	for _, requiredPlugins := range cfg.Packer.RequiredPlugins {
+		names := make([]string, 0, len(requiredPlugins.RequiredPlugins))
+		for name := range requiredPlugins.RequiredPlugins {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			plugin := requiredPlugins.RequiredPlugins[name]
+			bounded := false
+			for _, constraint := range plugin.Requirement.Required {
+				c := strings.TrimSpace(constraint.String())
+				operator := c[:len(c)-len(strings.TrimLeft(c, "<>=~! "))]
+				switch strings.TrimSpace(operator) {
+				case "", "=", "<", "<=", "~>":
+					bounded = true
+				}
+			}
+			if bounded {
+				continue
+			}
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagWarning,
+				Summary:  fmt.Sprintf("The version of the %s plugin has no upper bound", name),
+				Detail: fmt.Sprintf("With the %q version constraint, `packer init` "+
+					"can install a new major version of the plugin, which can "+
+					"break the template. Use a pessimistic constraint instead, "+
+					"like \"~> 1.2\".", plugin.Requirement.Required.String()),
+				Subject: plugin.DeclRange.Ptr(),
+			})
+		}
+	}
+	return diags
+}
@@ -0,0 +1,98 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package hcl2template
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/packer/packer"
+)
+
+func TestPackerConfig_Lint(t *testing.T) {
+	lintConfig, diags := DecodeLintConfigFile("testdata/lint/lint.hcl")
+	if diags.HasErrors() {
+		t.Fatalf("failed to decode the lint config %s", diags.Error())
+	}
+
+	tests := []struct {
+		name   string
+		config *LintConfig
+		issues []string
+	}{
+		{
+			name: "all rules",
+			issues: []string{
+				"3: plugin-version-constraints: The version of the amazon plugin has no upper bound",
+				`19: unused-declarations: Unused variable "unused"`,
+				`32: unused-declarations: Unused local "unused"`,
+				`35: unused-declarations: Unused data source "amazon-ami.unused"`,
+				"42: hard-coded-credentials: Hard-coded credentials in ssh_password",
+				"51: sensitive-inline: Sensitive local.login used in inline commands",
+				"55: provisioner-timeout: The shell provisioner has no timeout",
+				"60: discarded-artifacts: The post-processors delete the artifacts of the builders",
+				"63: discarded-artifacts: The amazon-import post-processor deletes the artifact of the manifest post-processor",
+			},
+		},
+		{
+			name:   "disabled rule",
+			config: lintConfig,
+			issues: []string{
+				"3: plugin-version-constraints: The version of the amazon plugin has no upper bound",
+				`19: unused-declarations: Unused variable "unused"`,
+				`32: unused-declarations: Unused local "unused"`,
+				`35: unused-declarations: Unused data source "amazon-ami.unused"`,
+				"42: hard-coded-credentials: Hard-coded credentials in ssh_password",
+				"51: sensitive-inline: Sensitive local.login used in inline commands",
+				"60: discarded-artifacts: The post-processors delete the artifacts of the builders",
+				"63: discarded-artifacts: The amazon-import post-processor deletes the artifact of the manifest post-processor",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, diags := getBasicParser().Parse("testdata/lint/issues.pkr.hcl", nil, nil)
+			if diags.HasErrors() {
+				t.Fatalf("failed to parse the template %s", diags.Error())
+			}
+			_ = cfg.Initialize(packer.InitializeOptions{SkipDatasourcesExecution: true})
+
+			var issues []string
+			for _, issue := range cfg.Lint(tt.config) {
+				issues = append(issues, fmt.Sprintf("%d: %s: %s", issue.Subject.Start.Line, issue.Rule, issue.Summary))
+			}
+			if diff := cmp.Diff(tt.issues, issues); diff != "" {
+				t.Errorf("unexpected issues: %s", diff)
+			}
+		})
+	}
+}
+
+func TestDecodeLintConfigFile_unknownRule(t *testing.T) {
+	_, diags := DecodeLintConfigFile("testdata/lint/unknown_rule.hcl")
+	if !diags.HasErrors() || diags[0].Summary != `Unknown lint rule "provisioner-timeouts"` {
+		t.Errorf("expected an unknown rule error, got %v", diags)
+	}
+}
+
+func TestParseLintIgnoreComment(t *testing.T) {
+	tests := []struct {
+		comment string
+		rules   []string
+		ok      bool
+	}{
+		{comment: "# packer-lint-ignore\n", ok: true},
+		{comment: "// packer-lint-ignore sensitive-inline, provisioner-timeout", rules: []string{"sensitive-inline", "provisioner-timeout"}, ok: true},
+		{comment: "/* packer-lint-ignore hard-coded-credentials */", rules: []string{"hard-coded-credentials"}, ok: true},
+		{comment: "# packer-lint-ignored"},
+		{comment: "# a comment"},
+	}
+	for _, tt := range tests {
+		rules, ok := parseLintIgnoreComment(tt.comment)
+		if ok != tt.ok || !cmp.Equal(rules, tt.rules) {
+			t.Errorf("parseLintIgnoreComment(%q) = %v, %t; expected %v, %t", tt.comment, rules, ok, tt.rules, tt.ok)
+		}
+	}
+}
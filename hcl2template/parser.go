@@ -295,9 +295,8 @@ func filterVarsFromLogs(inputOrLocal Variables) {
 
 func (cfg *PackerConfig) Initialize(opts packer.InitializeOptions) hcl.Diagnostics {
 	diags := cfg.InputVariables.ValidateValues()
-	diags = append(diags, cfg.evaluateDatasources(opts.SkipDatasourcesExecution)...)
 	diags = append(diags, checkForDuplicateLocalDefinition(cfg.LocalBlocks)...)
-	diags = append(diags, cfg.evaluateLocalVariables(cfg.LocalBlocks)...)
+	diags = append(diags, cfg.evaluateLocalsAndDatasources(opts.SkipDatasourcesExecution)...)
 
 	filterVarsFromLogs(cfg.InputVariables)
 	filterVarsFromLogs(cfg.LocalVariables)
@@ -307,6 +306,7 @@ func (cfg *PackerConfig) Initialize(opts packer.InitializeOptions) hcl.Diagnosti
 		diags = append(diags, cfg.parser.parseConfig(file, cfg)...)
 	}
 
+	diags = append(diags, cfg.checkBuildDependencies()...)
 	diags = append(diags, cfg.initializeBlocks()...)
 
 	return diags
@@ -325,30 +325,42 @@ func (p *Parser) parseConfig(f *hcl.File, cfg *PackerConfig) hcl.Diagnostics {
 	for _, block := range content.Blocks {
 		switch block.Type {
 		case sourceLabel:
-			source, moreDiags := p.decodeSource(block)
+			sources, moreDiags := p.decodeSource(block, cfg.EvalContext(LocalContext, nil))
 			diags = append(diags, moreDiags...)
 			if moreDiags.HasErrors() {
 				continue
 			}
 
-			ref := source.Ref()
-			if existing, found := cfg.Sources[ref]; found {
-				diags = append(diags, &hcl.Diagnostic{
-					Severity: hcl.DiagError,
-					Summary:  "Duplicate " + sourceLabel + " block",
-					Detail: fmt.Sprintf("This "+sourceLabel+" block has the "+
-						"same builder type and name as a previous block declared "+
-						"at %s. Each "+sourceLabel+" must have a unique name per builder type.",
-						existing.block.DefRange.Ptr()),
-					Subject: source.block.DefRange.Ptr(),
-				})
-				continue
-			}
-
-			if cfg.Sources == nil {
-				cfg.Sources = map[SourceRef]SourceBlock{}
+			for _, source := range sources {
+				ref := source.Ref()
+				if existing, found := cfg.Sources[ref]; found {
+					diags = append(diags, &hcl.Diagnostic{
+						Severity: hcl.DiagError,
+						Summary:  "Duplicate " + sourceLabel + " block",
+						Detail: fmt.Sprintf("This "+sourceLabel+" block has the "+
+							"same builder type and name as a previous block declared "+
+							"at %s. Each "+sourceLabel+" must have a unique name per builder type.",
+							existing.block.DefRange.Ptr()),
+						Subject: source.block.DefRange.Ptr(),
+					})
+					continue
+				}
+
+				if cfg.Sources == nil {
+					cfg.Sources = map[SourceRef]SourceBlock{}
+				}
+				cfg.Sources[ref] = source
+
+				if source.variables != nil {
+					// Keep track of the instances of a repeated source, so
+					// that using the source uses all of them.
+					if cfg.sourceInstances == nil {
+						cfg.sourceInstances = map[SourceRef][]SourceRef{}
+					}
+					base := SourceRef{Type: block.Labels[0], Name: block.Labels[1]}
+					cfg.sourceInstances[base] = append(cfg.sourceInstances[base], ref)
+				}
 			}
-			cfg.Sources[ref] = source
 
 		case buildLabel:
 			build, moreDiags := p.decodeBuildConfig(block, cfg)
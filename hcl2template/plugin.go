@@ -77,6 +77,15 @@ func (cfg *PackerConfig) DetectPluginBinaries() hcl.Diagnostics {
 		return diags
 	}
 
+	lock, err := plugingetter.LoadLock(cfg.Basedir)
+	if err != nil {
+		return append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Failed to read the plugin lock file",
+			Detail:   err.Error(),
+		})
+	}
+
 	uninstalledPlugins := map[string]string{}
 
 	for _, pluginRequirement := range pluginReqs {
@@ -95,6 +104,18 @@ func (cfg *PackerConfig) DetectPluginBinaries() hcl.Diagnostics {
 		}
 		log.Printf("[TRACE] Found the following %q installations: %v", pluginRequirement.Identifier, sortedInstalls)
 		install := sortedInstalls[len(sortedInstalls)-1]
+		if lock.Exists() {
+			install, err = selectLockedInstallation(lock, pluginRequirement, sortedInstalls)
+			if err != nil {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  fmt.Sprintf("Plugin %s does not match the lock file", pluginRequirement.Identifier),
+					Detail: fmt.Sprintf("%s\n\nRun packer init to install the locked version, "+
+						"or packer init -upgrade to update %s.", err, lock.Path()),
+				})
+				continue
+			}
+		}
 		err = cfg.parser.PluginConfig.DiscoverMultiPlugin(pluginRequirement.Accessor, install.BinaryPath)
 		if err != nil {
 			diags = append(diags, &hcl.Diagnostic{
@@ -123,11 +144,26 @@ func (cfg *PackerConfig) DetectPluginBinaries() hcl.Diagnostics {
 	return diags
 }
 
+// selectLockedInstallation returns the installation of the version locked for
+// req, when its binary matches the locked checksum.
+func selectLockedInstallation(lock *plugingetter.Lock, req *plugingetter.Requirement, installs plugingetter.InstallList) (*plugingetter.Installation, error) {
+	locked := lock.Plugin(req.Identifier.String())
+	if locked == nil {
+		return nil, fmt.Errorf("%s is not in the lock file", req.Identifier)
+	}
+	if err := locked.CheckConstraints(req.VersionConstraints); err != nil {
+		return nil, err
+	}
+	return locked.SelectInstallation(installs)
+}
+
 func (cfg *PackerConfig) initializeBlocks() hcl.Diagnostics {
 	// verify that all used plugins do exist
 	var diags hcl.Diagnostics
 
 	for _, build := range cfg.Builds {
+		diags = append(diags, cfg.expandSourceUses(build)...)
+
 		for i := range build.Sources {
 			// here we grab a pointer to the source usage because we will set
 			// its body.
@@ -167,7 +203,7 @@ func (cfg *PackerConfig) initializeBlocks() hcl.Diagnostics {
 			srcUsage.Body = body
 		}
 
-		for _, provBlock := range build.ProvisionerBlocks {
+		for _, provBlock := range flattenProvisionerBlocks(build.ProvisionerBlocks) {
 			if !cfg.parser.PluginConfig.Provisioners.Has(provBlock.PType) {
 				diags = append(diags, &hcl.Diagnostic{
 					Summary:  fmt.Sprintf("Unknown "+buildProvisionerLabel+" type %q", provBlock.PType),
@@ -206,3 +242,17 @@ func (cfg *PackerConfig) initializeBlocks() hcl.Diagnostics {
 
 	return diags
 }
+
+// flattenProvisionerBlocks returns the provisioner blocks, with the ones of
+// the provisioners blocks in place of them.
+func flattenProvisionerBlocks(blocks []*ProvisionerBlock) []*ProvisionerBlock {
+	var res []*ProvisionerBlock
+	for _, pb := range blocks {
+		if pb.PType == buildProvisionersLabel {
+			res = append(res, flattenProvisionerBlocks(pb.Provisioners)...)
+			continue
+		}
+		res = append(res, pb)
+	}
+	return res
+}
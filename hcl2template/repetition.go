@@ -0,0 +1,155 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package hcl2template
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/gocty"
+)
+
+const (
+	forEachAccessor = "each"
+	countAccessor   = "count"
+)
+
+// repetitionSchema holds the meta-arguments used to repeat a block.
+var repetitionSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{Name: "for_each"},
+		{Name: "count"},
+	},
+}
+
+// blockInstance is one of the instances of a block repeated with for_each or
+// count.
+type blockInstance struct {
+	// key identifies the instance: the for_each key, or the count index.
+	key string
+	// variables are the `each` or `count` variables available in the body of
+	// the instance.
+	variables map[string]cty.Value
+}
+
+// decodeInstances evaluates the for_each and count meta-arguments of a block
+// and returns its instances. It returns nil when neither is set.
+//
+// for_each can be a map, an object or a set of strings; each.key and
+// each.value are then available in the body of the instances. count is a
+// whole number; count.index is then available in the body of the instances.
+func decodeInstances(forEach, count hcl.Expression, ectx *hcl.EvalContext) ([]blockInstance, hcl.Diagnostics) {
+	hasForEach := !isNullExpression(forEach)
+	hasCount := !isNullExpression(count)
+
+	switch {
+	case hasForEach && hasCount:
+		return nil, hcl.Diagnostics{&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid combination of \"count\" and \"for_each\"",
+			Detail:   "The \"count\" and \"for_each\" meta-arguments are mutually-exclusive, only one should be used.",
+			Subject:  forEach.Range().Ptr(),
+		}}
+	case hasForEach:
+		return decodeForEach(forEach, ectx)
+	case hasCount:
+		return decodeCount(count, ectx)
+	}
+	return nil, nil
+}
+
+func decodeForEach(expr hcl.Expression, ectx *hcl.EvalContext) ([]blockInstance, hcl.Diagnostics) {
+	val, diags := expr.Value(ectx)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	invalid := func(detail string) hcl.Diagnostics {
+		return append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid for_each argument",
+			Detail:   detail,
+			Subject:  expr.Range().Ptr(),
+		})
+	}
+
+	if !val.IsWhollyKnown() {
+		return nil, invalid("The for_each value must be known when the configuration is loaded.")
+	}
+	if val.IsNull() {
+		return nil, invalid("The for_each value cannot be null.")
+	}
+
+	ty := val.Type()
+	switch {
+	case ty.IsMapType() || ty.IsObjectType():
+	case ty.IsSetType() && ty.ElementType() == cty.String:
+	default:
+		return nil, invalid(fmt.Sprintf("The for_each value must be a map, an object or a set of strings, got %s.", ty.FriendlyName()))
+	}
+
+	var instances []blockInstance
+	for it := val.ElementIterator(); it.Next(); {
+		k, v := it.Element()
+		if ty.IsSetType() {
+			k = v
+		}
+		if k.IsNull() {
+			return nil, invalid("The for_each keys cannot be null.")
+		}
+		key := k.AsString()
+		instances = append(instances, blockInstance{
+			key: key,
+			variables: map[string]cty.Value{
+				forEachAccessor: cty.ObjectVal(map[string]cty.Value{
+					"key":   cty.StringVal(key),
+					"value": v,
+				}),
+			},
+		})
+	}
+	return instances, diags
+}
+
+func decodeCount(expr hcl.Expression, ectx *hcl.EvalContext) ([]blockInstance, hcl.Diagnostics) {
+	val, diags := expr.Value(ectx)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	var count int
+	if !val.IsKnown() || val.IsNull() || val.Type() != cty.Number || gocty.FromCtyValue(val, &count) != nil || count < 0 {
+		return nil, append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid count argument",
+			Detail:   "The count value must be a known, positive, whole number.",
+			Subject:  expr.Range().Ptr(),
+		})
+	}
+
+	var instances []blockInstance
+	for i := 0; i < count; i++ {
+		instances = append(instances, blockInstance{
+			key: strconv.Itoa(i),
+			variables: map[string]cty.Value{
+				countAccessor: cty.ObjectVal(map[string]cty.Value{
+					"index": cty.NumberIntVal(int64(i)),
+				}),
+			},
+		})
+	}
+	return instances, diags
+}
+
+// isNullExpression tells whether expr is unset: gohcl sets optional
+// hcl.Expression fields to a null expression when the attribute is absent.
+func isNullExpression(expr hcl.Expression) bool {
+	if expr == nil {
+		return true
+	}
+	val, diags := expr.Value(nil)
+	return !diags.HasErrors() && val.IsNull()
+}
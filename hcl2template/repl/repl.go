@@ -5,3 +5,75 @@
 // HCL2. The REPL allows experimentation of HCL2 interpolations without having
 // to run a HCL2 configuration.
 package repl
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// closers are the tokens closing the tokens opening a nested part of an
+// expression.
+var closers = map[hclsyntax.TokenType]hclsyntax.TokenType{
+	hclsyntax.TokenOBrace:          hclsyntax.TokenCBrace,
+	hclsyntax.TokenOBrack:          hclsyntax.TokenCBrack,
+	hclsyntax.TokenOParen:          hclsyntax.TokenCParen,
+	hclsyntax.TokenTemplateInterp:  hclsyntax.TokenTemplateSeqEnd,
+	hclsyntax.TokenTemplateControl: hclsyntax.TokenTemplateSeqEnd,
+	hclsyntax.TokenOQuote:          hclsyntax.TokenCQuote,
+	hclsyntax.TokenOHeredoc:        hclsyntax.TokenCHeredoc,
+}
+
+// IsComplete tells whether src is a complete expression, or the start of an
+// expression spanning more lines: an expression with unclosed braces,
+// brackets, parentheses, template sequences or heredocs.
+//
+// A quoted string cannot span lines, so an expression ending in an unclosed
+// quoted string is complete, and invalid.
+func IsComplete(src string) bool {
+	tokens, _ := hclsyntax.LexExpression([]byte(src), "<console-input>", hcl.InitialPos)
+
+	var expected []hclsyntax.TokenType
+	for _, token := range tokens {
+		if closer, found := closers[token.Type]; found {
+			expected = append(expected, closer)
+			continue
+		}
+		if len(expected) == 0 {
+			continue
+		}
+		if token.Type == expected[len(expected)-1] {
+			expected = expected[:len(expected)-1]
+		}
+	}
+
+	return len(expected) == 0 || expected[len(expected)-1] == hclsyntax.TokenCQuote
+}
+
+// Complete completes the word ending line with the candidates starting with
+// it. Like readline completers, it returns the rest of each of these
+// candidates, sorted, and the length of the completed word.
+func Complete(line string, candidates []string) ([]string, int) {
+	start := strings.LastIndexFunc(line, func(r rune) bool {
+		return !isWordRune(r)
+	}) + 1
+	word := line[start:]
+
+	var completions []string
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, word) {
+			completions = append(completions, candidate[len(word):])
+		}
+	}
+	sort.Strings(completions)
+	return completions, len([]rune(word))
+}
+
+// isWordRune tells whether r is part of the words completed by Complete:
+// references, function names and meta-commands.
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("_-.:", r)
+}
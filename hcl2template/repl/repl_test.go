@@ -0,0 +1,64 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package repl
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestIsComplete(t *testing.T) {
+	tests := []struct {
+		src  string
+		want bool
+	}{
+		{``, true},
+		{`upper(var.foo)`, true},
+		{`upper(`, false},
+		{"upper(\n  var.foo\n)", true},
+		{"{\n  a = 1", false},
+		{"{\n  a = [1,\n", false},
+		{"{\n  a = [1, 2]\n}", true},
+		{`"${var.foo`, false},
+		{"\"${var.foo\n}\"", true},
+		{`"%{ if true }`, true},
+		{"<<EOF\nhello\n", false},
+		{"<<EOF\nhello\nEOF\n", true},
+		{"<<-EOF\n  ${upper(\"a\")}\n  EOF\n", true},
+		{`"unclosed`, true},
+		{`{ a = "unclosed`, true},
+		{`upper("a"))`, true},
+	}
+	for _, tt := range tests {
+		if got := IsComplete(tt.src); got != tt.want {
+			t.Errorf("IsComplete(%q) = %t, want %t", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestComplete(t *testing.T) {
+	candidates := []string{"var.foo", "var.foobar", "var.bar", "local.foo", "upper(", ":functions"}
+	tests := []struct {
+		line       string
+		want       []string
+		wantLength int
+	}{
+		{"var.f", []string{"oo", "oobar"}, 5},
+		{"upper(var.", []string{"bar", "foo", "foobar"}, 4},
+		{"up", []string{"per("}, 2},
+		{":f", []string{"unctions"}, 2},
+		{"nope", nil, 4},
+		{"", []string{":functions", "local.foo", "upper(", "var.bar", "var.foo", "var.foobar"}, 0},
+	}
+	for _, tt := range tests {
+		got, length := Complete(tt.line, candidates)
+		if diff := cmp.Diff(tt.want, got); diff != "" {
+			t.Errorf("Complete(%q) unexpected completions: %s", tt.line, diff)
+		}
+		if length != tt.wantLength {
+			t.Errorf("Complete(%q) length = %d, want %d", tt.line, length, tt.wantLength)
+		}
+	}
+}
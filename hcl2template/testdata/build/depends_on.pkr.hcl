@@ -0,0 +1,18 @@
+source "null" "test" {
+  communicator = "none"
+}
+
+build {
+  name    = "base"
+  sources = ["sources.null.test"]
+}
+
+build {
+  name       = "derived"
+  sources    = ["sources.null.test"]
+  depends_on = [build.base]
+
+  provisioner "shell" {
+    string = build.base.artifacts[0].id
+  }
+}
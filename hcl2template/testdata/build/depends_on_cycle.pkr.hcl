@@ -0,0 +1,21 @@
+source "null" "test" {
+  communicator = "none"
+}
+
+build {
+  name       = "a"
+  sources    = ["sources.null.test"]
+  depends_on = [build.c]
+}
+
+build {
+  name       = "b"
+  sources    = ["sources.null.test"]
+  depends_on = [build.a]
+}
+
+build {
+  name       = "c"
+  sources    = ["sources.null.test"]
+  depends_on = [build.b]
+}
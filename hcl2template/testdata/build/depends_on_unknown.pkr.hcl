@@ -0,0 +1,9 @@
+source "null" "test" {
+  communicator = "none"
+}
+
+build {
+  name       = "a"
+  sources    = ["sources.null.test"]
+  depends_on = [build.nope]
+}
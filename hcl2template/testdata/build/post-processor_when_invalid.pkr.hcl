@@ -0,0 +1,11 @@
+source "null" "test" {
+  communicator = "none"
+}
+
+build {
+  sources = ["sources.null.test"]
+
+  post-processor "manifest" {
+    when = build.name.enabled
+  }
+}
@@ -0,0 +1,29 @@
+variable "install_gpu_drivers" {
+  type    = bool
+  default = false
+}
+
+source "null" "test" {
+  communicator = "none"
+}
+
+build {
+  sources = ["sources.null.test"]
+
+  provisioner "shell" {
+    name = "gpu-drivers"
+    when = var.install_gpu_drivers
+  }
+
+  provisioner "shell" {
+    name = "on-host"
+    when = build.Host == "example.com"
+  }
+
+  provisioner "file" {
+  }
+
+  post-processor "manifest" {
+    when = !var.install_gpu_drivers
+  }
+}
@@ -0,0 +1,13 @@
+source "null" "test" {
+  communicator = "none"
+}
+
+build {
+  sources = ["sources.null.test"]
+
+  provisioners {
+    parallel = true
+
+    provisioner "nope" {}
+  }
+}
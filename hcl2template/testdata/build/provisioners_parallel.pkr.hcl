@@ -0,0 +1,32 @@
+source "null" "test" {
+  communicator = "none"
+}
+
+build {
+  sources = ["sources.null.test"]
+
+  provisioner "shell" {
+    string = "before"
+  }
+
+  provisioners {
+    name     = "downloads"
+    parallel = true
+
+    provisioner "shell" {
+      name = "a"
+    }
+    provisioner "file" {
+      name = "b"
+    }
+    provisioner "shell" {
+      except = ["null.test"]
+    }
+  }
+
+  provisioners {
+    provisioner "file" {
+      name = "after"
+    }
+  }
+}
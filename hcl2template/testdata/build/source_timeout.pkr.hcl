@@ -0,0 +1,20 @@
+source "null" "test" {
+  communicator = "none"
+  timeout      = "1h"
+}
+
+source "null" "other" {
+  communicator = "none"
+}
+
+build {
+  sources = ["sources.null.test"]
+
+  source "null.other" {
+    timeout = "30m"
+  }
+
+  source "null.other" {
+    name = "untimed"
+  }
+}
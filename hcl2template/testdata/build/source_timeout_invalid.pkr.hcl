@@ -0,0 +1,8 @@
+source "null" "test" {
+  communicator = "none"
+  timeout      = "soon"
+}
+
+build {
+  sources = ["sources.null.test"]
+}
@@ -0,0 +1,27 @@
+source "null" "test" {
+  communicator = "none"
+}
+
+build {
+  sources = ["sources.null.test"]
+
+  provisioner "shell" {}
+
+  test {
+    junit_report = "reports/${source.name}.xml"
+
+    assert "nginx-running" {
+      command        = "systemctl is-active nginx"
+      stdout_matches = "^active"
+    }
+
+    assert "no-apache" {
+      command   = "which apache2"
+      exit_code = 1
+    }
+
+    assert "nginx-config" {
+      file_exists = "/etc/nginx/nginx.conf"
+    }
+  }
+}
@@ -0,0 +1,14 @@
+source "null" "test" {
+  communicator = "none"
+}
+
+build {
+  sources = ["sources.null.test"]
+
+  test {
+    assert "both" {
+      command     = "true"
+      file_exists = "/tmp"
+    }
+  }
+}
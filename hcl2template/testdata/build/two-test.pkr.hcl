@@ -0,0 +1,19 @@
+source "null" "test" {
+  communicator = "none"
+}
+
+build {
+  sources = ["sources.null.test"]
+
+  test {
+    assert "a" {
+      command = "true"
+    }
+  }
+
+  test {
+    assert "b" {
+      command = "true"
+    }
+  }
+}
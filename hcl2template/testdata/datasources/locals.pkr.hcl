@@ -0,0 +1,16 @@
+variable "host" {
+  default = "example.com"
+}
+
+locals {
+  url    = "https://${var.host}/api"
+  result = "${data.null.api.output}/v1"
+}
+
+data "null" "api" {
+  input = local.url
+}
+
+data "null" "versioned" {
+  input = local.result
+}
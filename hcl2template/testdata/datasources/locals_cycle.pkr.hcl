@@ -0,0 +1,7 @@
+locals {
+  url = data.null.api.output
+}
+
+data "null" "api" {
+  input = local.url
+}
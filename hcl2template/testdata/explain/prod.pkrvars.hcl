@@ -0,0 +1 @@
+region = "us"
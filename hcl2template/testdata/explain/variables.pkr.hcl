@@ -0,0 +1,24 @@
+
+variable "region" {
+  type    = string
+  default = "eu-west-1"
+
+  validation {
+    condition     = length(var.region) > 3
+    error_message = "The region must be a valid region."
+  }
+}
+
+variable "zones" {
+  type    = number
+  default = 3
+}
+
+variable "token" {
+  type      = string
+  sensitive = true
+}
+
+variable "unset" {
+  type = string
+}
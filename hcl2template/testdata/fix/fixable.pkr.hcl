@@ -0,0 +1,11 @@
+# The ssh_wait_timeout option was renamed to ssh_timeout.
+source "null" "example" {
+  communicator     = "ssh"
+  ssh_host         = "127.0.0.1"
+  ssh_username     = "packer"
+  ssh_wait_timeout = "5m"
+}
+
+build {
+  sources = ["source.null.example"]
+}
@@ -0,0 +1,54 @@
+variable "password" {
+  type      = string
+  default   = "hunter2"
+  sensitive = true
+}
+
+variable "image" {
+  type        = string
+  description = "The image to build from."
+  default     = "ubuntu"
+}
+
+variable "unknown" {
+  type = list(string)
+}
+
+data "null" "token" {
+  input = "s3cr3t-token"
+}
+
+locals {
+  login         = "admin:${var.password}"
+  tags          = { image = var.image }
+  authorization = "Bearer ${data.null.token.output}"
+}
+
+source "virtualbox-iso" "example" {
+  iso_url = "https://example.com/${var.image}.iso"
+
+  nested {
+    name = source.name
+  }
+}
+
+build {
+  name = "inspected"
+
+  sources = ["source.virtualbox-iso.example"]
+
+  provisioner "shell" {
+    inline = ["echo ${local.login}", "echo ${build.name}", "echo ${build.ID}"]
+  }
+
+  provisioner "file" {
+    except      = ["virtualbox-iso.example"]
+    destination = "/tmp"
+  }
+
+  post-processors {
+    post-processor "manifest" {
+      output = "${source.name}.json"
+    }
+  }
+}
@@ -0,0 +1,67 @@
+packer {
+  required_plugins {
+    amazon = {
+      source  = "github.com/hashicorp/amazon"
+      version = ">= 1.0.0"
+    }
+    docker = {
+      source  = "github.com/hashicorp/docker"
+      version = "~> 1.0"
+    }
+  }
+}
+
+variable "password" {
+  type      = string
+  sensitive = true
+}
+
+variable "unused" {
+  type    = string
+  default = "foo"
+}
+
+# packer-lint-ignore
+variable "ignored" {
+  type    = string
+  default = "foo"
+}
+
+locals {
+  login  = "admin:${var.password}"
+  unused = "bar"
+}
+
+data "amazon-ami" "unused" {
+  filters = {}
+}
+
+source "null" "example" {
+  communicator = "ssh"
+  ssh_username = "packer"
+  ssh_password = "hunter2"
+  winrm_password = "hunter2" # packer-lint-ignore hard-coded-credentials
+}
+
+build {
+  sources = ["source.null.example"]
+
+  provisioner "shell" {
+    timeout = "10m"
+    inline  = ["echo ${local.login}"]
+  }
+
+  # packer-lint-ignore sensitive-inline
+  provisioner "shell" {
+    inline = ["echo hello"]
+  }
+
+  post-processors {
+    post-processor "manifest" {
+      keep_input_artifact = false
+    }
+    post-processor "amazon-import" {
+      keep_input_artifact = false
+    }
+  }
+}
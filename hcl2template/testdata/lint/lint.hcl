@@ -0,0 +1,7 @@
+rule "provisioner-timeout" {
+  enabled = false
+}
+
+rule "unused-declarations" {
+  enabled = true
+}
@@ -0,0 +1,3 @@
+rule "provisioner-timeouts" {
+  enabled = false
+}
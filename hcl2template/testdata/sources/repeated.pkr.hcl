@@ -0,0 +1,26 @@
+locals {
+  regions = {
+    us-east-1 = "ami-east"
+    eu-west-1 = "ami-west"
+  }
+}
+
+source "virtualbox-iso" "ubuntu" {
+  for_each = local.regions
+
+  string       = each.key
+  not_squashed = each.value
+}
+
+source "amazon-ebs" "ubuntu" {
+}
+
+build {
+  sources = ["sources.virtualbox-iso.ubuntu"]
+
+  source "amazon-ebs.ubuntu" {
+    count = 2
+
+    int = count.index + 10
+  }
+}
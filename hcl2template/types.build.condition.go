@@ -0,0 +1,101 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package hcl2template
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/packer/packer"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+)
+
+// unknownBuildValue is the placeholder set for the build values that are
+// generated by the builder, until it runs.
+var unknownBuildValue = cty.StringVal("<unknown>")
+
+// newCondition returns the condition set by the when attribute of a
+// provisioner or post-processor block, evaluated in the same context as the
+// rest of the block. It returns nil when the block has no when attribute.
+// The condition is evaluated once without generated data to report invalid
+// conditions before the build runs.
+func (cfg *PackerConfig) newCondition(when hcl.Expression, ectx *hcl.EvalContext) (*packer.Condition, hcl.Diagnostics) {
+	if isNullExpression(when) {
+		return nil, nil
+	}
+
+	condition := &packer.Condition{
+		Expression: cfg.expressionSource(when),
+		Evaluate: func(generatedData map[string]interface{}) (bool, bool, error) {
+			conditionCtx, err := conditionEvalContext(ectx, generatedData)
+			if err != nil {
+				return false, false, err
+			}
+
+			value, diags := when.Value(conditionCtx)
+			if diags.HasErrors() {
+				return false, false, diags
+			}
+			if !value.IsWhollyKnown() {
+				return false, false, nil
+			}
+			value, err = convert.Convert(value, cty.Bool)
+			if err != nil {
+				return false, false, err
+			}
+			if value.IsNull() {
+				return false, false, fmt.Errorf("a bool is required, got null")
+			}
+			return value.True(), true, nil
+		},
+	}
+
+	if _, _, err := condition.Evaluate(nil); err != nil {
+		return nil, hcl.Diagnostics{&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid when condition",
+			Detail:   err.Error(),
+			Subject:  when.Range().Ptr(),
+		}}
+	}
+	return condition, nil
+}
+
+// expressionSource returns the source of expr as written in its file.
+func (cfg *PackerConfig) expressionSource(expr hcl.Expression) string {
+	rng := expr.Range()
+	if file := cfg.parser.Files()[rng.Filename]; file != nil {
+		return string(rng.SliceBytes(file.Bytes))
+	}
+	return rng.String()
+}
+
+// conditionEvalContext returns ectx with the build values set from
+// generatedData. Without generated data, the build values generated by the
+// builder are unknown.
+func conditionEvalContext(ectx *hcl.EvalContext, generatedData map[string]interface{}) (*hcl.EvalContext, error) {
+	buildValues := map[string]cty.Value{}
+	if build := ectx.Variables[buildAccessor]; build.IsKnown() && !build.IsNull() {
+		for k, v := range build.AsValueMap() {
+			if v.RawEquals(unknownBuildValue) {
+				v = cty.UnknownVal(cty.String)
+			}
+			buildValues[k] = v
+		}
+	}
+	for k, v := range generatedData {
+		val, err := ConvertPluginConfigValueToHCLValue(v)
+		if err != nil {
+			return nil, err
+		}
+		buildValues[k] = val
+	}
+
+	conditionCtx := ectx.NewChild()
+	conditionCtx.Variables = map[string]cty.Value{
+		buildAccessor: cty.ObjectVal(buildValues),
+	}
+	return conditionCtx, nil
+}
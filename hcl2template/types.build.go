@@ -5,10 +5,12 @@ package hcl2template
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/gohcl"
 	"github.com/hashicorp/hcl/v2/hclsyntax"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
 	"github.com/zclconf/go-cty/cty"
 )
 
@@ -19,6 +21,8 @@ const (
 
 	buildProvisionerLabel = "provisioner"
 
+	buildProvisionersLabel = "provisioners"
+
 	buildErrorCleanupProvisionerLabel = "error-cleanup-provisioner"
 
 	buildPostProcessorLabel = "post-processor"
@@ -26,6 +30,8 @@ const (
 	buildPostProcessorsLabel = "post-processors"
 
 	buildHCPPackerRegistryLabel = "hcp_packer_registry"
+
+	buildTestLabel = "test"
 )
 
 var buildSchema = &hcl.BodySchema{
@@ -33,10 +39,18 @@ var buildSchema = &hcl.BodySchema{
 		{Type: buildFromLabel, LabelNames: []string{"type"}},
 		{Type: sourceLabel, LabelNames: []string{"reference"}},
 		{Type: buildProvisionerLabel, LabelNames: []string{"type"}},
+		{Type: buildProvisionersLabel, LabelNames: []string{}},
 		{Type: buildErrorCleanupProvisionerLabel, LabelNames: []string{"type"}},
 		{Type: buildPostProcessorLabel, LabelNames: []string{"type"}},
 		{Type: buildPostProcessorsLabel, LabelNames: []string{}},
 		{Type: buildHCPPackerRegistryLabel},
+		{Type: buildTestLabel},
+	},
+}
+
+var provisionersSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{
+		{Type: buildProvisionerLabel, LabelNames: []string{"type"}},
 	},
 }
 
@@ -63,6 +77,11 @@ type BuildBlock struct {
 	// call for example.
 	Description string
 
+	// DependsOn lists the names of the builds that must succeed before this
+	// build can start. Their artifacts can be referenced with
+	// `build.<name>.artifacts`.
+	DependsOn []string
+
 	// HCPPackerRegistry contains the configuration for publishing the image to the HCP Packer Registry.
 	HCPPackerRegistry *HCPPackerRegistryBlock
 
@@ -70,7 +89,8 @@ type BuildBlock struct {
 	Sources []SourceUseBlock
 
 	// ProvisionerBlocks references a list of HCL provisioner block that will
-	// will be ran against the sources.
+	// will be ran against the sources. A provisioners block is a
+	// ProvisionerBlock grouping other provisioners.
 	ProvisionerBlocks []*ProvisionerBlock
 
 	// ErrorCleanupProvisionerBlock references a special provisioner block that
@@ -82,6 +102,10 @@ type BuildBlock struct {
 	// steps.
 	PostProcessorsLists [][]*PostProcessorBlock
 
+	// TestBlock references the test block of the build, which assertions
+	// are checked after the provisioners ran.
+	TestBlock *TestBlock
+
 	HCL2Ref HCL2Ref
 }
 
@@ -91,10 +115,11 @@ type Builds []*BuildBlock
 // load the references to the contents of the build block.
 func (p *Parser) decodeBuildConfig(block *hcl.Block, cfg *PackerConfig) (*BuildBlock, hcl.Diagnostics) {
 	var b struct {
-		Name        string   `hcl:"name,optional"`
-		Description string   `hcl:"description,optional"`
-		FromSources []string `hcl:"sources,optional"`
-		Config      hcl.Body `hcl:",remain"`
+		Name        string         `hcl:"name,optional"`
+		Description string         `hcl:"description,optional"`
+		FromSources []string       `hcl:"sources,optional"`
+		DependsOn   hcl.Expression `hcl:"depends_on,optional"`
+		Config      hcl.Body       `hcl:",remain"`
 	}
 
 	body := block.Body
@@ -111,6 +136,13 @@ func (p *Parser) decodeBuildConfig(block *hcl.Block, cfg *PackerConfig) (*BuildB
 	build.Description = b.Description
 	build.HCL2Ref.DefRange = block.DefRange
 
+	dependsOn, moreDiags := decodeBuildDependsOn(b.DependsOn)
+	diags = append(diags, moreDiags...)
+	if moreDiags.HasErrors() {
+		return nil, diags
+	}
+	build.DependsOn = dependsOn
+
 	// Expose build.name during parsing of pps and provisioners
 	ectx := cfg.EvalContext(BuildContext, nil)
 	ectx.Variables[buildAccessor] = cty.ObjectVal(map[string]cty.Value{
@@ -173,12 +205,12 @@ func (p *Parser) decodeBuildConfig(block *hcl.Block, cfg *PackerConfig) (*BuildB
 			build.HCPPackerRegistry = hcpPackerRegistry
 		case sourceLabel:
 			hadSource = true
-			ref, moreDiags := p.decodeBuildSource(block)
+			refs, moreDiags := p.decodeBuildSource(block, cfg.EvalContext(LocalContext, nil))
 			diags = append(diags, moreDiags...)
 			if moreDiags.HasErrors() {
 				continue
 			}
-			build.Sources = append(build.Sources, ref)
+			build.Sources = append(build.Sources, refs...)
 		case buildProvisionerLabel:
 			p, moreDiags := p.decodeProvisioner(block, ectx)
 			diags = append(diags, moreDiags...)
@@ -186,6 +218,13 @@ func (p *Parser) decodeBuildConfig(block *hcl.Block, cfg *PackerConfig) (*BuildB
 				continue
 			}
 			build.ProvisionerBlocks = append(build.ProvisionerBlocks, p)
+		case buildProvisionersLabel:
+			p, moreDiags := p.decodeProvisioners(block, ectx)
+			diags = append(diags, moreDiags...)
+			if moreDiags.HasErrors() {
+				continue
+			}
+			build.ProvisionerBlocks = append(build.ProvisionerBlocks, p)
 		case buildErrorCleanupProvisionerLabel:
 			if build.ErrorCleanupProvisionerBlock != nil {
 				diags = append(diags, &hcl.Diagnostic{
@@ -201,6 +240,16 @@ func (p *Parser) decodeBuildConfig(block *hcl.Block, cfg *PackerConfig) (*BuildB
 				continue
 			}
 			build.ErrorCleanupProvisionerBlock = p
+		case buildTestLabel:
+			if build.TestBlock != nil {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  fmt.Sprintf("Only one " + buildTestLabel + " block is allowed"),
+					Subject:  block.DefRange.Ptr(),
+				})
+				continue
+			}
+			build.TestBlock = &TestBlock{HCL2Ref: newHCL2Ref(block, block.Body)}
 		case buildPostProcessorLabel:
 			pp, moreDiags := p.decodePostProcessor(block, ectx)
 			diags = append(diags, moreDiags...)
@@ -244,3 +293,172 @@ func (p *Parser) decodeBuildConfig(block *hcl.Block, cfg *PackerConfig) (*BuildB
 
 	return build, diags
 }
+
+// decodeBuildDependsOn decodes the `depends_on` attribute of a build block,
+// a list of `build.<name>` references.
+func decodeBuildDependsOn(expr hcl.Expression) ([]string, hcl.Diagnostics) {
+	if isNullExpression(expr) {
+		return nil, nil
+	}
+
+	exprs, diags := hcl.ExprList(expr)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	var dependsOn []string
+	for _, expr := range exprs {
+		traversal, moreDiags := hcl.AbsTraversalForExpr(expr)
+		diags = append(diags, moreDiags...)
+		if moreDiags.HasErrors() {
+			continue
+		}
+		var name hcl.TraverseAttr
+		ok := traversal.RootName() == buildAccessor && len(traversal) == 2
+		if ok {
+			name, ok = traversal[1].(hcl.TraverseAttr)
+		}
+		if !ok {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid depends_on reference",
+				Detail:   "A depends_on reference must be of the form `build.<name>`.",
+				Subject:  expr.Range().Ptr(),
+			})
+			continue
+		}
+		dependsOn = append(dependsOn, name.Name)
+	}
+	return dependsOn, diags
+}
+
+// checkBuildDependencies verifies that the builds referenced in the
+// depends_on attributes exist, and that there is no dependency cycle.
+func (cfg *PackerConfig) checkBuildDependencies() hcl.Diagnostics {
+	var diags hcl.Diagnostics
+
+	builds := map[string]*BuildBlock{}
+	for _, build := range cfg.Builds {
+		if build.Name != "" {
+			builds[build.Name] = build
+		}
+	}
+
+	for _, build := range cfg.Builds {
+		for _, dep := range build.DependsOn {
+			if _, found := builds[dep]; !found {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  fmt.Sprintf("Unknown build %q in depends_on", dep),
+					Detail:   "Builds can only depend on named build blocks.",
+					Subject:  build.HCL2Ref.DefRange.Ptr(),
+				})
+			}
+		}
+	}
+	if diags.HasErrors() {
+		return diags
+	}
+
+	// Depth first search, keeping the current path to report cycles.
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := map[string]int{}
+	var path []string
+	var visit func(name string) bool
+	visit = func(name string) bool {
+		switch state[name] {
+		case visiting:
+			cycle := append([]string{}, path...)
+			for i, n := range cycle {
+				if n == name {
+					cycle = cycle[i:]
+					break
+				}
+			}
+			cycle = append(cycle, name)
+			for i := range cycle {
+				cycle[i] = buildAccessor + "." + cycle[i]
+			}
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Cycle in build dependencies",
+				Detail:   "Builds cannot depend on each other: " + strings.Join(cycle, " -> "),
+				Subject:  builds[name].HCL2Ref.DefRange.Ptr(),
+			})
+			return false
+		case visited:
+			return true
+		}
+		state[name] = visiting
+		path = append(path, name)
+		for _, dep := range builds[name].DependsOn {
+			if !visit(dep) {
+				return false
+			}
+		}
+		path = path[:len(path)-1]
+		state[name] = visited
+		return true
+	}
+
+	for _, build := range cfg.Builds {
+		if build.Name == "" {
+			continue
+		}
+		if !visit(build.Name) {
+			break
+		}
+	}
+
+	return diags
+}
+
+// buildArtifactType is the type of the artifacts of a build, as exposed
+// through `build.<name>.artifacts` to the builds depending on it.
+var buildArtifactType = cty.Object(map[string]cty.Type{
+	"id":         cty.String,
+	"builder_id": cty.String,
+	"files":      cty.List(cty.String),
+	"string":     cty.String,
+})
+
+var buildDependencyType = cty.Object(map[string]cty.Type{
+	"artifacts": cty.List(buildArtifactType),
+})
+
+// buildDependencyValue returns the value of `build.<name>` for the given
+// artifacts.
+func buildDependencyValue(artifacts []packersdk.Artifact) cty.Value {
+	vals := []cty.Value{}
+	for _, artifact := range artifacts {
+		if artifact == nil {
+			continue
+		}
+		files := []cty.Value{}
+		for _, file := range artifact.Files() {
+			files = append(files, cty.StringVal(file))
+		}
+		filesVal := cty.ListValEmpty(cty.String)
+		if len(files) > 0 {
+			filesVal = cty.ListVal(files)
+		}
+		vals = append(vals, cty.ObjectVal(map[string]cty.Value{
+			"id":         cty.StringVal(artifact.Id()),
+			"builder_id": cty.StringVal(artifact.BuilderId()),
+			"files":      filesVal,
+			"string":     cty.StringVal(artifact.String()),
+		}))
+	}
+
+	artifactsVal := cty.ListValEmpty(buildArtifactType)
+	if len(vals) > 0 {
+		artifactsVal = cty.ListVal(vals)
+	}
+	return cty.ObjectVal(map[string]cty.Value{
+		"artifacts": artifactsVal,
+	})
+}
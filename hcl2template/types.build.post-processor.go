@@ -18,6 +18,9 @@ type PostProcessorBlock struct {
 	PName             string
 	OnlyExcept        OnlyExcept
 	KeepInputArtifact *bool
+	// When is the condition under which the post-processor runs, it is a
+	// null expression when unset.
+	When hcl.Expression
 
 	HCL2Ref
 }
@@ -28,11 +31,12 @@ func (p *PostProcessorBlock) String() string {
 
 func (p *Parser) decodePostProcessor(block *hcl.Block, ectx *hcl.EvalContext) (*PostProcessorBlock, hcl.Diagnostics) {
 	var b struct {
-		Name              string   `hcl:"name,optional"`
-		Only              []string `hcl:"only,optional"`
-		Except            []string `hcl:"except,optional"`
-		KeepInputArtifact *bool    `hcl:"keep_input_artifact,optional"`
-		Rest              hcl.Body `hcl:",remain"`
+		Name              string         `hcl:"name,optional"`
+		Only              []string       `hcl:"only,optional"`
+		Except            []string       `hcl:"except,optional"`
+		KeepInputArtifact *bool          `hcl:"keep_input_artifact,optional"`
+		When              hcl.Expression `hcl:"when,optional"`
+		Rest              hcl.Body       `hcl:",remain"`
 	}
 
 	diags := gohcl.DecodeBody(block.Body, ectx, &b)
@@ -46,6 +50,7 @@ func (p *Parser) decodePostProcessor(block *hcl.Block, ectx *hcl.EvalContext) (*
 		OnlyExcept:        OnlyExcept{Only: b.Only, Except: b.Except},
 		HCL2Ref:           newHCL2Ref(block, b.Rest),
 		KeepInputArtifact: b.KeepInputArtifact,
+		When:              b.When,
 	}
 
 	diags = diags.Extend(postProcessor.OnlyExcept.Validate())
@@ -5,6 +5,7 @@ package hcl2template
 
 import (
 	"fmt"
+	"regexp"
 	"strconv"
 	"time"
 
@@ -12,6 +13,7 @@ import (
 	"github.com/hashicorp/hcl/v2/gohcl"
 	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
 	hcl2shim "github.com/hashicorp/packer/hcl2template/shim"
+	"github.com/hashicorp/packer/packer"
 	"github.com/zclconf/go-cty/cty"
 )
 
@@ -61,15 +63,24 @@ func (o *OnlyExcept) Validate() hcl.Diagnostics {
 	return diags
 }
 
-// ProvisionerBlock references a detected but unparsed provisioner
+// ProvisionerBlock references a detected but unparsed provisioner, or a
+// provisioners block grouping Provisioners.
 type ProvisionerBlock struct {
-	PType       string
-	PName       string
-	PauseBefore time.Duration
-	MaxRetries  int
-	Timeout     time.Duration
-	Override    map[string]interface{}
-	OnlyExcept  OnlyExcept
+	PType string
+	PName string
+	// Provisioners are the provisioners of a provisioners block, they are
+	// run concurrently when Parallel is set, and in order otherwise.
+	Provisioners []*ProvisionerBlock
+	Parallel     bool
+	PauseBefore  time.Duration
+	MaxRetries   int
+	RetryPolicy  packer.RetryPolicy
+	Timeout      time.Duration
+	Override     map[string]interface{}
+	OnlyExcept   OnlyExcept
+	// When is the condition under which the provisioner runs, it is a
+	// null expression when unset.
+	When hcl.Expression
 	HCL2Ref
 }
 
@@ -79,14 +90,16 @@ func (p *ProvisionerBlock) String() string {
 
 func (p *Parser) decodeProvisioner(block *hcl.Block, ectx *hcl.EvalContext) (*ProvisionerBlock, hcl.Diagnostics) {
 	var b struct {
-		Name        string    `hcl:"name,optional"`
-		PauseBefore string    `hcl:"pause_before,optional"`
-		MaxRetries  int       `hcl:"max_retries,optional"`
-		Timeout     string    `hcl:"timeout,optional"`
-		Only        []string  `hcl:"only,optional"`
-		Except      []string  `hcl:"except,optional"`
-		Override    cty.Value `hcl:"override,optional"`
-		Rest        hcl.Body  `hcl:",remain"`
+		Name        string         `hcl:"name,optional"`
+		PauseBefore string         `hcl:"pause_before,optional"`
+		MaxRetries  int            `hcl:"max_retries,optional"`
+		Retry       *retryBlock    `hcl:"retry,block"`
+		Timeout     string         `hcl:"timeout,optional"`
+		Only        []string       `hcl:"only,optional"`
+		Except      []string       `hcl:"except,optional"`
+		When        hcl.Expression `hcl:"when,optional"`
+		Override    cty.Value      `hcl:"override,optional"`
+		Rest        hcl.Body       `hcl:",remain"`
 	}
 	diags := gohcl.DecodeBody(block.Body, ectx, &b)
 	if diags.HasErrors() {
@@ -98,6 +111,7 @@ func (p *Parser) decodeProvisioner(block *hcl.Block, ectx *hcl.EvalContext) (*Pr
 		PName:      b.Name,
 		MaxRetries: b.MaxRetries,
 		OnlyExcept: OnlyExcept{Only: b.Only, Except: b.Except},
+		When:       b.When,
 		HCL2Ref:    newHCL2Ref(block, b.Rest),
 	}
 
@@ -150,6 +164,22 @@ func (p *Parser) decodeProvisioner(block *hcl.Block, ectx *hcl.EvalContext) (*Pr
 		provisioner.PauseBefore = pauseBefore
 	}
 
+	if b.Retry != nil {
+		if b.MaxRetries != 0 {
+			return nil, append(diags, &hcl.Diagnostic{
+				Summary:  "Conflicting max_retries",
+				Severity: hcl.DiagError,
+				Detail:   "max_retries can either be set in the provisioner or in its retry block, not both.",
+				Subject:  &block.DefRange,
+			})
+		}
+		provisioner.MaxRetries = b.Retry.MaxRetries
+		provisioner.RetryPolicy, diags = b.Retry.policy(block, diags)
+		if diags.HasErrors() {
+			return nil, diags
+		}
+	}
+
 	if b.Timeout != "" {
 		timeout, err := time.ParseDuration(b.Timeout)
 		if err != nil {
@@ -166,6 +196,153 @@ func (p *Parser) decodeProvisioner(block *hcl.Block, ectx *hcl.EvalContext) (*Pr
 	return provisioner, diags
 }
 
+// decodeProvisioners decodes a provisioners block, grouping provisioners
+// that can run in parallel.
+func (p *Parser) decodeProvisioners(block *hcl.Block, ectx *hcl.EvalContext) (*ProvisionerBlock, hcl.Diagnostics) {
+	var b struct {
+		Name     string   `hcl:"name,optional"`
+		Parallel bool     `hcl:"parallel,optional"`
+		Only     []string `hcl:"only,optional"`
+		Except   []string `hcl:"except,optional"`
+		Rest     hcl.Body `hcl:",remain"`
+	}
+	diags := gohcl.DecodeBody(block.Body, ectx, &b)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	group := &ProvisionerBlock{
+		PType:      buildProvisionersLabel,
+		PName:      b.Name,
+		Parallel:   b.Parallel,
+		OnlyExcept: OnlyExcept{Only: b.Only, Except: b.Except},
+		HCL2Ref:    newHCL2Ref(block, b.Rest),
+	}
+
+	diags = diags.Extend(group.OnlyExcept.Validate())
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	content, moreDiags := b.Rest.Content(provisionersSchema)
+	diags = append(diags, moreDiags...)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	for _, block := range content.Blocks {
+		pb, moreDiags := p.decodeProvisioner(block, ectx)
+		diags = append(diags, moreDiags...)
+		if moreDiags.HasErrors() {
+			continue
+		}
+		group.Provisioners = append(group.Provisioners, pb)
+	}
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	if len(group.Provisioners) == 0 {
+		return nil, append(diags, &hcl.Diagnostic{
+			Summary:  "Empty " + buildProvisionersLabel + " block",
+			Severity: hcl.DiagError,
+			Detail:   "A " + buildProvisionersLabel + " block must contain at least one " + buildProvisionerLabel + " block.",
+			Subject:  block.DefRange.Ptr(),
+		})
+	}
+
+	return group, diags
+}
+
+// retryBlock is the retry block of a provisioner, setting how it is retried
+// when it fails.
+type retryBlock struct {
+	MaxRetries       int      `hcl:"max_retries"`
+	InitialBackoff   string   `hcl:"initial_backoff,optional"`
+	MaxBackoff       string   `hcl:"max_backoff,optional"`
+	Multiplier       float64  `hcl:"multiplier,optional"`
+	Jitter           float64  `hcl:"jitter,optional"`
+	MaxDuration      string   `hcl:"max_duration,optional"`
+	RetryOn          []string `hcl:"retry_on,optional"`
+	RetryOnExitCodes []int    `hcl:"retry_on_exit_codes,optional"`
+}
+
+// policy validates the retry block of the provisioner block and returns its
+// retry policy.
+func (r *retryBlock) policy(block *hcl.Block, diags hcl.Diagnostics) (packer.RetryPolicy, hcl.Diagnostics) {
+	policy := packer.RetryPolicy{
+		Multiplier:       r.Multiplier,
+		Jitter:           r.Jitter,
+		RetryOnExitCodes: r.RetryOnExitCodes,
+	}
+
+	if r.MaxRetries < 1 {
+		diags = append(diags, &hcl.Diagnostic{
+			Summary:  "Invalid max_retries",
+			Severity: hcl.DiagError,
+			Detail:   "The max_retries of a retry block must be at least 1.",
+			Subject:  &block.DefRange,
+		})
+	}
+
+	for _, d := range []struct {
+		name  string
+		value string
+		to    *time.Duration
+	}{
+		{"initial_backoff", r.InitialBackoff, &policy.InitialBackoff},
+		{"max_backoff", r.MaxBackoff, &policy.MaxBackoff},
+		{"max_duration", r.MaxDuration, &policy.MaxDuration},
+	} {
+		if d.value == "" {
+			continue
+		}
+		duration, err := time.ParseDuration(d.value)
+		if err != nil {
+			diags = append(diags, &hcl.Diagnostic{
+				Summary:  fmt.Sprintf("Failed to parse %s duration", d.name),
+				Severity: hcl.DiagError,
+				Detail:   err.Error(),
+				Subject:  &block.DefRange,
+			})
+			continue
+		}
+		*d.to = duration
+	}
+
+	if r.Multiplier != 0 && r.Multiplier < 1 {
+		diags = append(diags, &hcl.Diagnostic{
+			Summary:  "Invalid retry multiplier",
+			Severity: hcl.DiagError,
+			Detail:   "The multiplier of a retry block must be at least 1.",
+			Subject:  &block.DefRange,
+		})
+	}
+	if r.Jitter < 0 || r.Jitter > 1 {
+		diags = append(diags, &hcl.Diagnostic{
+			Summary:  "Invalid retry jitter",
+			Severity: hcl.DiagError,
+			Detail:   "The jitter of a retry block must be between 0 and 1.",
+			Subject:  &block.DefRange,
+		})
+	}
+
+	for _, expr := range r.RetryOn {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			diags = append(diags, &hcl.Diagnostic{
+				Summary:  "Invalid retry_on regular expression",
+				Severity: hcl.DiagError,
+				Detail:   err.Error(),
+				Subject:  &block.DefRange,
+			})
+			continue
+		}
+		policy.RetryOn = append(policy.RetryOn, re)
+	}
+
+	return policy, diags
+}
+
 func (cfg *PackerConfig) startProvisioner(source SourceUseBlock, pb *ProvisionerBlock, ectx *hcl.EvalContext) (packersdk.Provisioner, hcl.Diagnostics) {
 	var diags hcl.Diagnostics
 
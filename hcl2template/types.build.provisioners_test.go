@@ -5,7 +5,9 @@ package hcl2template
 
 import (
 	"testing"
+	"time"
 
+	"github.com/google/go-cmp/cmp"
 	"github.com/hashicorp/hcl/v2"
 )
 
@@ -34,6 +36,30 @@ func TestPackerConfig_ParseProvisionerBlock(t *testing.T) {
 			true,
 			"provisioner's override.'test' block must be an HCL object",
 		},
+		{
+			"success - provisioner retry block is valid",
+			"fixtures/well_formed_provisioner_retry.pkr.hcl",
+			false,
+			"",
+		},
+		{
+			"failure - max_retries set in the provisioner and its retry block",
+			"fixtures/conflicting_max_retries.pkr.hcl",
+			true,
+			"Conflicting max_retries",
+		},
+		{
+			"failure - retry_on is not a valid regular expression",
+			"fixtures/malformed_retry_on.pkr.hcl",
+			true,
+			"Invalid retry_on regular expression",
+		},
+		{
+			"failure - retry jitter is out of range",
+			"fixtures/malformed_retry_jitter.pkr.hcl",
+			true,
+			"Invalid retry jitter",
+		},
 	}
 
 	for _, test := range tests {
@@ -83,3 +109,36 @@ func TestPackerConfig_ParseProvisionerBlock(t *testing.T) {
 		})
 	}
 }
+
+func TestPackerConfig_ParseProvisionerBlock_retry(t *testing.T) {
+	cfg := PackerConfig{parser: getBasicParser()}
+	f, diags := cfg.parser.ParseHCLFile("fixtures/well_formed_provisioner_retry.pkr.hcl")
+	if diags.HasErrors() {
+		t.Fatal(diags)
+	}
+	pb, diags := cfg.parser.decodeProvisioner(f.OutermostBlockAtPos(hcl.Pos{Line: 1, Column: 1}), nil)
+	if diags.HasErrors() {
+		t.Fatal(diags)
+	}
+
+	if pb.MaxRetries != 5 {
+		t.Errorf("expected 5 max retries, got %d", pb.MaxRetries)
+	}
+	policy := pb.RetryPolicy
+	if policy.InitialBackoff != 2*time.Second || policy.MaxBackoff != 30*time.Second || policy.MaxDuration != 5*time.Minute {
+		t.Errorf("unexpected retry durations: %#v", policy)
+	}
+	if policy.Multiplier != 3 || policy.Jitter != 0.2 {
+		t.Errorf("unexpected multiplier or jitter: %#v", policy)
+	}
+	var retryOn []string
+	for _, re := range policy.RetryOn {
+		retryOn = append(retryOn, re.String())
+	}
+	if diff := cmp.Diff([]string{"Could not resolve host", "50[0-9] "}, retryOn); diff != "" {
+		t.Errorf("unexpected retry_on: %s", diff)
+	}
+	if diff := cmp.Diff([]int{100}, policy.RetryOnExitCodes); diff != "" {
+		t.Errorf("unexpected retry_on_exit_codes: %s", diff)
+	}
+}
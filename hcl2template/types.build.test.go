@@ -0,0 +1,132 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package hcl2template
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/packer/packer"
+)
+
+// TestBlock references the test block of a build, which assertions are
+// checked against the machine once it is provisioned, for example:
+//
+//	test {
+//		junit_report = "reports/${source.name}.xml"
+//		assert "nginx-running" {
+//			command        = "systemctl is-active nginx"
+//			stdout_matches = "^active"
+//		}
+//		assert "nginx-config" {
+//			file_exists = "/etc/nginx/nginx.conf"
+//		}
+//	}
+//
+// The test block is decoded for each source of the build, so it can use
+// source variables.
+type TestBlock struct {
+	HCL2Ref
+}
+
+type testBlockConfig struct {
+	Name        string              `hcl:"name,optional"`
+	JUnitReport string              `hcl:"junit_report,optional"`
+	Assertions  []assertBlockConfig `hcl:"assert,block"`
+}
+
+type assertBlockConfig struct {
+	Name          string `hcl:"name,label"`
+	Command       string `hcl:"command,optional"`
+	ExitCode      int    `hcl:"exit_code,optional"`
+	StdoutMatches string `hcl:"stdout_matches,optional"`
+	FileExists    string `hcl:"file_exists,optional"`
+}
+
+// getBuildTest decodes the test block of a build for one of its sources.
+func (cfg *PackerConfig) getBuildTest(tb *TestBlock, ectx *hcl.EvalContext) (*packer.BuildTest, hcl.Diagnostics) {
+	var b testBlockConfig
+	diags := gohcl.DecodeBody(tb.HCL2Ref.Rest, ectx, &b)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	subject := tb.HCL2Ref.DefRange.Ptr()
+	if len(b.Assertions) == 0 {
+		return nil, append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Empty " + buildTestLabel + " block",
+			Detail:   "A " + buildTestLabel + " block must contain at least one assert block.",
+			Subject:  subject,
+		})
+	}
+
+	test := &packer.BuildTest{
+		Name:        b.Name,
+		JUnitReport: b.JUnitReport,
+	}
+	names := map[string]bool{}
+	for _, a := range b.Assertions {
+		if names[a.Name] {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  fmt.Sprintf("Duplicate assert %q", a.Name),
+				Detail:   "The assert blocks of a " + buildTestLabel + " block must have unique names.",
+				Subject:  subject,
+			})
+			continue
+		}
+		names[a.Name] = true
+
+		assertion, moreDiags := a.assertion(subject)
+		diags = append(diags, moreDiags...)
+		if moreDiags.HasErrors() {
+			continue
+		}
+		test.Assertions = append(test.Assertions, assertion)
+	}
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	return test, diags
+}
+
+// assertion validates the assert block and returns its assertion.
+func (a *assertBlockConfig) assertion(subject *hcl.Range) (packer.Assertion, hcl.Diagnostics) {
+	var diags hcl.Diagnostics
+	invalid := func(detail string) (packer.Assertion, hcl.Diagnostics) {
+		return packer.Assertion{}, append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  fmt.Sprintf("Invalid assert %q", a.Name),
+			Detail:   detail,
+			Subject:  subject,
+		})
+	}
+
+	switch {
+	case a.Command != "" && a.FileExists != "":
+		return invalid("An assert block can either set command or file_exists, not both.")
+	case a.Command == "" && a.FileExists == "":
+		return invalid("An assert block must set either command or file_exists.")
+	case a.FileExists != "" && (a.ExitCode != 0 || a.StdoutMatches != ""):
+		return invalid("exit_code and stdout_matches can only be set with command.")
+	}
+
+	assertion := packer.Assertion{
+		Name:       a.Name,
+		Command:    a.Command,
+		ExitCode:   a.ExitCode,
+		FileExists: a.FileExists,
+	}
+	if a.StdoutMatches != "" {
+		re, err := regexp.Compile(a.StdoutMatches)
+		if err != nil {
+			return invalid(fmt.Sprintf("Invalid stdout_matches regular expression: %s", err))
+		}
+		assertion.Stdout = re
+	}
+	return assertion, diags
+}
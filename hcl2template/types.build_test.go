@@ -5,8 +5,11 @@ package hcl2template
 
 import (
 	"path/filepath"
+	"regexp"
 	"testing"
+	"time"
 
+	"github.com/google/go-cmp/cmp"
 	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
 	. "github.com/hashicorp/packer/hcl2template/internal"
 	"github.com/hashicorp/packer/packer"
@@ -688,3 +691,180 @@ func TestParse_build(t *testing.T) {
 	}
 	testParse(t, tests)
 }
+
+func TestParse_buildDependsOn(t *testing.T) {
+	testParseErrors(t, []parseErrorTest{
+		{"testdata/build/depends_on.pkr.hcl", ""},
+		{"testdata/build/depends_on_unknown.pkr.hcl", `Unknown build "nope" in depends_on`},
+		{"testdata/build/depends_on_cycle.pkr.hcl", "build.a -> build.c -> build.b -> build.a"},
+	})
+}
+
+func TestGetBuilds_dependsOn(t *testing.T) {
+	builds := getBuildsWithoutErrors(t, "testdata/build/depends_on.pkr.hcl", packer.GetBuildsOptions{DeferDependentBuilds: true})
+
+	derived := builds[1].(*packer.CoreBuild)
+	if diff := cmp.Diff([]string{"base"}, derived.DependsOn); diff != "" {
+		t.Fatalf("wrong dependencies: %s", diff)
+	}
+	if derived.Builder != nil || len(derived.Provisioners) != 0 {
+		t.Fatal("expected the dependent build not to be configured before its dependencies are resolved")
+	}
+	err := derived.ResolveDependencies(map[string][]packersdk.Artifact{
+		"base": {&packersdk.MockArtifact{IdValue: "base-id"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	prov := derived.Provisioners[0].Provisioner.(*HCL2Provisioner).Provisioner.(*MockProvisioner)
+	if prov.Config.String != "base-id" {
+		t.Fatalf("expected the artifact id of the base build, got %q", prov.Config.String)
+	}
+}
+
+func TestParse_buildProvisionersGroup(t *testing.T) {
+	testParseErrors(t, []parseErrorTest{
+		{"testdata/build/provisioners_parallel.pkr.hcl", ""},
+		{"testdata/build/provisioners_nonexistent.pkr.hcl", `Unknown provisioner type "nope"`},
+		{"testdata/build/provisioners_empty.pkr.hcl", "Empty provisioners block"},
+	})
+}
+
+func TestGetBuilds_provisionersGroup(t *testing.T) {
+	builds := getBuildsWithoutErrors(t, "testdata/build/provisioners_parallel.pkr.hcl", packer.GetBuildsOptions{})
+
+	type provisioner struct {
+		Type, Name string
+		Parallel   []provisioner
+	}
+	var got []provisioner
+	for _, p := range builds[0].(*packer.CoreBuild).Provisioners {
+		prov := provisioner{Type: p.PType, Name: p.PName}
+		if group, ok := p.Provisioner.(*packer.ParallelProvisioner); ok {
+			for _, child := range group.Provisioners {
+				prov.Parallel = append(prov.Parallel, provisioner{Type: child.PType, Name: child.PName})
+			}
+		}
+		got = append(got, prov)
+	}
+
+	want := []provisioner{
+		{Type: "shell"},
+		{Type: "provisioners", Name: "downloads", Parallel: []provisioner{
+			{Type: "shell", Name: "a"},
+			{Type: "file", Name: "b"},
+		}},
+		{Type: "file", Name: "after"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("unexpected provisioners: %s", diff)
+	}
+}
+
+func TestGetBuilds_when(t *testing.T) {
+	builds := getBuildsWithoutErrors(t, "testdata/build/provisioner_when.pkr.hcl", packer.GetBuildsOptions{})
+	build := builds[0].(*packer.CoreBuild)
+
+	type evaluation struct {
+		Value, Known, Err bool
+	}
+	evaluate := func(c packer.Condition, data map[string]interface{}) evaluation {
+		value, known, err := c.Evaluate(data)
+		return evaluation{Value: value, Known: known, Err: err != nil}
+	}
+
+	tests := []struct {
+		expression string
+		data       map[string]interface{}
+		want       evaluation
+	}{
+		{"var.install_gpu_drivers", nil, evaluation{Value: false, Known: true}},
+		{`build.Host == "example.com"`, nil, evaluation{Known: false}},
+		{`build.Host == "example.com"`, map[string]interface{}{"Host": "example.com"}, evaluation{Value: true, Known: true}},
+		{`build.Host == "example.com"`, map[string]interface{}{"Host": "127.0.0.1"}, evaluation{Value: false, Known: true}},
+	}
+	for _, tt := range tests {
+		var condition *packer.Condition
+		for _, p := range build.Provisioners {
+			if conditional, ok := p.Provisioner.(*packer.ConditionalProvisioner); ok && conditional.Condition.Expression == tt.expression {
+				condition = &conditional.Condition
+			}
+		}
+		if condition == nil {
+			t.Fatalf("no provisioner with the %s condition", tt.expression)
+		}
+		if got := evaluate(*condition, tt.data); got != tt.want {
+			t.Errorf("%s with %v: expected %+v, got %+v", tt.expression, tt.data, tt.want, got)
+		}
+	}
+
+	if _, ok := build.Provisioners[2].Provisioner.(*packer.ConditionalProvisioner); ok {
+		t.Error("expected a provisioner without a when attribute to always run")
+	}
+
+	pp := build.PostProcessors[0][0]
+	if pp.Condition == nil || pp.Condition.Expression != "!var.install_gpu_drivers" {
+		t.Fatalf("unexpected post-processor condition: %#v", pp.Condition)
+	}
+	if got := evaluate(*pp.Condition, nil); got != (evaluation{Value: true, Known: true}) {
+		t.Errorf("unexpected post-processor condition evaluation: %+v", got)
+	}
+}
+
+func TestGetBuilds_whenInvalid(t *testing.T) {
+	testGetBuildsError(t, "testdata/build/provisioner_when_invalid.pkr.hcl", "Invalid when condition")
+	testGetBuildsError(t, "testdata/build/post-processor_when_invalid.pkr.hcl", "Invalid when condition")
+}
+
+func TestGetBuilds_test(t *testing.T) {
+	builds := getBuildsWithoutErrors(t, "testdata/build/test.pkr.hcl", packer.GetBuildsOptions{})
+
+	want := &packer.BuildTest{
+		JUnitReport: "reports/test.xml",
+		Assertions: []packer.Assertion{
+			{Name: "nginx-running", Command: "systemctl is-active nginx", Stdout: regexp.MustCompile("^active")},
+			{Name: "no-apache", Command: "which apache2", ExitCode: 1},
+			{Name: "nginx-config", FileExists: "/etc/nginx/nginx.conf"},
+		},
+	}
+	regexpComparer := cmp.Comparer(func(a, b *regexp.Regexp) bool {
+		if a == nil || b == nil {
+			return a == b
+		}
+		return a.String() == b.String()
+	})
+	if diff := cmp.Diff(want, builds[0].(*packer.CoreBuild).Test, regexpComparer); diff != "" {
+		t.Fatalf("unexpected test: %s", diff)
+	}
+}
+
+func TestGetBuilds_testInvalid(t *testing.T) {
+	testGetBuildsError(t, "testdata/build/test_invalid_assert.pkr.hcl", `Invalid assert "both"`)
+	testGetBuildsError(t, "testdata/build/test_empty.pkr.hcl", "Empty test block")
+
+	_, diags := parseAndInitialize("testdata/build/two-test.pkr.hcl")
+	if len(diags) != 1 || diags[0].Summary != "Only one test block is allowed" {
+		t.Errorf("expected a single test block to be allowed, got %s", diags)
+	}
+}
+
+func TestGetBuilds_timeout(t *testing.T) {
+	builds := getBuildsWithoutErrors(t, "testdata/build/source_timeout.pkr.hcl", packer.GetBuildsOptions{})
+
+	want := map[string]time.Duration{
+		"null.test":    time.Hour,
+		"null.other":   30 * time.Minute,
+		"null.untimed": 0,
+	}
+	if len(builds) != len(want) {
+		t.Fatalf("expected %d builds, got %d", len(want), len(builds))
+	}
+	for _, build := range builds {
+		if got := build.(*packer.CoreBuild).Timeout; got != want[build.Name()] {
+			t.Errorf("%s: expected a %s timeout, got %s", build.Name(), want[build.Name()], got)
+		}
+	}
+
+	testGetBuildsError(t, "testdata/build/source_timeout_invalid.pkr.hcl", "Failed to parse timeout duration")
+}
@@ -5,6 +5,7 @@ package hcl2template
 
 import (
 	"path/filepath"
+	"strings"
 	"testing"
 
 	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
@@ -235,3 +236,25 @@ func TestParse_datasource(t *testing.T) {
 
 	testParse(t, tests)
 }
+
+func TestInitialize_datasourceAndLocalDependencies(t *testing.T) {
+	cfg, diags := parseAndInitialize("testdata/datasources/locals.pkr.hcl")
+	if diags.HasErrors() {
+		t.Fatal(diags)
+	}
+
+	got := cfg.Datasources[DatasourceRef{Type: "null", Name: "versioned"}].value.GetAttr("output").AsString()
+	if want := "https://example.com/api/v1"; got != want {
+		t.Fatalf("unexpected datasource output %q, expected %q", got, want)
+	}
+}
+
+func TestInitialize_datasourceAndLocalCycle(t *testing.T) {
+	_, diags := parseAndInitialize("testdata/datasources/locals_cycle.pkr.hcl")
+	if !diags.HasErrors() {
+		t.Fatal("expected a dependency cycle error")
+	}
+	if want := "local.url -> data.null.api -> local.url"; !strings.Contains(diags.Error(), want) {
+		t.Fatalf("expected the cycle path %q in: %s", want, diags)
+	}
+}
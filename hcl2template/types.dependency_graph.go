@@ -0,0 +1,189 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package hcl2template
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+)
+
+// dependencyNode is a local or a data source in the dependency graph
+// evaluated when the config is initialized.
+type dependencyNode struct {
+	// name is how the node is referenced, like `local.foo` or
+	// `data.http.bar`.
+	name string
+
+	local      *LocalBlock
+	datasource *DatasourceRef
+
+	dependsOn []string
+	defRange  hcl.Range
+}
+
+// dependencyGraph returns the locals and data sources of the config, indexed
+// by name, along with the names in the order they should be visited.
+func (cfg *PackerConfig) dependencyGraph(locals []*LocalBlock) (map[string]*dependencyNode, []string) {
+	nodes := map[string]*dependencyNode{}
+	var order []string
+
+	for _, local := range locals {
+		node := &dependencyNode{
+			name:     localsAccessor + "." + local.Name,
+			local:    local,
+			defRange: local.Expr.Range(),
+		}
+		node.dependsOn = dependencyNames(local.Expr.Variables())
+		if _, exists := nodes[node.name]; exists {
+			// duplicates are reported by checkForDuplicateLocalDefinition
+			continue
+		}
+		nodes[node.name] = node
+		order = append(order, node.name)
+	}
+
+	var dsNames []string
+	for ref, ds := range cfg.Datasources {
+		ref := ref
+		node := &dependencyNode{
+			name:       strings.Join([]string{dataAccessor, ref.Type, ref.Name}, "."),
+			datasource: &ref,
+			defRange:   ds.block.DefRange,
+		}
+		// Note: when looking at the expressions, we only need to care about
+		// attributes, as HCL2 expressions are not allowed in a block's labels.
+		node.dependsOn = dependencyNames(GetVarsByType(ds.block, dataAccessor, localsAccessor))
+		nodes[node.name] = node
+		dsNames = append(dsNames, node.name)
+	}
+	sort.Strings(dsNames)
+	order = append(order, dsNames...)
+
+	return nodes, order
+}
+
+// dependencyNames returns the names of the locals and data sources used in
+// traversals.
+func dependencyNames(traversals []hcl.Traversal) []string {
+	var names []string
+	for _, traversal := range traversals {
+		var parts []string
+		switch traversal.RootName() {
+		case localsAccessor:
+			parts = traversalAttrs(traversal, 2)
+		case dataAccessor:
+			parts = traversalAttrs(traversal, 3)
+		}
+		if parts != nil {
+			names = append(names, strings.Join(parts, "."))
+		}
+	}
+	return names
+}
+
+// traversalAttrs returns the n first steps of traversal, or nil if these are
+// not all attribute names.
+func traversalAttrs(traversal hcl.Traversal, n int) []string {
+	if len(traversal) < n {
+		return nil
+	}
+	parts := []string{traversal.RootName()}
+	for _, step := range traversal[1:n] {
+		attr, ok := step.(hcl.TraverseAttr)
+		if !ok {
+			return nil
+		}
+		parts = append(parts, attr.Name)
+	}
+	return parts
+}
+
+// evaluateLocalsAndDatasources evaluates locals and data sources in
+// dependency order, so that a local can use a data source and a data source
+// can use a local. A dependency cycle is reported with its path. Nodes that
+// depend on a node that failed to evaluate are not evaluated, only the root
+// error is reported.
+func (cfg *PackerConfig) evaluateLocalsAndDatasources(skipExecution bool) hcl.Diagnostics {
+	var diags hcl.Diagnostics
+
+	if len(cfg.LocalBlocks) > 0 && cfg.LocalVariables == nil {
+		cfg.LocalVariables = Variables{}
+	}
+
+	nodes, order := cfg.dependencyGraph(cfg.LocalBlocks)
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := map[string]int{}
+	failed := map[string]bool{}
+	var path []string
+
+	var visit func(name string) bool
+	visit = func(name string) bool {
+		node, found := nodes[name]
+		if !found {
+			// Unknown references are reported when evaluating the node
+			// using them.
+			return true
+		}
+
+		switch state[name] {
+		case visited:
+			return !failed[name]
+		case visiting:
+			cycle := []string{}
+			for i, n := range path {
+				if n == name {
+					cycle = append(cycle, path[i:]...)
+					break
+				}
+			}
+			cycle = append(cycle, name)
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Dependency cycle between locals and data sources",
+				Detail:   "The following locals and data sources depend on each other: " + strings.Join(cycle, " -> "),
+				Subject:  node.defRange.Ptr(),
+			})
+			return false
+		}
+
+		state[name] = visiting
+		path = append(path, name)
+		ok := true
+		for _, dep := range node.dependsOn {
+			if !visit(dep) {
+				ok = false
+				break
+			}
+		}
+		path = path[:len(path)-1]
+		state[name] = visited
+
+		if ok {
+			var moreDiags hcl.Diagnostics
+			switch {
+			case node.local != nil:
+				moreDiags = cfg.evaluateLocalVariable(node.local)
+			case node.datasource != nil:
+				moreDiags = cfg.evaluateDatasource(*node.datasource, skipExecution)
+			}
+			diags = append(diags, moreDiags...)
+			ok = !moreDiags.HasErrors()
+		}
+		failed[name] = !ok
+		return ok
+	}
+
+	for _, name := range order {
+		visit(name)
+	}
+
+	return diags
+}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/gobwas/glob"
 	hcl "github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/ext/typeexpr"
 	"github.com/hashicorp/hcl/v2/hcldec"
 	"github.com/hashicorp/hcl/v2/hclsyntax"
 	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
@@ -39,6 +40,10 @@ type PackerConfig struct {
 	// Available Source blocks
 	Sources map[SourceRef]SourceBlock
 
+	// sourceInstances are the instances of the source blocks repeated with
+	// for_each or count, in order.
+	sourceInstances map[SourceRef][]SourceRef
+
 	// InputVariables and LocalVariables are the list of defined input and
 	// local variables. They are of the same type but are not used in the same
 	// way. Local variables will not be decoded from any config file, env var,
@@ -132,7 +137,7 @@ func (cfg *PackerConfig) EvalContext(ctx BlockContext, variables map[string]cty.
 	// dependency tree, so that any block can use any block whatever the
 	// order.
 	// For now, don't add DataSources if there's a NilContext, which gets
-	// used with packer console.
+	// used with packer console; the console adds the executed ones.
 	switch ctx {
 	case LocalContext, BuildContext, DatasourceContext:
 		datasourceVariables, _ := cfg.Datasources.Values()
@@ -212,49 +217,6 @@ func parseLocalVariableBlocks(f *hcl.File) ([]*LocalBlock, hcl.Diagnostics) {
 	return locals, diags
 }
 
-func (c *PackerConfig) evaluateAllLocalVariables(locals []*LocalBlock) hcl.Diagnostics {
-	var diags hcl.Diagnostics
-
-	for _, local := range locals {
-		diags = append(diags, c.evaluateLocalVariable(local)...)
-	}
-
-	return diags
-}
-
-func (c *PackerConfig) evaluateLocalVariables(locals []*LocalBlock) hcl.Diagnostics {
-	var diags hcl.Diagnostics
-
-	if len(locals) == 0 {
-		return diags
-	}
-
-	if c.LocalVariables == nil {
-		c.LocalVariables = Variables{}
-	}
-
-	for foundSomething := true; foundSomething; {
-		foundSomething = false
-		for i := 0; i < len(locals); {
-			local := locals[i]
-			moreDiags := c.evaluateLocalVariable(local)
-			if moreDiags.HasErrors() {
-				i++
-				continue
-			}
-			foundSomething = true
-			locals = append(locals[:i], locals[i+1:]...)
-		}
-	}
-
-	if len(locals) != 0 {
-		// get errors from remaining variables
-		return c.evaluateAllLocalVariables(locals)
-	}
-
-	return diags
-}
-
 func checkForDuplicateLocalDefinition(locals []*LocalBlock) hcl.Diagnostics {
 	var diags hcl.Diagnostics
 
@@ -298,100 +260,29 @@ func (c *PackerConfig) evaluateLocalVariable(local *LocalBlock) hcl.Diagnostics
 	return diags
 }
 
-func (cfg *PackerConfig) evaluateDatasources(skipExecution bool) hcl.Diagnostics {
+// evaluateDatasource starts and executes the ref data source, with the cfg's
+// full data source context. When skipExecution is set, the data source is
+// only started and its value is left unknown.
+func (cfg *PackerConfig) evaluateDatasource(ref DatasourceRef, skipExecution bool) hcl.Diagnostics {
 	var diags hcl.Diagnostics
 
-	dependencies := map[DatasourceRef][]DatasourceRef{}
-	for ref, ds := range cfg.Datasources {
-		if ds.value != (cty.Value{}) {
-			continue
-		}
-		// Pre-examine body of this data source to see if it uses another data
-		// source in any of its input expressions. If so, skip evaluating it for
-		// now, and add it to a list of datasources to evaluate again, later,
-		// with the datasources in its context.
-		dependencies[ref] = []DatasourceRef{}
-
-		// Note: when looking at the expressions, we only need to care about
-		// attributes, as HCL2 expressions are not allowed in a block's labels.
-		vars := GetVarsByType(ds.block, "data")
-		for _, v := range vars {
-			// construct, backwards, the data source type and name we
-			// need to evaluate before this one can be evaluated.
-			dependsOn := DatasourceRef{
-				Type: v[1].(hcl.TraverseAttr).Name,
-				Name: v[2].(hcl.TraverseAttr).Name,
-			}
-			dependencies[ref] = append(dependencies[ref], dependsOn)
-		}
-	}
-
-	// Now that most of our data sources have been started and executed, we can
-	// try to execute the ones that depend on other data sources.
-	for ref := range dependencies {
-		_, moreDiags := cfg.recursivelyEvaluateDatasources(ref, dependencies, skipExecution, 0)
-		// Deduplicate diagnostics to prevent recursion messes.
-		cleanedDiags := map[string]*hcl.Diagnostic{}
-		for _, diag := range moreDiags {
-			cleanedDiags[diag.Summary] = diag
-		}
-
-		for _, diag := range cleanedDiags {
-			diags = append(diags, diag)
-		}
-	}
-
-	return diags
-}
-
-func (cfg *PackerConfig) recursivelyEvaluateDatasources(ref DatasourceRef, dependencies map[DatasourceRef][]DatasourceRef, skipExecution bool, depth int) (map[DatasourceRef][]DatasourceRef, hcl.Diagnostics) {
-	var diags hcl.Diagnostics
-	var moreDiags hcl.Diagnostics
-
-	if depth > 10 {
-		// Add a comment about recursion.
-		diags = append(diags, &hcl.Diagnostic{
-			Severity: hcl.DiagError,
-			Summary:  "Max datasource recursion depth exceeded.",
-			Detail: "An error occured while recursively evaluating data " +
-				"sources. Either your data source depends on more than ten " +
-				"other data sources, or your data sources have a cyclic " +
-				"dependency. Please simplify your config to continue. ",
-			Subject: &(cfg.Datasources[ref]).block.DefRange,
-		})
-		return dependencies, diags
-	}
-
 	ds := cfg.Datasources[ref]
-	// Make sure everything ref depends on has already been evaluated.
-	for _, dep := range dependencies[ref] {
-		if _, ok := dependencies[dep]; ok {
-			depth += 1
-			// If this dependency is not in the map, it means we've already
-			// launched and executed this datasource. Otherwise, it means
-			// we still need to run it. RECURSION TIME!!
-			dependencies, moreDiags = cfg.recursivelyEvaluateDatasources(dep, dependencies, skipExecution, depth)
-			diags = append(diags, moreDiags...)
-			if moreDiags.HasErrors() {
-				diags = append(diags, moreDiags...)
-				return dependencies, diags
-			}
-		}
+	if ds.value != (cty.Value{}) {
+		// already evaluated
+		return nil
 	}
-	// If we've gotten here, then it means ref doesn't seem to have any further
-	// dependencies we need to evaluate first. Evaluate it, with the cfg's full
-	// data source context.
+
 	datasource, startDiags := cfg.startDatasource(ds)
 	if startDiags.HasErrors() {
 		diags = append(diags, startDiags...)
-		return dependencies, diags
+		return diags
 	}
 
 	if skipExecution {
 		placeholderValue := cty.UnknownVal(hcldec.ImpliedType(datasource.OutputSpec()))
 		ds.value = placeholderValue
 		cfg.Datasources[ref] = ds
-		return dependencies, diags
+		return diags
 	}
 
 	opts, _ := decodeHCL2Spec(ds.block.Body, cfg.EvalContext(DatasourceContext, nil), datasource)
@@ -404,14 +295,12 @@ func (cfg *PackerConfig) recursivelyEvaluateDatasources(ref DatasourceRef, depen
 			Subject:  &cfg.Datasources[ref].block.DefRange,
 			Severity: hcl.DiagError,
 		})
-		return dependencies, diags
+		return diags
 	}
 
 	ds.value = realValue
 	cfg.Datasources[ref] = ds
-	// remove ref from the dependencies map.
-	delete(dependencies, ref)
-	return dependencies, diags
+	return diags
 }
 
 // getCoreBuildProvisioners takes a list of provisioner block, starts according
@@ -424,6 +313,24 @@ func (cfg *PackerConfig) getCoreBuildProvisioners(source SourceUseBlock, blocks
 			continue
 		}
 
+		if pb.PType == buildProvisionersLabel {
+			group, moreDiags := cfg.getCoreBuildProvisioners(source, pb.Provisioners, ectx)
+			diags = append(diags, moreDiags...)
+			if moreDiags.HasErrors() || len(group) == 0 {
+				continue
+			}
+			if !pb.Parallel || len(group) == 1 {
+				res = append(res, group...)
+				continue
+			}
+			res = append(res, packer.CoreBuildProvisioner{
+				PType:       packer.ParallelProvisionerType,
+				PName:       pb.PName,
+				Provisioner: &packer.ParallelProvisioner{Provisioners: group},
+			})
+			continue
+		}
+
 		coreBuildProv, moreDiags := cfg.getCoreBuildProvisioner(source, pb, ectx)
 		diags = append(diags, moreDiags...)
 		if moreDiags.HasErrors() {
@@ -459,6 +366,23 @@ func (cfg *PackerConfig) getCoreBuildProvisioner(source SourceUseBlock, pb *Prov
 	if pb.MaxRetries != 0 {
 		provisioner = &packer.RetriedProvisioner{
 			MaxRetries:  pb.MaxRetries,
+			RetryPolicy: pb.RetryPolicy,
+			Provisioner: provisioner,
+		}
+	}
+	condition, moreDiags := cfg.newCondition(pb.When, ectx)
+	diags = append(diags, moreDiags...)
+	if moreDiags.HasErrors() {
+		return packer.CoreBuildProvisioner{}, diags
+	}
+	if condition != nil {
+		name := pb.PName
+		if name == "" {
+			name = pb.PType
+		}
+		provisioner = &packer.ConditionalProvisioner{
+			Condition:   *condition,
+			Name:        name,
 			Provisioner: provisioner,
 		}
 	}
@@ -471,6 +395,40 @@ func (cfg *PackerConfig) getCoreBuildProvisioner(source SourceUseBlock, pb *Prov
 	}, diags
 }
 
+// exceptPostProcessor tells whether the -except option excludes the
+// post-processor ppb.
+func (cfg *PackerConfig) exceptPostProcessor(ppb *PostProcessorBlock) bool {
+	name := ppb.PName
+	if name == "" {
+		name = ppb.PType
+	}
+	for _, exceptGlob := range cfg.except {
+		if exceptGlob.Match(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// countExceptedPostProcessors counts the post-processors of build the -except
+// option excludes for the srcUsage source, like getCoreBuildPostProcessors
+// does when it starts them.
+func (cfg *PackerConfig) countExceptedPostProcessors(build *BuildBlock, srcUsage SourceUseBlock) int {
+	count := 0
+	for _, blocks := range build.PostProcessorsLists {
+		for _, ppb := range blocks {
+			if ppb.OnlyExcept.Skip(srcUsage.String()) {
+				continue
+			}
+			if cfg.exceptPostProcessor(ppb) {
+				count++
+				break
+			}
+		}
+	}
+	return count
+}
+
 // getCoreBuildProvisioners takes a list of post processor block, starts
 // according provisioners and sends parsed HCL2 over to it.
 func (cfg *PackerConfig) getCoreBuildPostProcessors(source SourceUseBlock, blocksList [][]*PostProcessorBlock, ectx *hcl.EvalContext, exceptMatches *int) ([][]packer.CoreBuildPostProcessor, hcl.Diagnostics) {
@@ -483,20 +441,9 @@ func (cfg *PackerConfig) getCoreBuildPostProcessors(source SourceUseBlock, block
 				continue
 			}
 
-			name := ppb.PName
-			if name == "" {
-				name = ppb.PType
-			}
 			// -except
-			exclude := false
-			for _, exceptGlob := range cfg.except {
-				if exceptGlob.Match(name) {
-					exclude = true
-					*exceptMatches = *exceptMatches + 1
-					break
-				}
-			}
-			if exclude {
+			if cfg.exceptPostProcessor(ppb) {
+				*exceptMatches = *exceptMatches + 1
 				break
 			}
 
@@ -508,12 +455,19 @@ func (cfg *PackerConfig) getCoreBuildPostProcessors(source SourceUseBlock, block
 
 			flatPostProcessorCfg, moreDiags := decodeHCL2Spec(ppb.HCL2Ref.Rest, ectx, postProcessor)
 
+			condition, moreDiags := cfg.newCondition(ppb.When, ectx)
+			diags = append(diags, moreDiags...)
+			if moreDiags.HasErrors() {
+				continue
+			}
+
 			pps = append(pps, packer.CoreBuildPostProcessor{
 				PostProcessor:     postProcessor,
 				PName:             ppb.PName,
 				PType:             ppb.PType,
 				HCLConfig:         flatPostProcessorCfg,
 				KeepInputArtifact: ppb.KeepInputArtifact,
+				Condition:         condition,
 			})
 		}
 		if len(pps) > 0 {
@@ -609,55 +563,36 @@ func (cfg *PackerConfig) GetBuilds(opts packer.GetBuildsOptions) ([]packersdk.Bu
 				}
 			}
 
-			builder, moreDiags, generatedVars := cfg.startBuilder(srcUsage, cfg.EvalContext(BuildContext, nil))
-			diags = append(diags, moreDiags...)
-			if moreDiags.HasErrors() {
-				continue
-			}
-
-			decoded, _ := decodeHCL2Spec(srcUsage.Body, cfg.EvalContext(BuildContext, nil), builder)
-			pcb.HCLConfig = decoded
-
-			// If the builder has provided a list of to-be-generated variables that
-			// should be made accessible to provisioners, pass that list into
-			// the provisioner prepare() so that the provisioner can appropriately
-			// validate user input against what will become available. Otherwise,
-			// only pass the default variables, using the basic placeholder data.
-			unknownBuildValues := map[string]cty.Value{}
-			for _, k := range append(packer.BuilderDataCommonKeys, generatedVars...) {
-				unknownBuildValues[k] = cty.StringVal("<unknown>")
-			}
-			unknownBuildValues["name"] = cty.StringVal(build.Name)
-
-			variables := map[string]cty.Value{
-				sourcesAccessor: cty.ObjectVal(srcUsage.ctyValues()),
-				buildAccessor:   cty.ObjectVal(unknownBuildValues),
-			}
-
-			provisioners, moreDiags := cfg.getCoreBuildProvisioners(srcUsage, build.ProvisionerBlocks, cfg.EvalContext(BuildContext, variables))
-			diags = append(diags, moreDiags...)
-			if moreDiags.HasErrors() {
-				continue
-			}
-			pps, moreDiags := cfg.getCoreBuildPostProcessors(srcUsage, build.PostProcessorsLists, cfg.EvalContext(BuildContext, variables), &opts.ExceptMatches)
-			diags = append(diags, moreDiags...)
-			if moreDiags.HasErrors() {
-				continue
-			}
-
-			if build.ErrorCleanupProvisionerBlock != nil &&
-				!build.ErrorCleanupProvisionerBlock.OnlyExcept.Skip(srcUsage.String()) {
-				errorCleanupProv, moreDiags := cfg.getCoreBuildProvisioner(srcUsage, build.ErrorCleanupProvisionerBlock, cfg.EvalContext(BuildContext, variables))
+			pcb.DependsOn = build.DependsOn
+			if len(build.DependsOn) > 0 && opts.DeferDependentBuilds {
+				// The plugins of the build are started once the builds it
+				// depends on ran, with their artifacts.
+				opts.ExceptMatches += cfg.countExceptedPostProcessors(build, srcUsage)
+				build, srcUsage := build, srcUsage
+				pcb.ResolveDependencies = func(artifacts map[string][]packersdk.Artifact) error {
+					dependencies := map[string]cty.Value{}
+					for _, dep := range build.DependsOn {
+						dependencies[dep] = buildDependencyValue(artifacts[dep])
+					}
+					var exceptMatches int
+					diags := cfg.configureCoreBuild(build, srcUsage, pcb, dependencies, &exceptMatches)
+					if diags.HasErrors() {
+						return diags
+					}
+					return nil
+				}
+			} else {
+				dependencies := map[string]cty.Value{}
+				for _, dep := range build.DependsOn {
+					dependencies[dep] = cty.UnknownVal(buildDependencyType)
+				}
+				moreDiags := cfg.configureCoreBuild(build, srcUsage, pcb, dependencies, &opts.ExceptMatches)
 				diags = append(diags, moreDiags...)
 				if moreDiags.HasErrors() {
 					continue
 				}
-				pcb.CleanupProvisioner = errorCleanupProv
 			}
 
-			pcb.Builder = builder
-			pcb.Provisioners = provisioners
-			pcb.PostProcessors = pps
 			pcb.Prepared = true
 
 			// Prepare just sets the "prepareCalled" flag on CoreBuild, since
@@ -695,19 +630,126 @@ func (cfg *PackerConfig) GetBuilds(opts packer.GetBuildsOptions) ([]packersdk.Bu
 	return res, diags
 }
 
+// configureCoreBuild starts the builder, provisioners and post-processors of
+// the srcUsage source of build and sets them on pcb. dependencies are the
+// values of the builds build depends on, they are unknown until these builds
+// ran.
+func (cfg *PackerConfig) configureCoreBuild(build *BuildBlock, srcUsage SourceUseBlock, pcb *packer.CoreBuild, dependencies map[string]cty.Value, exceptMatches *int) hcl.Diagnostics {
+	var diags hcl.Diagnostics
+
+	var builderVariables map[string]cty.Value
+	if len(dependencies) > 0 {
+		builderVariables = map[string]cty.Value{
+			buildAccessor: cty.ObjectVal(dependencies),
+		}
+	}
+
+	timeoutAttr, body, moreDiags := srcUsage.splitTimeout()
+	diags = append(diags, moreDiags...)
+	if moreDiags.HasErrors() {
+		return diags
+	}
+	srcUsage.Body = body
+
+	builderCtx := cfg.EvalContext(BuildContext, builderVariables)
+	builder, moreDiags, generatedVars := cfg.startBuilder(srcUsage, builderCtx)
+	diags = append(diags, moreDiags...)
+	if moreDiags.HasErrors() {
+		return diags
+	}
+
+	// The source values are only set in the context once the builder is
+	// started.
+	timeout, moreDiags := decodeTimeout(timeoutAttr, builderCtx)
+	diags = append(diags, moreDiags...)
+	if moreDiags.HasErrors() {
+		return diags
+	}
+	pcb.Timeout = timeout
+
+	decoded, _ := decodeHCL2Spec(srcUsage.Body, builderCtx, builder)
+	pcb.HCLConfig = decoded
+
+	// If the builder has provided a list of to-be-generated variables that
+	// should be made accessible to provisioners, pass that list into
+	// the provisioner prepare() so that the provisioner can appropriately
+	// validate user input against what will become available. Otherwise,
+	// only pass the default variables, using the basic placeholder data.
+	unknownBuildValues := map[string]cty.Value{}
+	for _, k := range append(packer.BuilderDataCommonKeys, generatedVars...) {
+		unknownBuildValues[k] = cty.StringVal("<unknown>")
+	}
+	unknownBuildValues["name"] = cty.StringVal(build.Name)
+	for dep, val := range dependencies {
+		unknownBuildValues[dep] = val
+	}
+
+	variables := map[string]cty.Value{
+		sourcesAccessor: cty.ObjectVal(srcUsage.ctyValues()),
+		buildAccessor:   cty.ObjectVal(unknownBuildValues),
+	}
+
+	provisioners, moreDiags := cfg.getCoreBuildProvisioners(srcUsage, build.ProvisionerBlocks, cfg.EvalContext(BuildContext, variables))
+	diags = append(diags, moreDiags...)
+	if moreDiags.HasErrors() {
+		return diags
+	}
+	pps, moreDiags := cfg.getCoreBuildPostProcessors(srcUsage, build.PostProcessorsLists, cfg.EvalContext(BuildContext, variables), exceptMatches)
+	diags = append(diags, moreDiags...)
+	if moreDiags.HasErrors() {
+		return diags
+	}
+
+	if build.ErrorCleanupProvisionerBlock != nil &&
+		!build.ErrorCleanupProvisionerBlock.OnlyExcept.Skip(srcUsage.String()) {
+		errorCleanupProv, moreDiags := cfg.getCoreBuildProvisioner(srcUsage, build.ErrorCleanupProvisionerBlock, cfg.EvalContext(BuildContext, variables))
+		diags = append(diags, moreDiags...)
+		if moreDiags.HasErrors() {
+			return diags
+		}
+		pcb.CleanupProvisioner = errorCleanupProv
+	}
+
+	if build.TestBlock != nil {
+		test, moreDiags := cfg.getBuildTest(build.TestBlock, cfg.EvalContext(BuildContext, variables))
+		diags = append(diags, moreDiags...)
+		if moreDiags.HasErrors() {
+			return diags
+		}
+		pcb.Test = test
+	}
+
+	pcb.Builder = builder
+	pcb.Provisioners = provisioners
+	pcb.PostProcessors = pps
+	return diags
+}
+
 var PackerConsoleHelp = strings.TrimSpace(`
 Packer console HCL2 Mode.
 The Packer console allows you to experiment with Packer interpolations.
 You may access variables and functions in the Packer config you called the
 console with.
 
-Type in the interpolation to test and hit <enter> to see the result.
+Type in the interpolation to test and hit <enter> to see the result. An
+expression with unclosed braces, brackets, parentheses or heredocs continues
+on the next lines. Hit <tab> to complete variables, locals, data sources and
+functions.
 
 "upper(var.foo.id)" would evaluate to the ID of "foo" and uppercase is, if it
 exists in your config file.
 
 "variables" will dump all available variables and their values.
 
+":variables" is the same as "variables".
+
+":functions" will list all available functions and their signatures.
+
+":explain var.foo" will list where "foo" was set, in precedence order: its
+default, PKR_VAR_foo, the var files and the -var arguments.
+
+":reload" will load the config again, after it was edited.
+
 To exit the console, type "exit" and hit <enter>, or use Control-C.
 
 /!\ It is not possible to use go templating interpolation like "{{timestamp}}"
@@ -722,8 +764,13 @@ func (p *PackerConfig) EvaluateExpression(line string) (out string, exit bool, d
 		return "", true, nil
 	case line == "help":
 		return PackerConsoleHelp, false, nil
-	case line == "variables":
+	case line == "variables", line == ":variables":
 		return p.printVariables(), false, nil
+	case line == ":functions":
+		return p.printFunctions(), false, nil
+	case strings.HasPrefix(line, ":explain"):
+		out, diags := p.ExplainVariable(strings.TrimPrefix(line, ":explain"))
+		return strings.TrimSuffix(out, "\n"), false, diags
 	default:
 		return p.handleEval(line)
 	}
@@ -750,6 +797,75 @@ func (p *PackerConfig) printVariables() string {
 	return out.String()
 }
 
+// printFunctions lists the functions available in the config, with their
+// signatures.
+func (p *PackerConfig) printFunctions() string {
+	functions := Functions(p.Basedir)
+	names := make([]string, 0, len(functions))
+	for name := range functions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	out := &strings.Builder{}
+	for _, name := range names {
+		fmt.Fprintf(out, "%s\n", functionSignature(name, functions[name]))
+	}
+	return strings.TrimSuffix(out.String(), "\n")
+}
+
+// functionSignature returns the signature of the function name, like
+// `upper(str string) string`. The type of the result is left out when it
+// depends on the arguments.
+func functionSignature(name string, f function.Function) string {
+	var params []string
+	var types []cty.Type
+	for _, param := range f.Params() {
+		params = append(params, param.Name+" "+functionTypeString(param.Type))
+		types = append(types, param.Type)
+	}
+	if param := f.VarParam(); param != nil {
+		params = append(params, param.Name+" ..."+functionTypeString(param.Type))
+		types = append(types, param.Type)
+	}
+
+	signature := fmt.Sprintf("%s(%s)", name, strings.Join(params, ", "))
+	if returnType, err := f.ReturnType(types); err == nil && returnType != cty.DynamicPseudoType {
+		signature += " " + functionTypeString(returnType)
+	}
+	return signature
+}
+
+// functionTypeString returns the type constraint syntax of t, or the name of
+// t when it is a capsule type, like the expressions taken by try.
+func functionTypeString(t cty.Type) string {
+	if t.IsCapsuleType() {
+		return t.FriendlyName()
+	}
+	return typeexpr.TypeString(t)
+}
+
+// ConsoleCompletions returns the words completed in the console: the
+// references to the variables, locals and data sources of the config, the
+// attributes of source, the functions and the console commands.
+func (p *PackerConfig) ConsoleCompletions() []string {
+	completions := []string{"help", "exit", "variables", ":variables", ":functions", ":explain ", ":reload",
+		sourcesAccessor + ".name", sourcesAccessor + ".type"}
+	for name := range p.InputVariables {
+		completions = append(completions, inputVariablesAccessor+"."+name)
+	}
+	for name := range p.LocalVariables {
+		completions = append(completions, localsAccessor+"."+name)
+	}
+	for ref := range p.Datasources {
+		completions = append(completions, fmt.Sprintf("%s.%s.%s", dataAccessor, ref.Type, ref.Name))
+	}
+	for name := range Functions(p.Basedir) {
+		completions = append(completions, name+"(")
+	}
+	return completions
+}
+
 func (p *PackerConfig) printBuilds() string {
 	out := &strings.Builder{}
 	out.WriteString("> builds:\n")
@@ -778,7 +894,19 @@ func (p *PackerConfig) printBuilds() string {
 			if prov.PName != "" {
 				str = strings.Join([]string{prov.PType, prov.PName}, ".")
 			}
+			if prov.Parallel {
+				str += " (parallel)"
+			}
+			str += p.printCondition(prov.When)
 			fmt.Fprintf(out, "      %s\n", str)
+			for _, child := range prov.Provisioners {
+				str := child.PType
+				if child.PName != "" {
+					str = strings.Join([]string{child.PType, child.PName}, ".")
+				}
+				str += p.printCondition(child.When)
+				fmt.Fprintf(out, "        %s\n", str)
+			}
 		}
 		fmt.Fprintf(out, "\n    post-processors:\n")
 		if len(build.PostProcessorsLists) == 0 {
@@ -791,6 +919,7 @@ func (p *PackerConfig) printBuilds() string {
 				if pp.PName != "" {
 					str = strings.Join([]string{pp.PType, pp.PName}, ".")
 				}
+				str += p.printCondition(pp.When)
 				fmt.Fprintf(out, "        %s\n", str)
 			}
 		}
@@ -798,6 +927,15 @@ func (p *PackerConfig) printBuilds() string {
 	return out.String()
 }
 
+// printCondition returns how the when condition of a provisioner or
+// post-processor block is shown by inspect, if it has one.
+func (p *PackerConfig) printCondition(when hcl.Expression) string {
+	if isNullExpression(when) {
+		return ""
+	}
+	return fmt.Sprintf(" (when %s)", p.expressionSource(when))
+}
+
 func (p *PackerConfig) handleEval(line string) (out string, exit bool, diags hcl.Diagnostics) {
 
 	// Parse the given line as an expression
@@ -807,7 +945,11 @@ func (p *PackerConfig) handleEval(line string) (out string, exit bool, diags hcl
 		return "", false, diags
 	}
 
-	val, valueDiags := expr.Value(p.EvalContext(NilContext, nil))
+	// the data sources were executed when the console was initialized.
+	datasourceVariables, _ := p.Datasources.Values()
+	val, valueDiags := expr.Value(p.EvalContext(NilContext, map[string]cty.Value{
+		dataAccessor: cty.ObjectVal(datasourceVariables),
+	}))
 	diags = append(diags, valueDiags...)
 	if valueDiags.HasErrors() {
 		return "", false, diags
@@ -816,14 +958,18 @@ func (p *PackerConfig) handleEval(line string) (out string, exit bool, diags hcl
 	return PrintableCtyValue(val), false, diags
 }
 
-func (p *PackerConfig) FixConfig(_ packer.FixConfigOptions) (diags hcl.Diagnostics) {
-	// No Fixers exist for HCL2 configs so there is nothing to do here for now.
-	return
-}
-
 func (p *PackerConfig) InspectConfig(opts packer.InspectConfigOptions) int {
 
 	ui := opts.Ui
+	if opts.Format == "json" {
+		out, err := p.marshalInspectJSON(opts.ShowDataSources)
+		if err != nil {
+			ui.Error(fmt.Sprintf("Failed to encode the configuration: %s", err))
+			return 1
+		}
+		ui.Say(strings.TrimSuffix(string(out), "\n"))
+		return 0
+	}
 	ui.Say("Packer Inspect: HCL2 mode\n")
 	ui.Say(p.printVariables())
 	ui.Say(p.printBuilds())
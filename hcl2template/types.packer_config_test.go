@@ -5,6 +5,7 @@ package hcl2template
 
 import (
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -665,3 +666,42 @@ func mustVersionConstraints(vs version.Constraints, err error) version.Constrain
 	}
 	return vs
 }
+
+func TestPackerConfig_console(t *testing.T) {
+	cfg, diags := parseAndInitialize("testdata/datasources/locals.pkr.hcl")
+	if diags.HasErrors() {
+		t.Fatal(diags)
+	}
+
+	out, _, diags := cfg.EvaluateExpression("data.null.versioned.output")
+	if diags.HasErrors() {
+		t.Fatal(diags)
+	}
+	if want := "https://example.com/api/v1"; out != want {
+		t.Errorf("unexpected data source output %q, expected %q", out, want)
+	}
+
+	out, _, _ = cfg.EvaluateExpression(":functions")
+	for _, signature := range []string{
+		"upper(str string) string",
+		"cidrsubnets(prefix string, newbits ...number) list(string)",
+		"try(expressions ...expression closure)",
+	} {
+		if !strings.Contains(out, signature+"\n") {
+			t.Errorf("signature %q not found in: %s", signature, out)
+		}
+	}
+
+	completions := cfg.ConsoleCompletions()
+	for _, completion := range []string{
+		"var.host", "local.url", "local.result", "data.null.api", "data.null.versioned", "source.name", "upper(", ":functions", ":reload",
+	} {
+		found := false
+		for _, c := range completions {
+			found = found || c == completion
+		}
+		if !found {
+			t.Errorf("completion %q not found in %v", completion, completions)
+		}
+	}
+}
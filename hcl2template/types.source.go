@@ -7,9 +7,12 @@ import (
 	"fmt"
 	"sort"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
 	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
 	hcl2shim "github.com/hashicorp/packer/hcl2template/shim"
 	"github.com/zclconf/go-cty/cty"
@@ -28,6 +31,10 @@ type SourceBlock struct {
 	// LocalName can be set in a singular source block from a build block, it
 	// allows to give a special name to a build in the logs.
 	LocalName string
+
+	// variables are the `each` or `count` values of a source expanded with
+	// for_each or count.
+	variables map[string]cty.Value
 }
 
 // SourceUseBlock is a SourceBlock 'usage' from a config stand point.
@@ -45,6 +52,10 @@ type SourceUseBlock struct {
 	// content
 	// Body can be expanded by a dynamic tag.
 	Body hcl.Body
+
+	// variables are the `each` or `count` values of the source, when it was
+	// expanded with for_each or count.
+	variables map[string]cty.Value
 }
 
 func (b *SourceUseBlock) name() string {
@@ -73,31 +84,117 @@ func (b *SourceUseBlock) ctyValues() map[string]cty.Value {
 //	    name = "local_name"
 //	  }
 //	}
-func (p *Parser) decodeBuildSource(block *hcl.Block) (SourceUseBlock, hcl.Diagnostics) {
+//
+// A used source block with a for_each or count argument is expanded into one
+// SourceUseBlock per instance.
+func (p *Parser) decodeBuildSource(block *hcl.Block, ectx *hcl.EvalContext) ([]SourceUseBlock, hcl.Diagnostics) {
 	ref := sourceRefFromString(block.Labels[0])
-	out := SourceUseBlock{SourceRef: ref}
 	var b struct {
-		Name string   `hcl:"name,optional"`
-		Rest hcl.Body `hcl:",remain"`
+		Name    hcl.Expression `hcl:"name,optional"`
+		ForEach hcl.Expression `hcl:"for_each,optional"`
+		Count   hcl.Expression `hcl:"count,optional"`
+		Rest    hcl.Body       `hcl:",remain"`
 	}
 	diags := gohcl.DecodeBody(block.Body, nil, &b)
 	if diags.HasErrors() {
-		return out, diags
+		return nil, diags
+	}
+
+	instances, moreDiags := decodeInstances(b.ForEach, b.Count, ectx)
+	diags = append(diags, moreDiags...)
+	if moreDiags.HasErrors() {
+		return nil, diags
 	}
-	out.LocalName = b.Name
-	out.Body = b.Rest
-	return out, nil
+	if instances == nil {
+		// not repeated, the name can only be a literal
+		instances = []blockInstance{{}}
+	}
+
+	var out []SourceUseBlock
+	for _, instance := range instances {
+		var nameCtx *hcl.EvalContext
+		if instance.variables != nil {
+			nameCtx = ectx.NewChild()
+			nameCtx.Variables = instance.variables
+		}
+		name := ""
+		if !isNullExpression(b.Name) {
+			moreDiags := gohcl.DecodeExpression(b.Name, nameCtx, &name)
+			diags = append(diags, moreDiags...)
+			if moreDiags.HasErrors() {
+				return nil, diags
+			}
+		}
+		if name == "" && instance.variables != nil {
+			name = ref.Name + "-" + instance.key
+		}
+		out = append(out, SourceUseBlock{
+			SourceRef: ref,
+			LocalName: name,
+			Body:      b.Rest,
+			variables: instance.variables,
+		})
+	}
+	return out, diags
 }
 
-func (p *Parser) decodeSource(block *hcl.Block) (SourceBlock, hcl.Diagnostics) {
+// decodeSource reads a source block. A source block with a for_each or count
+// argument is expanded into one SourceBlock per instance, named
+// `<name>-<key>`.
+func (p *Parser) decodeSource(block *hcl.Block, ectx *hcl.EvalContext) ([]SourceBlock, hcl.Diagnostics) {
 	source := SourceBlock{
 		Type:  block.Labels[0],
 		Name:  block.Labels[1],
 		block: block,
 	}
-	var diags hcl.Diagnostics
 
-	return source, diags
+	content, remain, diags := block.Body.PartialContent(repetitionSchema)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	if len(content.Attributes) == 0 {
+		return []SourceBlock{source}, diags
+	}
+
+	var forEach, count hcl.Expression
+	if attr, ok := content.Attributes["for_each"]; ok {
+		forEach = attr.Expr
+	}
+	if attr, ok := content.Attributes["count"]; ok {
+		count = attr.Expr
+	}
+	instances, moreDiags := decodeInstances(forEach, count, ectx)
+	diags = append(diags, moreDiags...)
+	if moreDiags.HasErrors() {
+		return nil, diags
+	}
+
+	// The body of the instances should not contain the meta-arguments.
+	instanceBlock := *block
+	instanceBlock.Body = remain
+
+	var sources []SourceBlock
+	for _, instance := range instances {
+		name := source.Name + "-" + instance.key
+		if !hclsyntax.ValidIdentifier(name) {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid " + sourceLabel + " instance name",
+				Detail: fmt.Sprintf("The key %q cannot be used to name a %s: "+
+					"%q is not a valid identifier.", instance.key, sourceLabel, name),
+				Subject: block.DefRange.Ptr(),
+			})
+			continue
+		}
+		sources = append(sources, SourceBlock{
+			Type:      source.Type,
+			Name:      name,
+			block:     &instanceBlock,
+			variables: instance.variables,
+		})
+	}
+
+	return sources, diags
 }
 
 func (cfg *PackerConfig) startBuilder(source SourceUseBlock, ectx *hcl.EvalContext) (packersdk.Builder, hcl.Diagnostics, []string) {
@@ -116,6 +213,9 @@ func (cfg *PackerConfig) startBuilder(source SourceUseBlock, ectx *hcl.EvalConte
 	body := source.Body
 	// Add known values to source accessor in eval context.
 	ectx.Variables[sourcesAccessor] = cty.ObjectVal(source.ctyValues())
+	for k, v := range source.variables {
+		ectx.Variables[k] = v
+	}
 
 	decoded, moreDiags := decodeHCL2Spec(body, ectx, builder)
 	diags = append(diags, moreDiags...)
@@ -146,6 +246,51 @@ func (cfg *PackerConfig) startBuilder(source SourceUseBlock, ectx *hcl.EvalConte
 	return builder, diags, generatedVars
 }
 
+// sourceTimeoutSchema holds the timeout argument of a source, which sets the
+// timeout of its builds instead of configuring its builder.
+var sourceTimeoutSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{Name: "timeout"},
+	},
+}
+
+// splitTimeout returns the timeout argument of the source, if set, and the
+// rest of its body, which configures its builder. The timeout can be set on
+// the source block or on the source block of a build.
+func (source *SourceUseBlock) splitTimeout() (*hcl.Attribute, hcl.Body, hcl.Diagnostics) {
+	content, remain, diags := source.Body.PartialContent(sourceTimeoutSchema)
+	if diags.HasErrors() {
+		return nil, source.Body, diags
+	}
+	return content.Attributes["timeout"], remain, diags
+}
+
+// decodeTimeout decodes the timeout argument of a source, a duration like
+// "1h30m".
+func decodeTimeout(attr *hcl.Attribute, ectx *hcl.EvalContext) (time.Duration, hcl.Diagnostics) {
+	if attr == nil {
+		return 0, nil
+	}
+	var raw string
+	diags := gohcl.DecodeExpression(attr.Expr, ectx, &raw)
+	if diags.HasErrors() {
+		return 0, diags
+	}
+	timeout, err := time.ParseDuration(raw)
+	if err == nil && timeout < 0 {
+		err = fmt.Errorf("a timeout cannot be negative")
+	}
+	if err != nil {
+		return 0, append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Failed to parse timeout duration",
+			Detail:   err.Error(),
+			Subject:  attr.Expr.Range().Ptr(),
+		})
+	}
+	return timeout, diags
+}
+
 // These variables will populate the PackerConfig inside of the builders.
 func (source *SourceUseBlock) builderVariables() map[string]string {
 	return map[string]string{
@@ -188,3 +333,41 @@ func listAvailableSourceNames(srcs map[SourceRef]SourceBlock) []string {
 	sort.Strings(res)
 	return res
 }
+
+// expandSourceUses replaces the uses of a source expanded with for_each or
+// count by a use of each of its instances.
+func (cfg *PackerConfig) expandSourceUses(build *BuildBlock) hcl.Diagnostics {
+	var diags hcl.Diagnostics
+	var sources []SourceUseBlock
+
+	for _, srcUsage := range build.Sources {
+		instances, found := cfg.sourceInstances[srcUsage.SourceRef]
+		if !found {
+			sources = append(sources, srcUsage)
+			continue
+		}
+		if srcUsage.variables != nil {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Repeated use of a repeated " + sourceLabel,
+				Detail: fmt.Sprintf("The %s %s is already repeated with for_each or count, "+
+					"it cannot be repeated again in a build.", sourceLabel, srcUsage.SourceRef),
+				Subject: build.HCL2Ref.DefRange.Ptr(),
+			})
+			continue
+		}
+		for _, ref := range instances {
+			instance := srcUsage
+			instance.SourceRef = ref
+			instance.variables = cfg.Sources[ref].variables
+			if instance.LocalName != "" {
+				// keep the instance suffix so that build names are distinct
+				instance.LocalName += strings.TrimPrefix(ref.Name, srcUsage.Name)
+			}
+			sources = append(sources, instance)
+		}
+	}
+
+	build.Sources = sources
+	return diags
+}
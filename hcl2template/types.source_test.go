@@ -4,11 +4,14 @@
 package hcl2template
 
 import (
+	"fmt"
 	"path/filepath"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
 	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
 	"github.com/hashicorp/packer/builder/null"
+	. "github.com/hashicorp/packer/hcl2template/internal"
 	"github.com/hashicorp/packer/packer"
 )
 
@@ -146,3 +149,23 @@ func TestParse_source(t *testing.T) {
 	}
 	testParse(t, tests)
 }
+
+func TestGetBuilds_repeatedSources(t *testing.T) {
+	builds := getBuildsWithoutErrors(t, "testdata/sources/repeated.pkr.hcl", packer.GetBuildsOptions{
+		Except: []string{"amazon-ebs.ubuntu-1"},
+	})
+
+	got := map[string]string{}
+	for _, build := range builds {
+		config := build.(*packer.CoreBuild).Builder.(*MockBuilder).Config
+		got[build.Name()] = fmt.Sprintf("%s %s %d", config.String, config.NotSquashed, config.Int)
+	}
+	want := map[string]string{
+		"virtualbox-iso.ubuntu-eu-west-1": "eu-west-1 ami-west 0",
+		"virtualbox-iso.ubuntu-us-east-1": "us-east-1 ami-east 0",
+		"amazon-ebs.ubuntu-0":             "  10",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("wrong builds: %s", diff)
+	}
+}
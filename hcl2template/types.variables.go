@@ -71,6 +71,22 @@ type Variable struct {
 	Sensitive bool
 
 	Range hcl.Range
+
+	// conversionDiags are the errors converting the values of Values to the
+	// type of the variable, by index in Values. These values are unknown.
+	conversionDiags map[int]hcl.Diagnostics
+}
+
+// assign adds an assignment to the values of the variable, convDiags being
+// the errors converting its value to the type of the variable.
+func (v *Variable) assign(assignment VariableAssignment, convDiags hcl.Diagnostics) {
+	if len(convDiags) > 0 {
+		if v.conversionDiags == nil {
+			v.conversionDiags = map[int]hcl.Diagnostics{}
+		}
+		v.conversionDiags[len(v.Values)] = convDiags
+	}
+	v.Values = append(v.Values, assignment)
 }
 
 func (v *Variable) GoString() string {
@@ -368,25 +384,27 @@ func (variables *Variables) decodeVariableBlock(block *hcl.Block, ectx *hcl.Eval
 			return diags
 		}
 
+		var convDiags hcl.Diagnostics
 		if v.Type != cty.NilType {
 			var err error
 			defaultValue, err = convert.Convert(defaultValue, v.Type)
 			if err != nil {
-				diags = append(diags, &hcl.Diagnostic{
+				convDiags = append(convDiags, &hcl.Diagnostic{
 					Severity: hcl.DiagError,
 					Summary:  "Invalid default value for variable",
 					Detail:   fmt.Sprintf("This default value is not compatible with the variable's type constraint: %s.", err),
 					Subject:  def.Expr.Range().Ptr(),
 				})
+				diags = append(diags, convDiags...)
 				defaultValue = cty.DynamicVal
 			}
 		}
 
-		v.Values = append(v.Values, VariableAssignment{
+		v.assign(VariableAssignment{
 			From:  "default",
 			Value: defaultValue,
 			Expr:  def.Expr,
-		})
+		}, convDiags)
 
 		// It's possible no type attribute was assigned so lets make sure we
 		// have a valid type otherwise there could be issues parsing the value.
@@ -595,24 +613,26 @@ func (cfg *PackerConfig) collectInputVariableValues(env []string, files []*hcl.F
 
 		val, valDiags := expr.Value(nil)
 		diags = append(diags, valDiags...)
+		var convDiags hcl.Diagnostics
 		if variable.Type != cty.NilType {
 			var err error
 			val, err = convert.Convert(val, variable.Type)
 			if err != nil {
-				diags = append(diags, &hcl.Diagnostic{
+				convDiags = append(convDiags, &hcl.Diagnostic{
 					Severity: hcl.DiagError,
 					Summary:  "Invalid value for variable",
 					Detail:   fmt.Sprintf("The value for %s is not compatible with the variable's type constraint: %s.", name, err),
 					Subject:  expr.Range().Ptr(),
 				})
+				diags = append(diags, convDiags...)
 				val = cty.DynamicVal
 			}
 		}
-		variable.Values = append(variable.Values, VariableAssignment{
+		variable.assign(VariableAssignment{
 			From:  "env",
 			Value: val,
 			Expr:  expr,
-		})
+		}, convDiags)
 	}
 
 	// files will contain files found in the folder then files passed as
@@ -632,9 +652,10 @@ func (cfg *PackerConfig) collectInputVariableValues(env []string, files []*hcl.F
 					},
 				},
 			})
+			var declDiags hcl.Diagnostics
 			for _, block := range content.Blocks {
 				name := block.Labels[0]
-				diags = append(diags, &hcl.Diagnostic{
+				declDiags = append(declDiags, &hcl.Diagnostic{
 					Severity: hcl.DiagError,
 					Summary:  "Variable declaration in a .pkrvar file",
 					Detail: fmt.Sprintf("A .pkrvar file is used to assign "+
@@ -648,10 +669,12 @@ func (cfg *PackerConfig) collectInputVariableValues(env []string, files []*hcl.F
 					Subject: &block.TypeRange,
 				})
 			}
-			if diags.HasErrors() {
-				// If we already found problems then JustAttributes below will find
-				// the same problems with less-helpful messages, so we'll bail for
-				// now to let the user focus on the immediate problem.
+			diags = append(diags, declDiags...)
+			if declDiags.HasErrors() {
+				// If the file declares variables then JustAttributes below will
+				// find the same problems with less-helpful messages, so we'll bail
+				// for now to let the user focus on the immediate problem. Errors
+				// in other values don't stop the next files from being read.
 				return diags
 			}
 		}
@@ -685,25 +708,27 @@ func (cfg *PackerConfig) collectInputVariableValues(env []string, files []*hcl.F
 			val, moreDiags := attr.Expr.Value(nil)
 			diags = append(diags, moreDiags...)
 
+			var convDiags hcl.Diagnostics
 			if variable.Type != cty.NilType {
 				var err error
 				val, err = convert.Convert(val, variable.Type)
 				if err != nil {
-					diags = append(diags, &hcl.Diagnostic{
+					convDiags = append(convDiags, &hcl.Diagnostic{
 						Severity: hcl.DiagError,
 						Summary:  "Invalid value for variable",
 						Detail:   fmt.Sprintf("The value for %s is not compatible with the variable's type constraint: %s.", name, err),
 						Subject:  attr.Expr.Range().Ptr(),
 					})
+					diags = append(diags, convDiags...)
 					val = cty.DynamicVal
 				}
 			}
 
-			variable.Values = append(variable.Values, VariableAssignment{
+			variable.assign(VariableAssignment{
 				From:  "varfile",
 				Value: val,
 				Expr:  attr.Expr,
-			})
+			}, convDiags)
 		}
 	}
 
@@ -733,25 +758,27 @@ func (cfg *PackerConfig) collectInputVariableValues(env []string, files []*hcl.F
 		val, valDiags := expr.Value(nil)
 		diags = append(diags, valDiags...)
 
+		var convDiags hcl.Diagnostics
 		if variable.Type != cty.NilType {
 			var err error
 			val, err = convert.Convert(val, variable.Type)
 			if err != nil {
-				diags = append(diags, &hcl.Diagnostic{
+				convDiags = append(convDiags, &hcl.Diagnostic{
 					Severity: hcl.DiagError,
 					Summary:  "Invalid argument value for -var variable",
 					Detail:   fmt.Sprintf("The received arg value for %s is not compatible with the variable's type constraint: %s.", name, err),
 					Subject:  expr.Range().Ptr(),
 				})
+				diags = append(diags, convDiags...)
 				val = cty.DynamicVal
 			}
 		}
 
-		variable.Values = append(variable.Values, VariableAssignment{
+		variable.assign(VariableAssignment{
 			From:  "cmd",
 			Value: val,
 			Expr:  expr,
-		})
+		}, convDiags)
 	}
 
 	return diags
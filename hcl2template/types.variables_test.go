@@ -950,6 +950,41 @@ func TestVariables_collectVariableValues(t *testing.T) {
 			},
 		},
 
+		{name: "value not corresponding to type - later cfg files are still read",
+			variables: Variables{
+				"used_bool": &Variable{
+					Type: cty.Bool,
+				},
+				"used_string": &Variable{
+					Type: cty.String,
+				},
+			},
+			args: args{
+				hclFiles: []string{
+					`used_bool=["string"]`,
+					`used_string="varfile_value"`,
+				},
+			},
+
+			// output
+			wantDiags:         true,
+			wantDiagsHasError: true,
+			wantVariables: Variables{
+				"used_bool": &Variable{
+					Type:   cty.Bool,
+					Values: []VariableAssignment{{"varfile", cty.DynamicVal, nil}},
+				},
+				"used_string": &Variable{
+					Type:   cty.String,
+					Values: []VariableAssignment{{"varfile", cty.StringVal("varfile_value"), nil}},
+				},
+			},
+			wantValues: map[string]cty.Value{
+				"used_bool":   cty.DynamicVal,
+				"used_string": cty.StringVal("varfile_value"),
+			},
+		},
+
 		{name: "value not corresponding to type - argv",
 			variables: Variables{
 				"used_string": &Variable{
@@ -7,12 +7,14 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"log"
 	"math/rand"
 	"os"
 	"runtime"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
@@ -179,6 +181,20 @@ func wrappedMain() int {
 		)
 	}
 
+	if !inPlugin {
+		shutdownTracing, err := packer.SetupTracing(context.Background())
+		if err != nil {
+			log.Printf("[WARN] (tracing) Error setting up tracing, traces won't be exported: %s", err)
+		}
+		defer func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := shutdownTracing(ctx); err != nil {
+				log.Printf("[WARN] (tracing) Error exporting traces: %s", err)
+			}
+		}()
+	}
+
 	cacheDir, err := packersdk.CachePath()
 	if err != nil {
 		// Writing to Stdout here so that the error message bypasses panicwrap. By using the
@@ -193,11 +209,28 @@ func wrappedMain() int {
 	// Determine if we're in machine-readable mode by mucking around with
 	// the arguments...
 	args, machineReadable := extractMachineReadable(os.Args[1:])
+	args, outputFormat := extractOutputFormat(args)
 
 	defer packer.CleanupClients()
 
 	var ui packersdk.Ui
-	if machineReadable {
+	if machineReadable && outputFormat != "" {
+		fmt.Fprintf(os.Stdout, "%s -machine-readable and -output can't be used together\n", ErrorPrefix)
+		return 1
+	} else if outputFormat == "jsonl" {
+		// Setup the UI to output one JSON event per line
+		ui = &packer.JSONLinesUi{
+			Writer: os.Stdout,
+		}
+
+		if err := os.Setenv("PACKER_NO_COLOR", "1"); err != nil {
+			ui.Error(fmt.Sprintf("Packer failed to initialize UI: %s\n", err))
+			return 1
+		}
+	} else if outputFormat != "" {
+		fmt.Fprintf(os.Stdout, "%s Unknown output format %q, expected \"jsonl\"\n", ErrorPrefix, outputFormat)
+		return 1
+	} else if machineReadable {
 		// Setup the UI as we're being machine-readable
 		ui = &packer.MachineReadableUi{
 			Writer: os.Stdout,
@@ -248,7 +281,8 @@ func wrappedMain() int {
 			},
 			Version: version.Version,
 		},
-		Ui: ui,
+		Ui:                 ui,
+		PluginInstallation: config.PluginInstallation,
 	}
 
 	//versionCLIHelper shortcuts "--version" and "-v" to just show the version
@@ -328,6 +362,33 @@ func extractMachineReadable(args []string) ([]string, bool) {
 	return args, false
 }
 
+// extractOutputFormat checks the args of the build command for the
+// `-output=FORMAT` flag and returns the format, or an empty string when it is
+// not set. It modifies the args to remove this flag. The args of the other
+// commands are left untouched.
+func extractOutputFormat(args []string) ([]string, string) {
+	command := 0
+	for command < len(args) && strings.HasPrefix(args[command], "-") {
+		command++
+	}
+	if command == len(args) || args[command] != "build" {
+		return args, ""
+	}
+
+	for i := command + 1; i < len(args); i++ {
+		arg := args[i]
+		if strings.HasPrefix(arg, "-output=") {
+			// We found it. Slice it out.
+			result := make([]string, len(args)-1)
+			copy(result, args[:i])
+			copy(result[i:], args[i+1:])
+			return result, strings.TrimPrefix(arg, "-output=")
+		}
+	}
+
+	return args, ""
+}
+
 func loadConfig() (*config, error) {
 	var config config
 	config.Plugins = &packer.PluginConfig{
@@ -65,6 +65,33 @@ func TestExtractMachineReadable(t *testing.T) {
 	}
 }
 
+func TestExtractOutputFormat(t *testing.T) {
+	args, format := extractOutputFormat([]string{"build", "foo.pkr.hcl"})
+	if !reflect.DeepEqual(args, []string{"build", "foo.pkr.hcl"}) {
+		t.Fatalf("bad: %#v", args)
+	}
+	if format != "" {
+		t.Fatalf("format should not be set, got %q", format)
+	}
+
+	args, format = extractOutputFormat([]string{"build", "-output=jsonl", "foo.pkr.hcl"})
+	if !reflect.DeepEqual(args, []string{"build", "foo.pkr.hcl"}) {
+		t.Fatalf("bad: %#v", args)
+	}
+	if format != "jsonl" {
+		t.Fatalf("format should be jsonl, got %q", format)
+	}
+
+	// Only the flag of the build command is extracted
+	args, format = extractOutputFormat([]string{"console", "-output=jsonl"})
+	if !reflect.DeepEqual(args, []string{"console", "-output=jsonl"}) {
+		t.Fatalf("bad: %#v", args)
+	}
+	if format != "" {
+		t.Fatalf("format should not be set, got %q", format)
+	}
+}
+
 func TestRandom(t *testing.T) {
 	if rand.Intn(9999999) == 8498210 {
 		t.Fatal("math.rand is not seeded properly")
@@ -6,14 +6,18 @@ package packer
 import (
 	"context"
 	"fmt"
+	"io"
 	"log"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/hashicorp/packer-plugin-sdk/common"
 	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
 	"github.com/hashicorp/packer-plugin-sdk/packerbuilderdata"
 	"github.com/hashicorp/packer/version"
 	"github.com/zclconf/go-cty/cty"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // A CoreBuild struct represents a single build job, the result of which should
@@ -39,15 +43,34 @@ type CoreBuild struct {
 	Provisioners       []CoreBuildProvisioner
 	PostProcessors     [][]CoreBuildPostProcessor
 	CleanupProvisioner CoreBuildProvisioner
-	TemplatePath       string
-	Variables          map[string]string
+	// Test, when set, is checked against the machine once it is
+	// provisioned. The build fails before its post-processors run if any
+	// of its assertions fails.
+	Test *BuildTest
+	// Timeout, when set, is the time after which the build is cancelled.
+	// It then fails with a BuildTimeoutError.
+	Timeout      time.Duration
+	TemplatePath string
+	Variables    map[string]string
 
 	// Indicates whether the build is already initialized before calling Prepare(..)
 	Prepared bool
 
+	// DependsOn are the names of the builds that must succeed before this
+	// build can run.
+	DependsOn []string
+	// ResolveDependencies, when set, is called with the artifacts of the
+	// builds listed in DependsOn, indexed by build name, before the build is
+	// run. It configures the build, which GetBuilds left unconfigured when
+	// told to defer dependent builds, now that these are known.
+	ResolveDependencies func(artifacts map[string][]packersdk.Artifact) error
+
 	debug         bool
 	force         bool
 	onError       string
+	journal       *BuildJournal
+	resume        bool
+	report        *BuildReport
 	l             sync.Mutex
 	prepareCalled bool
 }
@@ -67,6 +90,9 @@ type CoreBuildPostProcessor struct {
 	// deserialised directly from the JSON template
 	config            map[string]interface{}
 	KeepInputArtifact *bool
+	// Condition is the condition of the post-processor, it is skipped when
+	// the condition is false. The post-processor always runs when nil.
+	Condition *Condition
 }
 
 // CoreBuildProvisioner keeps track of the provisioner and the configuration of
@@ -93,6 +119,16 @@ func (b *CoreBuild) Name() string {
 	return b.Type
 }
 
+// builderType returns the type of the builder of the build.
+func (b *CoreBuild) builderType() string {
+	if b.BuilderType != "" {
+		return b.BuilderType
+	}
+	// HCL2 builds are typed after their source, in the
+	// `<builder type>.<source name>` form.
+	return strings.SplitN(b.Type, ".", 2)[0]
+}
+
 // Prepare prepares the build by doing some initialization for the builder
 // and any hooks. This _must_ be called prior to Run. The parameter is the
 // overrides for the variables within the template (if any).
@@ -186,6 +222,80 @@ func (b *CoreBuild) Run(ctx context.Context, originalUi packersdk.Ui) ([]packers
 		panic("Prepare must be called first")
 	}
 
+	if originalUi == nil {
+		// The output of the build is not wanted, but it is still reported
+		// to the ui.
+		originalUi = &packersdk.BasicUi{
+			Writer:      io.Discard,
+			ErrorWriter: io.Discard,
+		}
+	}
+
+	ui := &TargetedUI{
+		Target: b.Name(),
+		Ui:     originalUi,
+	}
+	machineEvent(ui, MachineBuildStart, b.builderType())
+	start := time.Now()
+	ctx, span := tracer().Start(ctx, "build "+b.Name(), trace.WithAttributes(
+		attrBuildName.String(b.Name()),
+		attrBuilderType.String(b.builderType()),
+	))
+	b.report = newBuildReport(b.Name(), b.builderType())
+	b.report.Start = &start
+	b.report.setTraceContext(ctx)
+
+	runCtx := ctx
+	if b.Timeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, b.Timeout)
+		defer cancel()
+	}
+	artifacts, err := b.run(runCtx, originalUi)
+	if runCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil {
+		log.Printf("Build '%s' timed out after %s", b.Name(), b.Timeout)
+		err = &BuildTimeoutError{Timeout: b.Timeout, Err: err}
+	}
+	b.report.finish(artifacts, err)
+	span.SetAttributes(
+		attrOutcome.String(b.report.Outcome),
+		attrArtifactCount.Int(len(b.report.Artifacts)),
+	)
+	endSpan(span, err)
+
+	for _, artifact := range artifacts {
+		if artifact == nil {
+			continue
+		}
+		args := append([]string{artifact.BuilderId(), artifact.Id()}, artifact.Files()...)
+		machineEvent(ui, MachineArtifactProduced, args...)
+	}
+	machineEvent(ui, MachineBuildEnd, machineDuration(time.Since(start)), machineError(err))
+
+	return artifacts, err
+}
+
+// Report returns the report of the run of the build, or nil if the build
+// did not run.
+func (b *CoreBuild) Report() *BuildReport {
+	return b.report
+}
+
+// run runs the builder, the provisioners and the post-processors of the
+// build.
+func (b *CoreBuild) run(ctx context.Context, originalUi packersdk.Ui) ([]packersdk.Artifact, error) {
+	if b.journal != nil {
+		if err := b.startJournal(originalUi); err != nil {
+			return nil, err
+		}
+	}
+
+	// The builder just has a normal Ui, but targeted
+	builderUi := &TargetedUI{
+		Target: b.Name(),
+		Ui:     originalUi,
+	}
+
 	// Copy the hooks
 	hooks := make(map[string][]packersdk.Hook)
 	for hookName, hookList := range b.hooks {
@@ -203,17 +313,20 @@ func (b *CoreBuild) Run(ctx context.Context, originalUi packersdk.Ui) ([]packers
 			} else {
 				pConfig = p.HCLConfig
 			}
+			reportParallelProvisioners(p.Provisioner, b.report, builderUi)
 			if b.debug {
 				hookedProvisioners[i] = &HookedProvisioner{
 					&DebuggedProvisioner{Provisioner: p.Provisioner},
 					pConfig,
 					p.PType,
+					p.PName,
 				}
 			} else {
 				hookedProvisioners[i] = &HookedProvisioner{
 					p.Provisioner,
 					pConfig,
 					p.PType,
+					p.PName,
 				}
 			}
 		}
@@ -224,6 +337,17 @@ func (b *CoreBuild) Run(ctx context.Context, originalUi packersdk.Ui) ([]packers
 
 		hooks[packersdk.HookProvision] = append(hooks[packersdk.HookProvision], &ProvisionHook{
 			Provisioners: hookedProvisioners,
+			Journal:      b.journal,
+			BuildName:    b.Name(),
+			Report:       b.report,
+			EventUi:      builderUi,
+		})
+	}
+
+	if b.Test != nil {
+		hooks[packersdk.HookProvision] = append(hooks[packersdk.HookProvision], &TestHook{
+			Test:      b.Test,
+			BuildName: b.Name(),
 		})
 	}
 
@@ -232,21 +356,18 @@ func (b *CoreBuild) Run(ctx context.Context, originalUi packersdk.Ui) ([]packers
 			b.CleanupProvisioner.Provisioner,
 			b.CleanupProvisioner.config,
 			b.CleanupProvisioner.PType,
+			b.CleanupProvisioner.PName,
 		}
 		hooks[packersdk.HookCleanupProvision] = []packersdk.Hook{&ProvisionHook{
 			Provisioners: []*HookedProvisioner{hookedCleanupProvisioner},
+			Report:       b.report,
+			EventUi:      builderUi,
 		}}
 	}
 
 	hook := &packersdk.DispatchHook{Mapping: hooks}
 	artifacts := make([]packersdk.Artifact, 0, 1)
 
-	// The builder just has a normal Ui, but targeted
-	builderUi := &TargetedUI{
-		Target: b.Name(),
-		Ui:     originalUi,
-	}
-
 	var ts *TelemetrySpan
 	log.Printf("Running builder: %s", b.BuilderType)
 	if b.BuilderConfig != nil {
@@ -254,8 +375,16 @@ func (b *CoreBuild) Run(ctx context.Context, originalUi packersdk.Ui) ([]packers
 	} else {
 		ts = CheckpointReporter.AddSpan(b.Type, "builder", b.HCLConfig)
 	}
+	// The provisioners run while the builder runs, their spans are children
+	// of the span of the builder.
+	builderCtx, builderSpan := tracer().Start(ctx, "builder "+b.builderType(), trace.WithAttributes(
+		attrComponentType.String(b.builderType()),
+	))
+	b.report.setTraceContext(builderCtx)
 	builderArtifact, err := b.Builder.Run(ctx, builderUi, hook)
 	ts.End(err)
+	endSpan(builderSpan, err)
+	b.report.setTraceContext(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -276,16 +405,40 @@ func (b *CoreBuild) Run(ctx context.Context, originalUi packersdk.Ui) ([]packers
 	default:
 	}
 
+	// The conditions of the post-processors are evaluated against the data
+	// generated by the builder, post-processors don't pass it along.
+	generatedData := CastDataToMap(builderArtifact.State("generated_data"))
+
 	// Run the post-processors
 PostProcessorRunSeqLoop:
 	for _, ppSeq := range b.PostProcessors {
 		priorArtifact := builderArtifact
-		for i, corePP := range ppSeq {
+		first := true
+		for _, corePP := range ppSeq {
 			ppUi := &TargetedUI{
 				Target: fmt.Sprintf("%s (%s)", b.Name(), corePP.PType),
 				Ui:     originalUi,
 			}
 
+			if corePP.Condition != nil {
+				run, err := corePP.Condition.evaluate(generatedData)
+				if err != nil {
+					errors = append(errors, fmt.Errorf("Post-processor failed: %s", err))
+					continue PostProcessorRunSeqLoop
+				}
+				if !run {
+					// The next post-processor gets the artifact this one
+					// would have processed.
+					name := corePP.PName
+					if name == "" {
+						name = corePP.PType
+					}
+					builderUi.Say(fmt.Sprintf("Post-processor %s skipped (condition false): %s", name, corePP.Condition.Expression))
+					b.report.skipPostProcessor(corePP.PType, corePP.PName)
+					continue
+				}
+			}
+
 			if corePP.PName == corePP.PType {
 				builderUi.Say(fmt.Sprintf("Running post-processor: %s", corePP.PType))
 			} else {
@@ -297,8 +450,13 @@ PostProcessorRunSeqLoop:
 			} else {
 				ts = CheckpointReporter.AddSpan(corePP.PType, "post-processor", corePP.HCLConfig)
 			}
+			machineEvent(builderUi, MachinePostProcessorStart, corePP.PType, corePP.PName)
+			ppStart := time.Now()
 			artifact, defaultKeep, forceOverride, err := corePP.PostProcessor.PostProcess(ctx, ppUi, priorArtifact)
 			ts.End(err)
+			machineEvent(builderUi, MachinePostProcessorEnd, corePP.PType, corePP.PName,
+				machineDuration(time.Since(ppStart)), machineError(err))
+			b.report.addPostProcessor(corePP.PType, corePP.PName, ppStart, err)
 			if err != nil {
 				errors = append(errors, fmt.Errorf("Post-processor failed: %s", err))
 				continue PostProcessorRunSeqLoop
@@ -328,7 +486,7 @@ PostProcessorRunSeqLoop:
 					keep = *corePP.KeepInputArtifact
 				}
 			}
-			if i == 0 {
+			if first {
 				// This is the first post-processor. We handle deleting
 				// previous artifacts a bit different because multiple
 				// post-processors may be using the original and need it.
@@ -353,6 +511,14 @@ PostProcessorRunSeqLoop:
 			}
 
 			priorArtifact = artifact
+			first = false
+		}
+
+		if first {
+			// Every post-processor of the sequence was skipped, the builder
+			// artifact is its result.
+			keepOriginalArtifact = true
+			continue
 		}
 
 		// Add on the last artifact to the results
@@ -377,9 +543,38 @@ PostProcessorRunSeqLoop:
 		return artifacts, err
 	}
 
+	if b.journal != nil {
+		if err := b.journal.Finish(b.Name()); err != nil {
+			log.Printf("Failed to update the build journal: %s", err)
+		}
+	}
+
 	return artifacts, nil
 }
 
+// startJournal checks whether the build can be resumed from its journal, and
+// starts a fresh journal when it is not resumed.
+func (b *CoreBuild) startJournal(ui packersdk.Ui) error {
+	name := b.Name()
+	entry := b.journal.Entry(name)
+	if !b.resume || entry == nil {
+		return b.journal.Start(name, len(b.Provisioners))
+	}
+
+	if !ResumableBuilders[b.builderType()] {
+		return fmt.Errorf("build '%s' cannot be resumed: the %s builder does not support resuming",
+			name, b.builderType())
+	}
+	if entry.Provisioners != len(b.Provisioners) {
+		return fmt.Errorf("build '%s' cannot be resumed: its provisioners changed since %s was written",
+			name, b.journal.Path())
+	}
+
+	ui.Say(fmt.Sprintf("%s: resuming build, %d of %d provisioner(s) already completed",
+		name, len(entry.CompletedProvisioners), entry.Provisioners))
+	return nil
+}
+
 func (b *CoreBuild) SetDebug(val bool) {
 	if b.prepareCalled {
 		panic("prepare has already been called")
@@ -396,6 +591,14 @@ func (b *CoreBuild) SetForce(val bool) {
 	b.force = val
 }
 
+// SetJournal sets the journal recording the progress of the build. When
+// resume is set, the provisioners the journal recorded as completed are not
+// run again.
+func (b *CoreBuild) SetJournal(journal *BuildJournal, resume bool) {
+	b.journal = journal
+	b.resume = resume
+}
+
 func (b *CoreBuild) SetOnError(val string) {
 	if b.prepareCalled {
 		panic("prepare has already been called")
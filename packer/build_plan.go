@@ -0,0 +1,249 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package packer
+
+import (
+	"fmt"
+	"strings"
+
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// BuildPlan describes what a CoreBuild will do once run: which builder will
+// be started, and which provisioners, test assertions and post-processors will
+// then be executed, in order. Computing a plan never calls Builder.Run.
+type BuildPlan struct {
+	Name                    string                `json:"name"`
+	Builder                 string                `json:"builder"`
+	DependsOn               []string              `json:"depends_on,omitempty"`
+	Timeout                 string                `json:"timeout,omitempty"`
+	Provisioners            []ProvisionerPlan     `json:"provisioners"`
+	ErrorCleanupProvisioner *ProvisionerPlan      `json:"error_cleanup_provisioner,omitempty"`
+	TestAssertions          []string              `json:"test_assertions,omitempty"`
+	PostProcessors          [][]PostProcessorPlan `json:"post_processors"`
+
+	// UnknownValues are the values the build gets from the builds it depends
+	// on. A plan runs no build, so they are unknown.
+	UnknownValues []string `json:"unknown_values,omitempty"`
+}
+
+// ProvisionerPlan describes a provisioner step of a BuildPlan, with the
+// settings from its pause/timeout/retry wrappers resolved.
+type ProvisionerPlan struct {
+	Type        string `json:"type"`
+	Name        string `json:"name,omitempty"`
+	PauseBefore string `json:"pause_before,omitempty"`
+	Timeout     string `json:"timeout,omitempty"`
+	MaxRetries  int    `json:"max_retries,omitempty"`
+	// When is the condition of the provisioner, and Skipped tells whether
+	// it is already known to be false.
+	When    string `json:"when,omitempty"`
+	Skipped bool   `json:"skipped,omitempty"`
+	// Parallel are the provisioners of a group running them concurrently.
+	Parallel []ProvisionerPlan `json:"parallel,omitempty"`
+}
+
+// PostProcessorPlan describes a post-processor step of a BuildPlan.
+type PostProcessorPlan struct {
+	Type              string `json:"type"`
+	Name              string `json:"name,omitempty"`
+	KeepInputArtifact *bool  `json:"keep_input_artifact,omitempty"`
+	When              string `json:"when,omitempty"`
+	Skipped           bool   `json:"skipped,omitempty"`
+}
+
+// Plan returns the BuildPlan of this build. Provisioners and post-processors
+// excluded through only/except have already been filtered out when the
+// build was created, so they are not part of the plan.
+func (b *CoreBuild) Plan() BuildPlan {
+	plan := BuildPlan{
+		Name:           b.Name(),
+		Builder:        b.builderType(),
+		DependsOn:      b.DependsOn,
+		Provisioners:   []ProvisionerPlan{},
+		PostProcessors: [][]PostProcessorPlan{},
+	}
+	for _, dep := range b.DependsOn {
+		plan.UnknownValues = append(plan.UnknownValues, "build."+dep)
+	}
+	if b.Timeout > 0 {
+		plan.Timeout = b.Timeout.String()
+	}
+
+	for _, p := range b.Provisioners {
+		plan.Provisioners = append(plan.Provisioners, newProvisionerPlan(p))
+	}
+
+	if b.CleanupProvisioner.PType != "" {
+		cleanup := newProvisionerPlan(b.CleanupProvisioner)
+		plan.ErrorCleanupProvisioner = &cleanup
+	}
+
+	if b.Test != nil {
+		for _, a := range b.Test.Assertions {
+			plan.TestAssertions = append(plan.TestAssertions, a.Name)
+		}
+	}
+
+	for _, ppSeq := range b.PostProcessors {
+		seq := []PostProcessorPlan{}
+		for _, pp := range ppSeq {
+			ppPlan := PostProcessorPlan{
+				Type:              pp.PType,
+				Name:              pp.PName,
+				KeepInputArtifact: pp.KeepInputArtifact,
+			}
+			if pp.Condition != nil {
+				ppPlan.When = pp.Condition.Expression
+				ppPlan.Skipped = pp.Condition.skipped()
+			}
+			seq = append(seq, ppPlan)
+		}
+		plan.PostProcessors = append(plan.PostProcessors, seq)
+	}
+
+	return plan
+}
+
+// newProvisionerPlan unwraps the provisioner wrappers set up when the build
+// was created in order to expose their settings.
+func newProvisionerPlan(p CoreBuildProvisioner) ProvisionerPlan {
+	plan := ProvisionerPlan{
+		Type: p.PType,
+		Name: p.PName,
+	}
+
+	var prov packersdk.Provisioner = p.Provisioner
+	for prov != nil {
+		switch wrapped := prov.(type) {
+		case *ConditionalProvisioner:
+			plan.When = wrapped.Condition.Expression
+			plan.Skipped = wrapped.Condition.skipped()
+			prov = wrapped.Provisioner
+		case *RetriedProvisioner:
+			plan.MaxRetries = wrapped.MaxRetries
+			prov = wrapped.Provisioner
+		case *PausedProvisioner:
+			plan.PauseBefore = wrapped.PauseBefore.String()
+			prov = wrapped.Provisioner
+		case *TimeoutProvisioner:
+			plan.Timeout = wrapped.Timeout.String()
+			prov = wrapped.Provisioner
+		case *DebuggedProvisioner:
+			prov = wrapped.Provisioner
+		case *ParallelProvisioner:
+			plan.Parallel = []ProvisionerPlan{}
+			for _, child := range wrapped.Provisioners {
+				plan.Parallel = append(plan.Parallel, newProvisionerPlan(child))
+			}
+			prov = nil
+		default:
+			prov = nil
+		}
+	}
+
+	return plan
+}
+
+// String returns a human readable representation of the plan.
+func (p BuildPlan) String() string {
+	out := &strings.Builder{}
+	fmt.Fprintf(out, "%s:\n", p.Name)
+	fmt.Fprintf(out, "  builder: %s\n", p.Builder)
+	if len(p.DependsOn) > 0 {
+		fmt.Fprintf(out, "  depends on: %s\n", strings.Join(p.DependsOn, ", "))
+	}
+	if len(p.UnknownValues) > 0 {
+		fmt.Fprintf(out, "  unknown values: %s\n", strings.Join(p.UnknownValues, ", "))
+	}
+	if p.Timeout != "" {
+		fmt.Fprintf(out, "  timeout: %s\n", p.Timeout)
+	}
+
+	out.WriteString("  provisioners:\n")
+	if len(p.Provisioners) == 0 {
+		out.WriteString("    <no provisioner>\n")
+	}
+	for i, prov := range p.Provisioners {
+		fmt.Fprintf(out, "    %d: %s\n", i, prov)
+		for _, child := range prov.Parallel {
+			fmt.Fprintf(out, "      - %s\n", child)
+		}
+	}
+
+	if p.ErrorCleanupProvisioner != nil {
+		fmt.Fprintf(out, "  error-cleanup-provisioner: %s\n", p.ErrorCleanupProvisioner)
+	}
+
+	if len(p.TestAssertions) > 0 {
+		fmt.Fprintf(out, "  test assertions: %s\n", strings.Join(p.TestAssertions, ", "))
+	}
+
+	out.WriteString("  post-processors:\n")
+	if len(p.PostProcessors) == 0 {
+		out.WriteString("    <no post-processor>\n")
+	}
+	for i, seq := range p.PostProcessors {
+		fmt.Fprintf(out, "    %d:\n", i)
+		for _, pp := range seq {
+			fmt.Fprintf(out, "      %s\n", pp)
+		}
+	}
+
+	return out.String()
+}
+
+func (p ProvisionerPlan) String() string {
+	str := p.Type
+	if p.Name != "" && p.Name != p.Type {
+		str = fmt.Sprintf("%s (type %s)", p.Name, p.Type)
+	}
+
+	var opts []string
+	if p.PauseBefore != "" {
+		opts = append(opts, "pause_before="+p.PauseBefore)
+	}
+	if p.Timeout != "" {
+		opts = append(opts, "timeout="+p.Timeout)
+	}
+	if p.MaxRetries != 0 {
+		opts = append(opts, fmt.Sprintf("max_retries=%d", p.MaxRetries))
+	}
+	if p.Parallel != nil {
+		opts = append(opts, "parallel")
+	}
+	opts = append(opts, conditionOpts(p.When, p.Skipped)...)
+	if len(opts) > 0 {
+		str = fmt.Sprintf("%s [%s]", str, strings.Join(opts, ", "))
+	}
+	return str
+}
+
+func (p PostProcessorPlan) String() string {
+	str := p.Type
+	if p.Name != "" && p.Name != p.Type {
+		str = fmt.Sprintf("%s (type %s)", p.Name, p.Type)
+	}
+	var opts []string
+	if p.KeepInputArtifact != nil {
+		opts = append(opts, fmt.Sprintf("keep_input_artifact=%t", *p.KeepInputArtifact))
+	}
+	opts = append(opts, conditionOpts(p.When, p.Skipped)...)
+	if len(opts) > 0 {
+		str = fmt.Sprintf("%s [%s]", str, strings.Join(opts, ", "))
+	}
+	return str
+}
+
+// conditionOpts returns how the condition of a step is shown in a plan.
+func conditionOpts(when string, skipped bool) []string {
+	if when == "" {
+		return nil
+	}
+	opts := []string{"when=" + when}
+	if skipped {
+		opts = append(opts, "skipped (condition false)")
+	}
+	return opts
+}
@@ -0,0 +1,258 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package packer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// Outcomes of a build, a provisioner or a post-processor in a BuildReport.
+const (
+	OutcomeSucceeded = "succeeded"
+	OutcomeFailed    = "failed"
+	OutcomeSkipped   = "skipped"
+	OutcomeTimedOut  = "timed-out"
+)
+
+// reportRedactedKeys are the keys of the generated data of an artifact that
+// are never written to a report.
+var reportRedactedKeys = map[string]bool{
+	"Password":      true,
+	"SSHPrivateKey": true,
+	"WinRMPassword": true,
+}
+
+// BuildReport records the run of a build: when it ran, how each of its
+// provisioners and post-processors went, and the artifacts it produced.
+type BuildReport struct {
+	Name            string           `json:"name"`
+	Builder         string           `json:"builder,omitempty"`
+	Outcome         string           `json:"outcome"`
+	Start           *time.Time       `json:"start,omitempty"`
+	End             *time.Time       `json:"end,omitempty"`
+	DurationSeconds float64          `json:"duration_seconds"`
+	Provisioners    []StepReport     `json:"provisioners"`
+	PostProcessors  []StepReport     `json:"post_processors"`
+	Artifacts       []ArtifactReport `json:"artifacts"`
+	Error           string           `json:"error,omitempty"`
+
+	l sync.Mutex
+	// traceCtx holds the span under which the runs of the provisioners and
+	// post-processors are traced, when set.
+	traceCtx context.Context
+}
+
+// StepReport records the run of a provisioner or a post-processor. The
+// provisioners of a group running in parallel are recorded as they finish,
+// before their group.
+type StepReport struct {
+	Type            string    `json:"type"`
+	Name            string    `json:"name,omitempty"`
+	Outcome         string    `json:"outcome"`
+	Start           time.Time `json:"start"`
+	DurationSeconds float64   `json:"duration_seconds"`
+	Error           string    `json:"error,omitempty"`
+}
+
+// ArtifactReport describes an artifact produced by a build.
+type ArtifactReport struct {
+	BuilderID     string                 `json:"builder_id"`
+	ID            string                 `json:"id"`
+	Files         []string               `json:"files"`
+	GeneratedData map[string]interface{} `json:"generated_data,omitempty"`
+}
+
+// NewBuildReport returns the report of a build that did not run, for
+// example because a build it depends on failed.
+func NewBuildReport(name, outcome string, err error) *BuildReport {
+	r := newBuildReport(name, "")
+	r.Outcome = outcome
+	r.Error = reportError(err)
+	return r
+}
+
+// newBuildReport returns an empty report for the build named name.
+func newBuildReport(name, builder string) *BuildReport {
+	return &BuildReport{
+		Name:           name,
+		Builder:        builder,
+		Provisioners:   []StepReport{},
+		PostProcessors: []StepReport{},
+		Artifacts:      []ArtifactReport{},
+	}
+}
+
+// addProvisioner records the run of a provisioner that started at start.
+// It does nothing on a nil report, so that hooks can run without one.
+func (r *BuildReport) addProvisioner(ptype, pname string, start time.Time, err error) {
+	if r == nil {
+		return
+	}
+	r.l.Lock()
+	defer r.l.Unlock()
+	step := newStepReport(ptype, pname, start, err)
+	r.Provisioners = append(r.Provisioners, step)
+	r.trace("provisioner", step)
+}
+
+// addPostProcessor records the run of a post-processor that started at
+// start.
+func (r *BuildReport) addPostProcessor(ptype, pname string, start time.Time, err error) {
+	r.l.Lock()
+	defer r.l.Unlock()
+	step := newStepReport(ptype, pname, start, err)
+	r.PostProcessors = append(r.PostProcessors, step)
+	r.trace("post-processor", step)
+}
+
+// skipProvisioner records that a provisioner did not run.
+func (r *BuildReport) skipProvisioner(ptype, pname string) {
+	if r == nil {
+		return
+	}
+	r.l.Lock()
+	defer r.l.Unlock()
+	step := StepReport{Type: ptype, Name: pname, Outcome: OutcomeSkipped, Start: time.Now()}
+	r.Provisioners = append(r.Provisioners, step)
+	r.trace("provisioner", step)
+}
+
+// skipPostProcessor records that a post-processor did not run.
+func (r *BuildReport) skipPostProcessor(ptype, pname string) {
+	r.l.Lock()
+	defer r.l.Unlock()
+	step := StepReport{Type: ptype, Name: pname, Outcome: OutcomeSkipped, Start: time.Now()}
+	r.PostProcessors = append(r.PostProcessors, step)
+	r.trace("post-processor", step)
+}
+
+// setTraceContext sets the context holding the span under which the next
+// steps are traced.
+func (r *BuildReport) setTraceContext(ctx context.Context) {
+	r.l.Lock()
+	defer r.l.Unlock()
+	r.traceCtx = ctx
+}
+
+// trace records step as a span, when the report is traced. The lock must be
+// held.
+func (r *BuildReport) trace(kind string, step StepReport) {
+	if r.traceCtx != nil {
+		traceStep(r.traceCtx, kind, step)
+	}
+}
+
+// finish records the end of the build, with its artifacts and error.
+func (r *BuildReport) finish(artifacts []packersdk.Artifact, err error) {
+	r.l.Lock()
+	defer r.l.Unlock()
+
+	end := time.Now()
+	r.End = &end
+	r.DurationSeconds = end.Sub(*r.Start).Seconds()
+	r.Outcome = OutcomeSucceeded
+	if err != nil {
+		r.Outcome = OutcomeFailed
+		if errors.As(err, new(*BuildTimeoutError)) {
+			r.Outcome = OutcomeTimedOut
+		}
+		r.Error = reportError(err)
+	}
+	for _, artifact := range artifacts {
+		if artifact == nil {
+			continue
+		}
+		files := artifact.Files()
+		if files == nil {
+			files = []string{}
+		}
+		r.Artifacts = append(r.Artifacts, ArtifactReport{
+			BuilderID:     artifact.BuilderId(),
+			ID:            artifact.Id(),
+			Files:         files,
+			GeneratedData: reportGeneratedData(artifact.State("generated_data")),
+		})
+	}
+}
+
+func newStepReport(ptype, pname string, start time.Time, err error) StepReport {
+	step := StepReport{
+		Type:            ptype,
+		Name:            pname,
+		Outcome:         OutcomeSucceeded,
+		Start:           start,
+		DurationSeconds: time.Since(start).Seconds(),
+	}
+	if err != nil {
+		step.Outcome = OutcomeFailed
+		step.Error = reportError(err)
+	}
+	return step
+}
+
+// reportError returns the message of err with its secrets filtered out.
+func reportError(err error) string {
+	if err == nil {
+		return ""
+	}
+	return packersdk.LogSecretFilter.FilterString(err.Error())
+}
+
+// reportGeneratedData returns the generated data of an artifact as it is
+// written to a report: without its secrets, and with the values that can't
+// be encoded to JSON formatted as strings.
+func reportGeneratedData(data interface{}) map[string]interface{} {
+	if data == nil {
+		return nil
+	}
+	res := map[string]interface{}{}
+	for k, v := range CastDataToMap(data) {
+		if reportRedactedKeys[k] {
+			continue
+		}
+		if s, ok := v.(string); ok {
+			v = packersdk.LogSecretFilter.FilterString(s)
+		} else if _, err := json.Marshal(v); err != nil {
+			v = fmt.Sprint(v)
+		}
+		res[k] = v
+	}
+	return res
+}
+
+// reportParallelProvisioners sets the report in which the groups of
+// provisioners wrapped by p record the runs of their provisioners, and the ui
+// they send the events reporting these runs to.
+func reportParallelProvisioners(p packersdk.Provisioner, report *BuildReport, eventUi packersdk.Ui) {
+	for p != nil {
+		switch wrapped := p.(type) {
+		case *ParallelProvisioner:
+			wrapped.report = report
+			wrapped.eventUi = eventUi
+			for _, child := range wrapped.Provisioners {
+				reportParallelProvisioners(child.Provisioner, report, eventUi)
+			}
+			return
+		case *ConditionalProvisioner:
+			p = wrapped.Provisioner
+		case *RetriedProvisioner:
+			p = wrapped.Provisioner
+		case *PausedProvisioner:
+			p = wrapped.Provisioner
+		case *TimeoutProvisioner:
+			p = wrapped.Provisioner
+		case *DebuggedProvisioner:
+			p = wrapped.Provisioner
+		default:
+			p = nil
+		}
+	}
+}
@@ -0,0 +1,25 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package packer
+
+import (
+	"fmt"
+	"time"
+)
+
+// BuildTimeoutError is the error of a build that was cancelled because it
+// ran for longer than its timeout.
+type BuildTimeoutError struct {
+	Timeout time.Duration
+	// Err is the error the build returned once cancelled, if any.
+	Err error
+}
+
+func (e *BuildTimeoutError) Error() string {
+	return fmt.Sprintf("timed out after %s", e.Timeout)
+}
+
+func (e *BuildTimeoutError) Unwrap() error {
+	return e.Err
+}
@@ -470,6 +470,8 @@ Type in the interpolation to test and hit <enter> to see the result.
 
 "{{timestamp}}" will output the timestamp, for example "1559855090".
 
+":reload" will load the template again, after it was edited.
+
 To exit the console, type "exit" and hit <enter>, or use Control-C.
 
 /!\ If you would like to start console in hcl2 mode without a config you can
@@ -484,6 +486,11 @@ func (c *Core) EvaluateExpression(line string) (string, bool, hcl.Diagnostics) {
 		return "", true, nil
 	case line == "help":
 		return ConsoleHelp, false, nil
+	case strings.HasPrefix(line, ":explain"):
+		return "", false, hcl.Diagnostics{&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "The :explain command is only supported in HCL2 mode",
+		}}
 	case line == "variables":
 		varsstring := "\n"
 		for k, v := range c.Context().UserVariables {
@@ -509,6 +516,10 @@ func (c *Core) InspectConfig(opts InspectConfigOptions) int {
 
 	// Convenience...
 	ui := opts.Ui
+	if opts.Format == "json" {
+		ui.Error("The json format is only supported for HCL2 templates.")
+		return 1
+	}
 	tpl := c.Template
 	ui.Say("Packer Inspect: JSON mode")
 
@@ -0,0 +1,172 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package packer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// JournalFileName is the name of the journal file written in the journal
+// directory.
+const JournalFileName = ".packer-journal.json"
+
+// ResumableBuilders lists the builder types that can be run again against
+// the machine kept alive by a previous build that failed while provisioning.
+// A builder opts in to `packer build -resume` by being listed here.
+var ResumableBuilders = map[string]bool{
+	"null": true,
+}
+
+// BuildJournal records, for each build, the provisioners that completed. It
+// is persisted after each change so that a build that failed while
+// provisioning can later be resumed from the first provisioner that did not
+// complete.
+type BuildJournal struct {
+	Builds map[string]*BuildJournalEntry `json:"builds"`
+
+	path string
+	l    sync.Mutex
+}
+
+// BuildJournalEntry is the journal of a single build.
+type BuildJournalEntry struct {
+	// Provisioners is the number of provisioners of the build when the
+	// journal was started, it is used to detect template changes.
+	Provisioners int `json:"provisioners"`
+	// CompletedProvisioners are the indices of the provisioners that
+	// completed successfully.
+	CompletedProvisioners []int `json:"completed_provisioners"`
+}
+
+// LoadBuildJournal reads the journal file in dir. An empty journal is
+// returned if the file does not exist yet.
+func LoadBuildJournal(dir string) (*BuildJournal, error) {
+	j := &BuildJournal{
+		Builds: map[string]*BuildJournalEntry{},
+		path:   filepath.Join(dir, JournalFileName),
+	}
+
+	b, err := os.ReadFile(j.path)
+	if os.IsNotExist(err) {
+		return j, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read build journal: %s", err)
+	}
+	if err := json.Unmarshal(b, j); err != nil {
+		return nil, fmt.Errorf("failed to decode build journal %s: %s", j.path, err)
+	}
+	if j.Builds == nil {
+		j.Builds = map[string]*BuildJournalEntry{}
+	}
+	return j, nil
+}
+
+// Path returns the path of the journal file.
+func (j *BuildJournal) Path() string {
+	return j.path
+}
+
+// Entry returns a copy of the journal entry of build, or nil if there is
+// none.
+func (j *BuildJournal) Entry(build string) *BuildJournalEntry {
+	j.l.Lock()
+	defer j.l.Unlock()
+
+	entry, ok := j.Builds[build]
+	if !ok {
+		return nil
+	}
+	return &BuildJournalEntry{
+		Provisioners:          entry.Provisioners,
+		CompletedProvisioners: append([]int{}, entry.CompletedProvisioners...),
+	}
+}
+
+// Start starts a fresh journal for build, forgetting what was recorded
+// before.
+func (j *BuildJournal) Start(build string, provisioners int) error {
+	j.l.Lock()
+	defer j.l.Unlock()
+
+	j.Builds[build] = &BuildJournalEntry{
+		Provisioners:          provisioners,
+		CompletedProvisioners: []int{},
+	}
+	return j.save()
+}
+
+// ProvisionerCompleted records that the provisioner at index i of build
+// completed.
+func (j *BuildJournal) ProvisionerCompleted(build string, i int) error {
+	j.l.Lock()
+	defer j.l.Unlock()
+
+	entry, ok := j.Builds[build]
+	if !ok {
+		entry = &BuildJournalEntry{}
+		j.Builds[build] = entry
+	}
+	for _, done := range entry.CompletedProvisioners {
+		if done == i {
+			return nil
+		}
+	}
+	entry.CompletedProvisioners = append(entry.CompletedProvisioners, i)
+	sort.Ints(entry.CompletedProvisioners)
+	return j.save()
+}
+
+// Completed says whether the provisioner at index i of build completed.
+func (j *BuildJournal) Completed(build string, i int) bool {
+	j.l.Lock()
+	defer j.l.Unlock()
+
+	entry, ok := j.Builds[build]
+	if !ok {
+		return false
+	}
+	for _, done := range entry.CompletedProvisioners {
+		if done == i {
+			return true
+		}
+	}
+	return false
+}
+
+// Finish removes build from the journal, once it fully succeeded.
+func (j *BuildJournal) Finish(build string) error {
+	j.l.Lock()
+	defer j.l.Unlock()
+
+	delete(j.Builds, build)
+	if len(j.Builds) == 0 {
+		err := os.Remove(j.path)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	return j.save()
+}
+
+func (j *BuildJournal) save() error {
+	b, err := json.MarshalIndent(j, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(j.path), 0755); err != nil {
+		return err
+	}
+	tmp := j.path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, j.path)
+}
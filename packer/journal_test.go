@@ -0,0 +1,88 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package packer
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+func TestBuildJournal(t *testing.T) {
+	dir := t.TempDir()
+
+	journal, err := LoadBuildJournal(dir)
+	if err != nil {
+		t.Fatalf("failed to load empty journal: %s", err)
+	}
+	if err := journal.Start("null.foo", 3); err != nil {
+		t.Fatal(err)
+	}
+	if err := journal.ProvisionerCompleted("null.foo", 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := journal.ProvisionerCompleted("null.foo", 0); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded, err := LoadBuildJournal(dir)
+	if err != nil {
+		t.Fatalf("failed to reload journal: %s", err)
+	}
+	expected := &BuildJournalEntry{Provisioners: 3, CompletedProvisioners: []int{0, 1}}
+	if diff := cmp.Diff(expected, reloaded.Entry("null.foo")); diff != "" {
+		t.Fatalf("unexpected journal entry: %s", diff)
+	}
+
+	if err := reloaded.Finish("null.foo"); err != nil {
+		t.Fatal(err)
+	}
+	if reloaded.Entry("null.foo") != nil {
+		t.Fatalf("entry should be removed once the build finished")
+	}
+}
+
+func TestProvisionHook_journal(t *testing.T) {
+	journal, err := LoadBuildJournal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := journal.ProvisionerCompleted("foo", 0); err != nil {
+		t.Fatal(err)
+	}
+
+	pA := &packersdk.MockProvisioner{}
+	pB := &packersdk.MockProvisioner{}
+	pC := &packersdk.MockProvisioner{
+		ProvFunc: func(context.Context) error { return errors.New("failed") },
+	}
+
+	hook := &ProvisionHook{
+		Provisioners: []*HookedProvisioner{
+			{pA, nil, "", ""},
+			{pB, nil, "", ""},
+			{pC, nil, "", ""},
+		},
+		Journal:   journal,
+		BuildName: "foo",
+	}
+
+	err = hook.Run(context.Background(), "foo", testUi(), new(packersdk.MockCommunicator), nil)
+	if err == nil {
+		t.Fatal("should error")
+	}
+
+	if pA.ProvCalled {
+		t.Error("journaled provisioner should not be called")
+	}
+	if !pB.ProvCalled || !pC.ProvCalled {
+		t.Error("remaining provisioners should be called")
+	}
+	if !journal.Completed("foo", 1) || journal.Completed("foo", 2) {
+		t.Errorf("unexpected journal: %#v", journal.Entry("foo"))
+	}
+}
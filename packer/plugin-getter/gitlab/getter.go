@@ -0,0 +1,193 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package gitlab
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	plugingetter "github.com/hashicorp/packer/packer/plugin-getter"
+)
+
+const (
+	defaultUserAgent = "packer-gitlab-plugin-getter"
+	releasesPerPage  = "100"
+)
+
+// Getter gets plugins from the releases of GitLab projects. The plugin
+// source gitlab.example.com/infra/happycloud is looked up in the
+// infra/packer-plugin-happycloud project, and the checksum and zip files
+// must be links, or uploads, of its releases named like the files of a
+// GitHub release.
+type Getter struct {
+	// BaseURL of the GitLab API, defaults to https://{hostname}/api/v4/ where
+	// hostname is the one of the plugin source.
+	BaseURL string
+
+	// Client is the HTTP client used for requests, nil means we use the
+	// default one from http. Per host credentials can be set with a
+	// github.HostSpecificTokenAuthTransport.
+	Client    *http.Client
+	UserAgent string
+}
+
+var _ plugingetter.Getter = &Getter{}
+
+// release is a GitLab release, as returned by the releases API.
+type release struct {
+	TagName string `json:"tag_name"`
+	Assets  struct {
+		Links []struct {
+			Name           string `json:"name"`
+			URL            string `json:"url"`
+			DirectAssetURL string `json:"direct_asset_url"`
+		} `json:"links"`
+	} `json:"assets"`
+}
+
+func (g *Getter) Get(what string, opts plugingetter.GetOptions) (io.ReadCloser, error) {
+	project, err := g.projectURL(opts.PluginRequirement.Identifier.Hostname, opts.PluginRequirement.Identifier.RealRelativePath())
+	if err != nil {
+		return nil, err
+	}
+
+	switch what {
+	case "releases":
+		return g.releases(project)
+	case "sha256":
+		// something like packer-plugin-happycloud_v0.2.11_SHA256SUMS
+		body, err := g.asset(project, opts.Version(), opts.PluginRequirement.FilenamePrefix()+opts.Version()+"_SHA256SUMS")
+		if err != nil {
+			return nil, err
+		}
+		return plugingetter.TransformChecksumStream()(body)
+	case "zip":
+		return g.asset(project, opts.Version(), opts.ExpectedZipFilename())
+	}
+	return nil, fmt.Errorf("%q not implemented", what)
+}
+
+// projectURL returns the API URL of the project of a plugin.
+func (g *Getter) projectURL(hostname, projectPath string) (*url.URL, error) {
+	base := g.BaseURL
+	if base == "" {
+		base = "https://" + hostname + "/api/v4/"
+	}
+	u, err := url.Parse(strings.TrimSuffix(base, "/") + "/projects/" + url.PathEscape(projectPath))
+	if err != nil {
+		return nil, fmt.Errorf("invalid GitLab API URL %q: %s", base, err)
+	}
+	return u, nil
+}
+
+// releases returns the JSON list of plugingetter.Release of a project,
+// following the pagination of the API.
+func (g *Getter) releases(project *url.URL) (io.ReadCloser, error) {
+	out := []plugingetter.Release{}
+	page := "1"
+	for page != "" {
+		u := *project
+		u.Path += "/releases"
+		u.RawPath = ""
+		if project.RawPath != "" {
+			u.RawPath = project.RawPath + "/releases"
+		}
+		u.RawQuery = url.Values{"per_page": {releasesPerPage}, "page": {page}}.Encode()
+
+		resp, err := g.do(&u)
+		if err != nil {
+			return nil, err
+		}
+		var releases []release
+		err = json.NewDecoder(resp.Body).Decode(&releases)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("could not decode GitLab releases: %s", err)
+		}
+		for _, r := range releases {
+			out = append(out, plugingetter.Release{Version: r.TagName})
+		}
+		page = resp.Header.Get("X-Next-Page")
+	}
+
+	buf := &bytes.Buffer{}
+	if err := json.NewEncoder(buf).Encode(out); err != nil {
+		return nil, err
+	}
+	return io.NopCloser(buf), nil
+}
+
+// asset returns the content of the file named name in the release of tag.
+func (g *Getter) asset(project *url.URL, tag, name string) (io.ReadCloser, error) {
+	u := *project
+	u.Path += "/releases/" + tag
+	u.RawPath = ""
+	if project.RawPath != "" {
+		u.RawPath = project.RawPath + "/releases/" + url.PathEscape(tag)
+	}
+
+	resp, err := g.do(&u)
+	if err != nil {
+		return nil, err
+	}
+	var r release
+	err = json.NewDecoder(resp.Body).Decode(&r)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("could not decode GitLab release %s: %s", tag, err)
+	}
+
+	for _, link := range r.Assets.Links {
+		if link.Name != name {
+			continue
+		}
+		assetURL := link.DirectAssetURL
+		if assetURL == "" {
+			assetURL = link.URL
+		}
+		au, err := project.Parse(assetURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid URL %q for %s: %s", assetURL, name, err)
+		}
+		resp, err := g.do(au)
+		if err != nil {
+			return nil, err
+		}
+		return resp.Body, nil
+	}
+	return nil, fmt.Errorf("no %s asset found in GitLab release %s", name, tag)
+}
+
+func (g *Getter) do(u *url.URL) (*http.Response, error) {
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", defaultUserAgent)
+	if g.UserAgent != "" {
+		req.Header.Set("User-Agent", g.UserAgent)
+	}
+
+	client := g.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	log.Printf("[DEBUG] gitlab-getter: getting %q", u)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("GET %s: unexpected status %s", u.Redacted(), resp.Status)
+	}
+	return resp, nil
+}
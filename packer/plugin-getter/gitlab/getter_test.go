@@ -0,0 +1,142 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package gitlab
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/packer/hcl2template/addrs"
+	plugingetter "github.com/hashicorp/packer/packer/plugin-getter"
+	"github.com/hashicorp/packer/packer/plugin-getter/github"
+	"golang.org/x/oauth2"
+)
+
+func TestGetter_install(t *testing.T) {
+	files := map[string][]byte{}
+	for _, v := range []string{"v1.2.4", "v1.2.5"} {
+		binary := fmt.Sprintf("packer-plugin-amazon_%s_x5.0_linux_amd64", v)
+		zip := testZip(t, binary, v)
+		sum := sha256.Sum256(zip)
+		files[binary+".zip"] = zip
+		files["packer-plugin-amazon_"+v+"_SHA256SUMS"] = []byte(fmt.Sprintf("%s  %s.zip\n", hex.EncodeToString(sum[:]), binary))
+	}
+
+	project := "/api/v4/projects/" + url.PathEscape("infra/packer-plugin-amazon")
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer s3cr3t" {
+			t.Errorf("unexpected Authorization header %q for %s", got, r.URL)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		path := r.URL.EscapedPath()
+		switch {
+		case path == project+"/releases":
+			// one release per page, to check pagination is followed
+			switch r.URL.Query().Get("page") {
+			case "1":
+				w.Header().Set("X-Next-Page", "2")
+				fmt.Fprint(w, `[{"tag_name": "v1.2.5"}]`)
+			case "2":
+				fmt.Fprint(w, `[{"tag_name": "v1.2.4"}]`)
+			default:
+				fmt.Fprint(w, `[]`)
+			}
+		case strings.HasPrefix(path, project+"/releases/"):
+			tag := strings.TrimPrefix(path, project+"/releases/")
+			links := []string{}
+			for name := range files {
+				if strings.Contains(name, tag) {
+					links = append(links, fmt.Sprintf(`{"name": %q, "direct_asset_url": %q}`, name, server.URL+"/downloads/"+name))
+				}
+			}
+			fmt.Fprintf(w, `{"tag_name": %q, "assets": {"links": [%s]}}`, tag, strings.Join(links, ","))
+		case strings.HasPrefix(path, "/downloads/"):
+			content, found := files[strings.TrimPrefix(path, "/downloads/")]
+			if !found {
+				http.NotFound(w, r)
+				return
+			}
+			_, _ = w.Write(content)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	serverURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	getter := &Getter{
+		BaseURL: server.URL + "/api/v4",
+		Client: &http.Client{
+			Transport: &github.HostSpecificTokenAuthTransport{
+				TokenSources: map[string]oauth2.TokenSource{
+					serverURL.Host: oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "s3cr3t"}),
+				},
+			},
+		},
+	}
+
+	identifier, diags := addrs.ParsePluginSourceString("gitlab.example.com/infra/amazon")
+	if len(diags) != 0 {
+		t.Fatal(diags)
+	}
+	pr := &plugingetter.Requirement{
+		Identifier:         identifier,
+		VersionConstraints: version.MustConstraints(version.NewConstraint(">= v1")),
+	}
+	install, err := pr.InstallLatest(plugingetter.InstallOptions{
+		Getters:   []plugingetter.Getter{getter},
+		InFolders: []string{t.TempDir()},
+		BinaryInstallationOptions: plugingetter.BinaryInstallationOptions{
+			APIVersionMajor: "5", APIVersionMinor: "0",
+			OS: "linux", ARCH: "amd64",
+			Checksummers: []plugingetter.Checksummer{
+				{Type: "sha256", Hash: sha256.New()},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("InstallLatest: %s", err)
+	}
+	if install == nil || install.Version != "v1.2.5" {
+		t.Fatalf("Expected v1.2.5 to be installed, got %v", install)
+	}
+	content, err := os.ReadFile(install.BinaryPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "v1.2.5" {
+		t.Errorf("unexpected binary content %q", content)
+	}
+}
+
+func testZip(t *testing.T, name, content string) []byte {
+	buf := &bytes.Buffer{}
+	zw := zip.NewWriter(buf)
+	w, err := zw.Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte(content)); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,7 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+// Package httpindex defines a getter for plugins published on any HTTPS
+// server, along with a JSON index of their releases.
+
+package httpindex
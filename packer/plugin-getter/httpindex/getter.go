@@ -0,0 +1,188 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package httpindex
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	plugingetter "github.com/hashicorp/packer/packer/plugin-getter"
+)
+
+const (
+	defaultUserAgent = "packer-http-index-plugin-getter"
+	indexFilename    = "index.json"
+)
+
+// Getter gets plugins from a JSON index of their releases. The index of the
+// plugin source plugins.example.com/infra/happycloud is read from
+// {BaseURL}/infra/happycloud/index.json and looks like:
+//
+//	{
+//	  "releases": [
+//	    {
+//	      "version": "v1.2.3",
+//	      "files": {
+//	        "packer-plugin-happycloud_v1.2.3_SHA256SUMS": "v1.2.3/SHA256SUMS",
+//	        "packer-plugin-happycloud_v1.2.3_x5.0_linux_amd64.zip": "https://cdn.example.com/happycloud_linux_amd64.zip"
+//	      }
+//	    }
+//	  ]
+//	}
+//
+// Files are named like the files of a GitHub release, and their URLs can be
+// relative to the index.
+type Getter struct {
+	// BaseURL of the indexes, defaults to https://{hostname}/ where hostname
+	// is the one of the plugin source.
+	BaseURL string
+
+	// Client is the HTTP client used for requests, nil means we use the
+	// default one from http. Per host credentials can be set with a
+	// github.HostSpecificTokenAuthTransport.
+	Client    *http.Client
+	UserAgent string
+
+	mu      sync.Mutex
+	indexes map[string]*index
+}
+
+var _ plugingetter.Getter = &Getter{}
+
+type index struct {
+	Releases []struct {
+		Version string            `json:"version"`
+		Files   map[string]string `json:"files"`
+	} `json:"releases"`
+
+	url *url.URL
+}
+
+func (g *Getter) Get(what string, opts plugingetter.GetOptions) (io.ReadCloser, error) {
+	idx, err := g.index(opts.PluginRequirement)
+	if err != nil {
+		return nil, err
+	}
+
+	switch what {
+	case "releases":
+		out := []plugingetter.Release{}
+		for _, r := range idx.Releases {
+			out = append(out, plugingetter.Release{Version: r.Version})
+		}
+		buf := &bytes.Buffer{}
+		if err := json.NewEncoder(buf).Encode(out); err != nil {
+			return nil, err
+		}
+		return io.NopCloser(buf), nil
+	case "sha256":
+		body, err := g.file(idx, opts.Version(), opts.PluginRequirement.FilenamePrefix()+opts.Version()+"_SHA256SUMS")
+		if err != nil {
+			return nil, err
+		}
+		return plugingetter.TransformChecksumStream()(body)
+	case "zip":
+		return g.file(idx, opts.Version(), opts.ExpectedZipFilename())
+	}
+	return nil, fmt.Errorf("%q not implemented", what)
+}
+
+// index returns the index of the releases of a plugin, it is only fetched
+// once per getter.
+func (g *Getter) index(pr *plugingetter.Requirement) (*index, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	id := pr.Identifier.String()
+	if idx, found := g.indexes[id]; found {
+		return idx, nil
+	}
+
+	base := g.BaseURL
+	if base == "" {
+		base = "https://" + pr.Identifier.Hostname + "/"
+	}
+	baseURL, err := url.Parse(strings.TrimSuffix(base, "/") + "/")
+	if err != nil {
+		return nil, fmt.Errorf("invalid plugin index URL %q: %s", base, err)
+	}
+	u := baseURL.ResolveReference(&url.URL{
+		Path: pr.Identifier.Namespace + "/" + pr.Identifier.Type + "/" + indexFilename,
+	})
+
+	resp, err := g.do(u)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	idx := &index{url: u}
+	if err := json.NewDecoder(resp.Body).Decode(idx); err != nil {
+		return nil, fmt.Errorf("could not decode plugin index %s: %s", u.Redacted(), err)
+	}
+
+	if g.indexes == nil {
+		g.indexes = map[string]*index{}
+	}
+	g.indexes[id] = idx
+	return idx, nil
+}
+
+// file returns the content of the file named name of the release of
+// version.
+func (g *Getter) file(idx *index, version, name string) (io.ReadCloser, error) {
+	for _, r := range idx.Releases {
+		if r.Version != version {
+			continue
+		}
+		fileURL, found := r.Files[name]
+		if !found {
+			return nil, fmt.Errorf("no %s file found in release %s of plugin index %s", name, version, idx.url.Redacted())
+		}
+		u, err := idx.url.Parse(fileURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid URL %q for %s: %s", fileURL, name, err)
+		}
+		resp, err := g.do(u)
+		if err != nil {
+			return nil, err
+		}
+		return resp.Body, nil
+	}
+	return nil, fmt.Errorf("no release %s found in plugin index %s", version, idx.url.Redacted())
+}
+
+func (g *Getter) do(u *url.URL) (*http.Response, error) {
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", defaultUserAgent)
+	if g.UserAgent != "" {
+		req.Header.Set("User-Agent", g.UserAgent)
+	}
+
+	client := g.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	log.Printf("[DEBUG] http-index-getter: getting %q", u.Redacted())
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("GET %s: unexpected status %s", u.Redacted(), resp.Status)
+	}
+	return resp, nil
+}
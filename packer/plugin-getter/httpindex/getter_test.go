@@ -0,0 +1,113 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package httpindex
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/packer/hcl2template/addrs"
+	plugingetter "github.com/hashicorp/packer/packer/plugin-getter"
+)
+
+func TestGetter_install(t *testing.T) {
+	binary := "packer-plugin-amazon_v1.2.5_x5.0_linux_amd64"
+	buf := &bytes.Buffer{}
+	zw := zip.NewWriter(buf)
+	w, err := zw.Create(binary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("v1.2.5")); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(buf.Bytes())
+
+	var indexRequests int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/plugins/infra/amazon/index.json", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&indexRequests, 1)
+		fmt.Fprintf(w, `{"releases": [
+  {"version": "v1.2.4", "files": {}},
+  {"version": "v1.2.5", "files": {
+    "packer-plugin-amazon_v1.2.5_SHA256SUMS": "v1.2.5/SHA256SUMS",
+    %q: "/cdn/amazon.zip"
+  }}
+]}`, binary+".zip")
+	})
+	mux.HandleFunc("/plugins/infra/amazon/v1.2.5/SHA256SUMS", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s  %s.zip\n", hex.EncodeToString(sum[:]), binary)
+	})
+	mux.HandleFunc("/cdn/amazon.zip", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(buf.Bytes())
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	identifier, diags := addrs.ParsePluginSourceString("plugins.example.com/infra/amazon")
+	if len(diags) != 0 {
+		t.Fatal(diags)
+	}
+	pr := &plugingetter.Requirement{
+		Identifier:         identifier,
+		VersionConstraints: version.MustConstraints(version.NewConstraint(">= v1")),
+	}
+	install, err := pr.InstallLatest(plugingetter.InstallOptions{
+		Getters:   []plugingetter.Getter{&Getter{BaseURL: server.URL + "/plugins"}},
+		InFolders: []string{t.TempDir()},
+		BinaryInstallationOptions: plugingetter.BinaryInstallationOptions{
+			APIVersionMajor: "5", APIVersionMinor: "0",
+			OS: "linux", ARCH: "amd64",
+			Checksummers: []plugingetter.Checksummer{
+				{Type: "sha256", Hash: sha256.New()},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("InstallLatest: %s", err)
+	}
+	if install == nil || install.Version != "v1.2.5" {
+		t.Fatalf("Expected v1.2.5 to be installed, got %v", install)
+	}
+	content, err := os.ReadFile(install.BinaryPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "v1.2.5" {
+		t.Errorf("unexpected binary content %q", content)
+	}
+	if n := atomic.LoadInt32(&indexRequests); n != 1 {
+		t.Errorf("Expected the index to be fetched once, got %d requests", n)
+	}
+}
+
+func TestGetter_missingIndex(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	identifier, diags := addrs.ParsePluginSourceString("plugins.example.com/infra/amazon")
+	if len(diags) != 0 {
+		t.Fatal(diags)
+	}
+	opts := plugingetter.GetOptions{
+		PluginRequirement: &plugingetter.Requirement{Identifier: identifier},
+	}
+	_, err := (&Getter{BaseURL: server.URL}).Get("releases", opts)
+	if err == nil || !strings.Contains(err.Error(), "404") {
+		t.Fatalf("Expected a not found error, got %v", err)
+	}
+}
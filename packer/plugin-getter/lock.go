@@ -0,0 +1,343 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// LockFileName is the name of the file, written next to the template, that
+// records the plugin versions selected by `packer init`.
+const LockFileName = ".packer.lock.hcl"
+
+// zipChecksumFileExt is the extension of the file, written next to an
+// installed binary, holding the SHA256 checksum of the zip file of the release
+// the binary was extracted from.
+const zipChecksumFileExt = "_ZIP_SHA256SUM"
+
+const lockFileHeader = `# This file is maintained automatically by "packer init".
+# Manual edits may be lost in future updates.
+`
+
+// Lock records, for each required plugin, the version selected by `packer
+// init` and the checksums of its release, so that every machine running the
+// template installs and uses the very same binaries.
+type Lock struct {
+	Plugins []*LockedPlugin `hcl:"plugin,block"`
+
+	path   string
+	exists bool
+}
+
+// LockedPlugin is the lock of a single plugin.
+type LockedPlugin struct {
+	// Identifier of the plugin, like github.com/hashicorp/amazon.
+	Identifier string `hcl:"identifier,label"`
+	// Version selected, like v1.2.3.
+	Version string `hcl:"version"`
+	// Constraints the version was selected with.
+	Constraints string `hcl:"constraints,optional"`
+	// ZipSHA256 are the checksums of the zip files of the release, for every
+	// platform, by file name, as listed in the checksum file of the release.
+	ZipSHA256 map[string]string `hcl:"zip_sha256,optional"`
+	// BinarySHA256 are the checksums of the installed binaries, by file name,
+	// for the platforms `packer init` was run on.
+	BinarySHA256 map[string]string `hcl:"binary_sha256,optional"`
+}
+
+// LoadLock reads the lock file in dir. An empty lock is returned if the file
+// does not exist yet.
+func LoadLock(dir string) (*Lock, error) {
+	l := &Lock{
+		path: filepath.Join(dir, LockFileName),
+	}
+
+	if _, err := os.Stat(l.path); os.IsNotExist(err) {
+		return l, nil
+	}
+
+	f, diags := hclparse.NewParser().ParseHCLFile(l.path)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("failed to read plugin lock file: %s", diags)
+	}
+	if diags := gohcl.DecodeBody(f.Body, nil, l); diags.HasErrors() {
+		return nil, fmt.Errorf("failed to decode plugin lock file %s: %s", l.path, diags)
+	}
+	l.exists = true
+	return l, nil
+}
+
+// Path returns the path of the lock file.
+func (l *Lock) Path() string {
+	return l.path
+}
+
+// Exists says whether the lock file was found when the lock was loaded.
+func (l *Lock) Exists() bool {
+	return l.exists
+}
+
+// Plugin returns the lock of the plugin with the given identifier, or nil if
+// it is not locked.
+func (l *Lock) Plugin(identifier string) *LockedPlugin {
+	for _, p := range l.Plugins {
+		if p.Identifier == identifier {
+			return p
+		}
+	}
+	return nil
+}
+
+// SetPlugin adds or replaces the lock of a plugin.
+func (l *Lock) SetPlugin(locked *LockedPlugin) {
+	for i, p := range l.Plugins {
+		if p.Identifier == locked.Identifier {
+			l.Plugins[i] = locked
+			return
+		}
+	}
+	l.Plugins = append(l.Plugins, locked)
+}
+
+// Retain removes the plugins that are not in identifiers.
+func (l *Lock) Retain(identifiers []string) {
+	keep := map[string]bool{}
+	for _, id := range identifiers {
+		keep[id] = true
+	}
+	plugins := l.Plugins[:0]
+	for _, p := range l.Plugins {
+		if keep[p.Identifier] {
+			plugins = append(plugins, p)
+		}
+	}
+	l.Plugins = plugins
+}
+
+// Save writes the lock file, plugins and checksums are sorted so that the
+// file only changes when the lock does.
+func (l *Lock) Save() error {
+	sort.Slice(l.Plugins, func(i, j int) bool {
+		return l.Plugins[i].Identifier < l.Plugins[j].Identifier
+	})
+
+	f := hclwrite.NewEmptyFile()
+	body := f.Body()
+	for _, p := range l.Plugins {
+		body.AppendNewline()
+		block := body.AppendNewBlock("plugin", []string{p.Identifier}).Body()
+		block.SetAttributeValue("version", cty.StringVal(p.Version))
+		if p.Constraints != "" {
+			block.SetAttributeValue("constraints", cty.StringVal(p.Constraints))
+		}
+		if len(p.ZipSHA256) > 0 {
+			block.SetAttributeValue("zip_sha256", checksumsValue(p.ZipSHA256))
+		}
+		if len(p.BinarySHA256) > 0 {
+			block.SetAttributeValue("binary_sha256", checksumsValue(p.BinarySHA256))
+		}
+	}
+
+	content := append([]byte(lockFileHeader), hclwrite.Format(f.Bytes())...)
+	tmp := l.path + ".tmp"
+	if err := os.WriteFile(tmp, content, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, l.path); err != nil {
+		return err
+	}
+	l.exists = true
+	return nil
+}
+
+func checksumsValue(checksums map[string]string) cty.Value {
+	vals := map[string]cty.Value{}
+	for name, checksum := range checksums {
+		vals[name] = cty.StringVal(checksum)
+	}
+	return cty.MapVal(vals)
+}
+
+// CheckConstraints returns an error if the locked version does not match
+// constraints, which happens when they were changed after the lock was
+// written.
+func (p *LockedPlugin) CheckConstraints(constraints version.Constraints) error {
+	v, err := version.NewVersion(p.Version)
+	if err != nil {
+		return fmt.Errorf("invalid locked version %q: %s", p.Version, err)
+	}
+	if !constraints.Check(v) {
+		return fmt.Errorf("locked version %s does not match the %q constraints", p.Version, constraints)
+	}
+	return nil
+}
+
+// SelectInstallation returns the installation of the locked version, after
+// checking that its binary matches the locked checksum.
+//
+// The checksums of the binaries are only known for the platforms `packer
+// init` was run on. On the other platforms, the binary must have been
+// extracted from a zip file of the locked release, whose checksums are locked
+// for every platform.
+//
+// The binary is hashed every time a template is loaded, which is what makes
+// the check worth anything: a binary replaced after it was installed is
+// caught. Hashing a plugin binary takes a few tens of milliseconds.
+func (p *LockedPlugin) SelectInstallation(installs InstallList) (*Installation, error) {
+	if p.Version == "" {
+		return nil, fmt.Errorf("no version of %s is locked", p.Identifier)
+	}
+	locked, err := version.NewVersion(p.Version)
+	if err != nil {
+		return nil, fmt.Errorf("invalid locked version %q: %s", p.Version, err)
+	}
+
+	var install *Installation
+	var found []string
+	for _, i := range installs {
+		found = append(found, i.Version)
+		if v, err := version.NewVersion(i.Version); err == nil && v.Equal(locked) {
+			install = i
+		}
+	}
+	if install == nil {
+		if len(found) == 0 {
+			return nil, fmt.Errorf("locked version %s of %s is not installed", p.Version, p.Identifier)
+		}
+		return nil, fmt.Errorf("locked version %s of %s is not installed, found %s",
+			p.Version, p.Identifier, strings.Join(found, ", "))
+	}
+
+	expected, ok := p.BinarySHA256[filepath.Base(install.BinaryPath)]
+	if !ok {
+		if err := p.checkReleaseInstallation(install); err != nil {
+			return nil, err
+		}
+		return install, nil
+	}
+	actual, err := fileSHA256(install.BinaryPath)
+	if err != nil {
+		return nil, err
+	}
+	if actual != expected {
+		return nil, fmt.Errorf("checksum of %s does not match the locked one.\nExpected: %s\nGot     : %s",
+			install.BinaryPath, expected, actual)
+	}
+	return install, nil
+}
+
+// checkReleaseInstallation checks that the binary of install was extracted
+// from the zip file of the locked release for its platform, and did not
+// change since: the checksum of the zip file recorded when it was installed
+// must be the locked one, and the binary must match the checksum recorded
+// next to it.
+func (p *LockedPlugin) checkReleaseInstallation(install *Installation) error {
+	name := filepath.Base(install.BinaryPath)
+	zipName := strings.TrimSuffix(name, ".exe") + ".zip"
+	expected, ok := p.ZipSHA256[zipName]
+	if !ok {
+		return fmt.Errorf("no checksum of %s is locked", name)
+	}
+
+	zipChecksum, err := os.ReadFile(install.BinaryPath + zipChecksumFileExt)
+	if err != nil {
+		return fmt.Errorf("no checksum of %s is locked and it was not installed from a release; "+
+			"run packer init to install it again: %s", name, err)
+	}
+	if actual := strings.TrimSpace(string(zipChecksum)); !strings.EqualFold(actual, expected) {
+		return fmt.Errorf("%s was not installed from the locked release, checksum of %s does not match the locked one.\nExpected: %s\nGot     : %s",
+			install.BinaryPath, zipName, expected, actual)
+	}
+
+	binaryChecksum, err := os.ReadFile(install.BinaryPath + "_SHA256SUM")
+	if err != nil {
+		return fmt.Errorf("failed to read the checksum of %s: %s", install.BinaryPath, err)
+	}
+	actual, err := fileSHA256(install.BinaryPath)
+	if err != nil {
+		return err
+	}
+	if expected := strings.TrimSpace(string(binaryChecksum)); !strings.EqualFold(actual, expected) {
+		return fmt.Errorf("%s changed since it was installed.\nExpected: %s\nGot     : %s",
+			install.BinaryPath, expected, actual)
+	}
+	return nil
+}
+
+// LockInstallation locks a plugin that is already installed. Since its
+// release is not fetched, only the checksum of its binary is recorded; the
+// checksums of the release are recorded when it is installed again.
+func (p *LockedPlugin) LockInstallation(install *Installation) error {
+	v, err := version.NewVersion(install.Version)
+	if err != nil {
+		return fmt.Errorf("invalid version %q for %s: %s", install.Version, install.BinaryPath, err)
+	}
+	return p.recordInstallation(v, install.BinaryPath)
+}
+
+// recordRelease records the checksums of the release of version, or checks
+// them against the ones already recorded for it.
+func (p *LockedPlugin) recordRelease(v *version.Version, entries []ChecksumFileEntry) error {
+	if p.Version != "" {
+		if locked, err := version.NewVersion(p.Version); err == nil && locked.Equal(v) && len(p.ZipSHA256) > 0 {
+			for _, entry := range entries {
+				expected, ok := p.ZipSHA256[entry.Filename]
+				if ok && !strings.EqualFold(expected, entry.Checksum) {
+					return fmt.Errorf("checksum of %s does not match the locked one.\nExpected: %s\nGot     : %s",
+						entry.Filename, expected, entry.Checksum)
+				}
+			}
+			return nil
+		}
+	}
+
+	p.ZipSHA256 = map[string]string{}
+	for _, entry := range entries {
+		p.ZipSHA256[entry.Filename] = strings.ToLower(entry.Checksum)
+	}
+	return nil
+}
+
+// recordInstallation records the version and the checksum of the binary
+// that was installed.
+func (p *LockedPlugin) recordInstallation(v *version.Version, binaryPath string) error {
+	checksum, err := fileSHA256(binaryPath)
+	if err != nil {
+		return err
+	}
+	version := "v" + v.String()
+	if p.Version != version || p.BinarySHA256 == nil {
+		p.BinarySHA256 = map[string]string{}
+	}
+	p.Version = version
+	p.BinarySHA256[filepath.Base(binaryPath)] = checksum
+	return nil
+}
+
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("failed to hash %s: %s", path, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,275 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/packer/hcl2template/addrs"
+)
+
+func TestLock_SaveLoad(t *testing.T) {
+	dir := t.TempDir()
+
+	lock, err := LoadLock(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lock.Exists() {
+		t.Fatal("Expected the lock file not to exist yet")
+	}
+
+	lock.SetPlugin(&LockedPlugin{
+		Identifier:  "github.com/hashicorp/google",
+		Version:     "v4.5.6",
+		Constraints: ">= 4.0.0",
+		ZipSHA256: map[string]string{
+			"packer-plugin-google_v4.5.6_x5.0_darwin_amd64.zip": "1337",
+			"packer-plugin-google_v4.5.6_x5.0_linux_amd64.zip":  "c0ffee",
+		},
+		BinarySHA256: map[string]string{
+			"packer-plugin-google_v4.5.6_x5.0_darwin_amd64": "beef",
+		},
+	})
+	lock.SetPlugin(&LockedPlugin{
+		Identifier: "github.com/hashicorp/amazon",
+		Version:    "v1.2.3",
+	})
+	lock.SetPlugin(&LockedPlugin{
+		Identifier: "github.com/hashicorp/azure",
+		Version:    "v1.0.0",
+	})
+	lock.Retain([]string{"github.com/hashicorp/google", "github.com/hashicorp/amazon"})
+	if err := lock.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadLock(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !loaded.Exists() {
+		t.Fatal("Expected the lock file to exist")
+	}
+	if diff := cmp.Diff(lock.Plugins, loaded.Plugins, cmpopts.EquateEmpty()); diff != "" {
+		t.Errorf("unexpected lock after a round trip: %s", diff)
+	}
+	if loaded.Plugins[0].Identifier != "github.com/hashicorp/amazon" {
+		t.Errorf("Expected plugins to be sorted, got %s first", loaded.Plugins[0].Identifier)
+	}
+}
+
+func TestLockedPlugin_SelectInstallation(t *testing.T) {
+	binary := filepath.Join(pluginFolderTwo, "github.com", "hashicorp", "amazon", "packer-plugin-amazon_v1.2.6_x5.1_darwin_amd64")
+	checksum, err := fileSHA256(binary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	installs := InstallList{
+		{Version: "v1.2.6", BinaryPath: binary},
+	}
+
+	tests := []struct {
+		name    string
+		locked  LockedPlugin
+		wantErr bool
+	}{
+		{"match", LockedPlugin{
+			Version:      "v1.2.6",
+			BinarySHA256: map[string]string{filepath.Base(binary): checksum},
+		}, false},
+		{"checksum-mismatch", LockedPlugin{
+			Version:      "v1.2.6",
+			BinarySHA256: map[string]string{filepath.Base(binary): "1337"},
+		}, true},
+		{"no-checksum", LockedPlugin{
+			Version: "v1.2.6",
+		}, true},
+		{"version-not-installed", LockedPlugin{
+			Version:      "v1.2.5",
+			BinarySHA256: map[string]string{filepath.Base(binary): checksum},
+		}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.locked.Identifier = "github.com/hashicorp/amazon"
+			got, err := tt.locked.SelectInstallation(installs)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SelectInstallation() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != installs[0] {
+				t.Errorf("SelectInstallation() = %v, want %v", got, installs[0])
+			}
+		})
+	}
+}
+
+func TestLockedPlugin_SelectInstallation_otherPlatform(t *testing.T) {
+	// The lock was written on another platform, so it only has the checksum
+	// of the zip file of the release for this one.
+	binaryName := "packer-plugin-amazon_v1.2.6_x5.1_windows_amd64.exe"
+	zipName := "packer-plugin-amazon_v1.2.6_x5.1_windows_amd64.zip"
+	binarySum := sha256.Sum256([]byte("v1.2.6_x5.1_windows_amd64"))
+	binaryChecksum := hex.EncodeToString(binarySum[:])
+	locked := LockedPlugin{
+		Identifier: "github.com/hashicorp/amazon",
+		Version:    "v1.2.6",
+		ZipSHA256: map[string]string{
+			zipName: "abcd",
+			"packer-plugin-amazon_v1.2.6_x5.1_linux_amd64.zip": "ef01",
+		},
+		BinarySHA256: map[string]string{
+			"packer-plugin-amazon_v1.2.6_x5.1_linux_amd64": "2345",
+		},
+	}
+
+	tests := []struct {
+		name           string
+		binary         string
+		binaryChecksum string
+		zipChecksum    string
+		wantErr        bool
+	}{
+		{"installed-from-release", "v1.2.6_x5.1_windows_amd64", binaryChecksum, "ABCD", false},
+		{"installed-from-other-release", "v1.2.6_x5.1_windows_amd64", binaryChecksum, "1337", true},
+		{"not-installed-from-release", "v1.2.6_x5.1_windows_amd64", binaryChecksum, "", true},
+		{"binary-changed", "tampered", binaryChecksum, "abcd", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			binary := filepath.Join(t.TempDir(), binaryName)
+			files := map[string]string{
+				binary:                    tt.binary,
+				binary + "_SHA256SUM":     tt.binaryChecksum,
+				binary + "_ZIP_SHA256SUM": tt.zipChecksum,
+			}
+			for path, content := range files {
+				if content == "" {
+					continue
+				}
+				if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			installs := InstallList{{Version: "v1.2.6", BinaryPath: binary}}
+			got, err := locked.SelectInstallation(installs)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SelectInstallation() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != installs[0] {
+				t.Errorf("SelectInstallation() = %v, want %v", got, installs[0])
+			}
+		})
+	}
+}
+
+func TestRequirement_InstallLocked(t *testing.T) {
+	zipName := "packer-plugin-amazon_v2.0.0_x5.0_darwin_amd64.zip"
+	binaryName := "packer-plugin-amazon_v2.0.0_x5.0_darwin_amd64"
+	zip, err := io.ReadAll(zipFile(map[string]string{binaryName: "v2.0.0_x5.0_darwin_amd64"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	zipSum := sha256.Sum256(zip)
+	zipChecksum := hex.EncodeToString(zipSum[:])
+	binarySum := sha256.Sum256([]byte("v2.0.0_x5.0_darwin_amd64"))
+
+	tests := []struct {
+		name    string
+		locked  LockedPlugin
+		want    LockedPlugin
+		wantErr bool
+	}{
+		{"record-locked-version",
+			LockedPlugin{Version: "v2.0.0"},
+			LockedPlugin{
+				Version:      "v2.0.0",
+				ZipSHA256:    map[string]string{zipName: zipChecksum},
+				BinarySHA256: map[string]string{binaryName: hex.EncodeToString(binarySum[:])},
+			},
+			false},
+		{"zip-checksum-mismatch",
+			LockedPlugin{
+				Version:   "v2.0.0",
+				ZipSHA256: map[string]string{zipName: "1337"},
+			},
+			LockedPlugin{},
+			true},
+		{"locked-version-not-released",
+			LockedPlugin{Version: "v1.9.0"},
+			LockedPlugin{},
+			true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			identifier, diags := addrs.ParsePluginSourceString("github.com/hashicorp/amazon")
+			if len(diags) != 0 {
+				t.Fatal(diags)
+			}
+			pr := &Requirement{
+				Identifier:         identifier,
+				VersionConstraints: version.MustConstraints(version.NewConstraint(">= v1")),
+			}
+			getter := &mockPluginGetter{
+				Releases: []Release{
+					{Version: "v2.0.0"},
+					{Version: "v2.1.0"},
+				},
+				ChecksumFileEntries: map[string][]ChecksumFileEntry{
+					"2.0.0": {{Filename: zipName, Checksum: zipChecksum}},
+					"2.1.0": {{Filename: "packer-plugin-amazon_v2.1.0_x5.0_darwin_amd64.zip", Checksum: zipChecksum}},
+				},
+				Zips: map[string]io.ReadCloser{
+					"github.com/hashicorp/packer-plugin-amazon/" + zipName: io.NopCloser(bytes.NewReader(zip)),
+				},
+			}
+			dir := t.TempDir()
+
+			locked := tt.locked
+			got, err := pr.InstallLocked(InstallOptions{
+				[]Getter{getter},
+				[]string{dir},
+				BinaryInstallationOptions{
+					APIVersionMajor: "5", APIVersionMinor: "0",
+					OS: "darwin", ARCH: "amd64",
+					Checksummers: []Checksummer{
+						{
+							Type: "sha256",
+							Hash: sha256.New(),
+						},
+					},
+				},
+			}, &locked)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Requirement.InstallLocked() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if _, err := os.Stat(filepath.Join(dir, "github.com", "hashicorp", "amazon", binaryName)); err == nil {
+					t.Fatal("Expected the plugin not to be installed")
+				}
+				return
+			}
+			if got == nil || got.Version != "v2.0.0" {
+				t.Fatalf("Expected the locked version to be installed, got %v", got)
+			}
+			if diff := cmp.Diff(tt.want, locked); diff != "" {
+				t.Errorf("unexpected lock: %s", diff)
+			}
+			recorded, err := os.ReadFile(filepath.Join(dir, "github.com", "hashicorp", "amazon", binaryName+"_ZIP_SHA256SUM"))
+			if err != nil || string(recorded) != zipChecksum {
+				t.Errorf("expected the checksum of the zip file to be recorded, got %q: %v", recorded, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,315 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/go-version"
+)
+
+// A mirror serves the files of plugin releases with the following layout,
+// relative to its root:
+//
+//	github.com/hashicorp/amazon/releases.json
+//	github.com/hashicorp/amazon/v1.2.3/packer-plugin-amazon_v1.2.3_SHA256SUMS
+//	github.com/hashicorp/amazon/v1.2.3/packer-plugin-amazon_v1.2.3_x5.0_linux_amd64.zip
+//
+// releases.json is the JSON list of Release, and the SHA256SUMS file is the
+// checksum file published with the release.
+const mirrorReleasesFilename = "releases.json"
+
+// MirrorPath returns the slash separated path, relative to the root of a
+// mirror, of the file a Getter must return for what.
+func (gp *GetOptions) MirrorPath(what string) (string, error) {
+	pluginPath := path.Join(gp.PluginRequirement.Identifier.Parts()...)
+	switch what {
+	case "releases":
+		return path.Join(pluginPath, mirrorReleasesFilename), nil
+	case "sha256":
+		return path.Join(pluginPath, gp.Version(), gp.PluginRequirement.FilenamePrefix()+gp.Version()+"_SHA256SUMS"), nil
+	case "zip":
+		return path.Join(pluginPath, gp.Version(), gp.ExpectedZipFilename()), nil
+	}
+	return "", fmt.Errorf("%q not implemented", what)
+}
+
+// MirrorOptions describes how to mirror the release of a plugin.
+type MirrorOptions struct {
+	// Different means to get releases, sha256 and zip files.
+	Getters []Getter
+
+	// Dir is the root of the mirror.
+	Dir string
+
+	// Platforms are the systems, and protocol versions, for which binaries
+	// are mirrored.
+	Platforms []BinaryInstallationOptions
+
+	// Version to mirror. When nil, the highest version matching the
+	// requirement is mirrored.
+	Version *version.Version
+}
+
+// MirroredRelease is a plugin release that was copied in a mirror.
+type MirroredRelease struct {
+	// Version of the release, like v1.2.3.
+	Version string
+	// Zips are the paths of the zip files of the release in the mirror.
+	Zips []string
+}
+
+// Mirror copies the release of the plugin matching the requirement in a
+// mirror directory, for every platform of opts. Zip files already in the
+// mirror are kept when they match their checksum.
+func (pr *Requirement) Mirror(opts MirrorOptions) (*MirroredRelease, error) {
+	if len(opts.Platforms) == 0 {
+		return nil, fmt.Errorf("no platform to mirror %s for", pr.Identifier)
+	}
+
+	var errs *multierror.Error
+	var releases []Release
+	for _, getter := range opts.Getters {
+		releasesFile, err := getter.Get("releases", GetOptions{
+			PluginRequirement:         pr,
+			BinaryInstallationOptions: opts.Platforms[0],
+		})
+		if err != nil {
+			errs = multierror.Append(errs, err)
+			log.Printf("[TRACE] %s", err.Error())
+			continue
+		}
+		releases, err = ParseReleases(releasesFile)
+		if err != nil {
+			err := fmt.Errorf("could not parse release: %w", err)
+			errs = multierror.Append(errs, err)
+			log.Printf("[TRACE] %s", err.Error())
+			continue
+		}
+		break
+	}
+
+	var selected *version.Version
+	for _, release := range releases {
+		v, err := version.NewVersion(release.Version)
+		if err != nil {
+			log.Printf("[TRACE] could not parse release version %s, ignoring it: %s", release.Version, err)
+			continue
+		}
+		if opts.Version != nil && !v.Equal(opts.Version) {
+			continue
+		}
+		if pr.VersionConstraints.Check(v) && (selected == nil || v.GreaterThan(selected)) {
+			selected = v
+		}
+	}
+	if selected == nil {
+		if opts.Version != nil {
+			errs = multierror.Append(errs, fmt.Errorf("version %s of %s not found in releases", opts.Version, pr.Identifier))
+		} else {
+			errs = multierror.Append(errs, fmt.Errorf("no release version found for constraints: %q", pr.VersionConstraints.String()))
+		}
+		return nil, errs
+	}
+
+	checksummer := Checksummer{Type: "sha256", Hash: sha256.New()}
+	var entries []ChecksumFileEntry
+	var getter Getter
+	for _, g := range opts.Getters {
+		checksumFile, err := g.Get(checksummer.Type, GetOptions{
+			PluginRequirement:         pr,
+			BinaryInstallationOptions: opts.Platforms[0],
+			version:                   selected,
+		})
+		if err != nil {
+			err := fmt.Errorf("could not get %s checksum file for %s version %s: %w", checksummer.Type, pr.Identifier, selected, err)
+			errs = multierror.Append(errs, err)
+			log.Printf("[TRACE] %s", err)
+			continue
+		}
+		entries, err = ParseChecksumFileEntries(checksumFile)
+		_ = checksumFile.Close()
+		if err != nil {
+			err := fmt.Errorf("could not parse %s checksumfile: %v", checksummer.Type, err)
+			errs = multierror.Append(errs, err)
+			log.Printf("[TRACE] %s", err)
+			continue
+		}
+		getter = g
+		break
+	}
+	if getter == nil {
+		return nil, errs
+	}
+
+	mirrored := &MirroredRelease{Version: "v" + selected.String()}
+	getOpts := GetOptions{PluginRequirement: pr, version: selected}
+
+	for _, platform := range opts.Platforms {
+		var entry *ChecksumFileEntry
+		for i := range entries {
+			e := entries[i]
+			if err := e.init(pr); err != nil {
+				continue
+			}
+			if err := e.validate(mirrored.Version, platform); err != nil {
+				continue
+			}
+			entry = &e
+			break
+		}
+		if entry == nil {
+			errs = multierror.Append(errs, fmt.Errorf("no compatible %s_%s binary found in release %s of %s",
+				platform.OS, platform.ARCH, mirrored.Version, pr.Identifier))
+			continue
+		}
+
+		getOpts.BinaryInstallationOptions = platform
+		getOpts.expectedZipFilename = entry.Filename
+		zipPath, err := getOpts.MirrorPath("zip")
+		if err != nil {
+			return nil, err
+		}
+		outputFileName := filepath.Join(opts.Dir, filepath.FromSlash(zipPath))
+
+		expected, err := checksummer.ParseChecksum(strings.NewReader(entry.Checksum))
+		if err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("could not parse %s checksum of %s: %s", checksummer.Type, entry.Filename, err))
+			continue
+		}
+		if err := checksummer.ChecksumFile(expected, outputFileName); err == nil {
+			log.Printf("[INFO] %s is already mirrored in %q", entry.Filename, outputFileName)
+			mirrored.Zips = append(mirrored.Zips, outputFileName)
+			continue
+		}
+
+		zip, err := getter.Get("zip", getOpts)
+		if err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("could not get %s: %s", entry.Filename, err))
+			continue
+		}
+		err = writeMirrorFile(outputFileName, zip, func(f *os.File) error {
+			return checksummer.Checksum(expected, f)
+		})
+		_ = zip.Close()
+		if err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("could not mirror %s: %w", entry.Filename, err))
+			continue
+		}
+		mirrored.Zips = append(mirrored.Zips, outputFileName)
+	}
+
+	if len(mirrored.Zips) == 0 {
+		return nil, errs
+	}
+
+	// The checksum file lists every binary of the release, like the one
+	// published with it, even though only some of them are mirrored.
+	sums := &bytes.Buffer{}
+	for _, entry := range entries {
+		fmt.Fprintf(sums, "%s  %s\n", entry.Checksum, entry.Filename)
+	}
+	sumsPath, err := getOpts.MirrorPath("sha256")
+	if err != nil {
+		return nil, err
+	}
+	if err := writeMirrorFile(filepath.Join(opts.Dir, filepath.FromSlash(sumsPath)), sums, nil); err != nil {
+		errs = multierror.Append(errs, err)
+		return mirrored, errs
+	}
+
+	if err := pr.addMirroredRelease(opts.Dir, mirrored.Version); err != nil {
+		errs = multierror.Append(errs, err)
+		return mirrored, errs
+	}
+
+	if len(mirrored.Zips) < len(opts.Platforms) {
+		return mirrored, errs
+	}
+	return mirrored, nil
+}
+
+// addMirroredRelease adds version to the list of releases of the plugin in
+// the mirror.
+func (pr *Requirement) addMirroredRelease(dir, v string) error {
+	getOpts := GetOptions{PluginRequirement: pr}
+	releasesPath, err := getOpts.MirrorPath("releases")
+	if err != nil {
+		return err
+	}
+	releasesPath = filepath.Join(dir, filepath.FromSlash(releasesPath))
+
+	var releases []Release
+	if f, err := os.Open(releasesPath); err == nil {
+		releases, err = ParseReleases(f)
+		if err != nil {
+			return fmt.Errorf("could not parse %s: %w", releasesPath, err)
+		}
+	}
+
+	for _, release := range releases {
+		if release.Version == v {
+			return nil
+		}
+	}
+	releases = append(releases, Release{Version: v})
+	sort.Slice(releases, func(i, j int) bool {
+		vi, erri := version.NewVersion(releases[i].Version)
+		vj, errj := version.NewVersion(releases[j].Version)
+		if erri != nil || errj != nil {
+			return releases[i].Version < releases[j].Version
+		}
+		return vi.LessThan(vj)
+	})
+
+	content, err := json.MarshalIndent(releases, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeMirrorFile(releasesPath, bytes.NewReader(append(content, '\n')), nil)
+}
+
+// writeMirrorFile writes the content of r to name, through a temporary file
+// that is only renamed to name once check succeeded.
+func writeMirrorFile(name string, r io.Reader, check func(*os.File) error) error {
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return fmt.Errorf("could not create mirror folder: %w", err)
+	}
+	f, err := os.CreateTemp(filepath.Dir(name), "."+filepath.Base(name)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	if _, err := io.Copy(f, r); err != nil {
+		return err
+	}
+	if check != nil {
+		if _, err := f.Seek(0, 0); err != nil {
+			return err
+		}
+		if err := check(f); err != nil {
+			return err
+		}
+	}
+	if err := f.Chmod(0644); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), name)
+}
@@ -0,0 +1,7 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+// Package mirror defines getters installing plugins from a mirror, that is a
+// local directory or an HTTP server, populated by `packer plugins mirror`.
+
+package mirror
@@ -0,0 +1,44 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package mirror
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+
+	plugingetter "github.com/hashicorp/packer/packer/plugin-getter"
+)
+
+// FilesystemGetter gets plugins from a mirror in a local directory.
+type FilesystemGetter struct {
+	// Path of the root of the mirror.
+	Path string
+}
+
+var _ plugingetter.Getter = &FilesystemGetter{}
+
+func (g *FilesystemGetter) Get(what string, opts plugingetter.GetOptions) (io.ReadCloser, error) {
+	p, err := opts.MirrorPath(what)
+	if err != nil {
+		return nil, err
+	}
+	p = filepath.Join(g.Path, filepath.FromSlash(p))
+
+	log.Printf("[DEBUG] filesystem-mirror-getter: getting %q", p)
+	f, err := os.Open(p)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%s not found in filesystem mirror %s", filepath.Base(p), g.Path)
+		}
+		return nil, err
+	}
+
+	if what == "sha256" {
+		return plugingetter.TransformChecksumStream()(f)
+	}
+	return f, nil
+}
@@ -0,0 +1,130 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package mirror
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/packer/hcl2template/addrs"
+	plugingetter "github.com/hashicorp/packer/packer/plugin-getter"
+)
+
+func TestGetters_install(t *testing.T) {
+	mirrorDir := testMirror(t)
+	server := httptest.NewServer(http.FileServer(http.Dir(mirrorDir)))
+	defer server.Close()
+
+	tests := map[string]plugingetter.Getter{
+		"filesystem": &FilesystemGetter{Path: mirrorDir},
+		"network":    &NetworkGetter{URL: server.URL + "/"},
+	}
+	for name, getter := range tests {
+		t.Run(name, func(t *testing.T) {
+			identifier, diags := addrs.ParsePluginSourceString("github.com/hashicorp/amazon")
+			if len(diags) != 0 {
+				t.Fatal(diags)
+			}
+			pr := &plugingetter.Requirement{
+				Identifier:         identifier,
+				VersionConstraints: version.MustConstraints(version.NewConstraint(">= v1")),
+			}
+			installDir := t.TempDir()
+			install, err := pr.InstallLatest(plugingetter.InstallOptions{
+				Getters:   []plugingetter.Getter{getter},
+				InFolders: []string{installDir},
+				BinaryInstallationOptions: plugingetter.BinaryInstallationOptions{
+					APIVersionMajor: "5", APIVersionMinor: "0",
+					OS: "linux", ARCH: "amd64",
+					Checksummers: []plugingetter.Checksummer{
+						{Type: "sha256", Hash: sha256.New()},
+					},
+				},
+			})
+			if err != nil {
+				t.Fatalf("InstallLatest: %s", err)
+			}
+			if install == nil || install.Version != "v1.2.5" {
+				t.Fatalf("Expected v1.2.5 to be installed, got %v", install)
+			}
+			content, err := os.ReadFile(install.BinaryPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(content) != "v1.2.5" {
+				t.Errorf("unexpected binary content %q", content)
+			}
+		})
+	}
+}
+
+func TestGetters_notFound(t *testing.T) {
+	mirrorDir := t.TempDir()
+	server := httptest.NewServer(http.FileServer(http.Dir(mirrorDir)))
+	defer server.Close()
+
+	identifier, diags := addrs.ParsePluginSourceString("github.com/hashicorp/amazon")
+	if len(diags) != 0 {
+		t.Fatal(diags)
+	}
+	opts := plugingetter.GetOptions{
+		PluginRequirement: &plugingetter.Requirement{Identifier: identifier},
+	}
+	for _, getter := range []plugingetter.Getter{
+		&FilesystemGetter{Path: mirrorDir},
+		&NetworkGetter{URL: server.URL},
+	} {
+		if _, err := getter.Get("releases", opts); err == nil {
+			t.Errorf("%T: expected an error for a missing releases file", getter)
+		}
+	}
+}
+
+// testMirror writes a mirror with the v1.2.4 and v1.2.5 releases of the
+// amazon plugin.
+func testMirror(t *testing.T) string {
+	dir := t.TempDir()
+	pluginDir := filepath.Join(dir, "github.com", "hashicorp", "amazon")
+
+	writeFile := func(name string, content []byte) {
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(name, content, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	writeFile(filepath.Join(pluginDir, "releases.json"), []byte(`[{"version": "v1.2.4"}, {"version": "v1.2.5"}]`))
+	for _, v := range []string{"v1.2.4", "v1.2.5"} {
+		binary := fmt.Sprintf("packer-plugin-amazon_%s_x5.0_linux_amd64", v)
+		buf := &bytes.Buffer{}
+		zw := zip.NewWriter(buf)
+		w, err := zw.Create(binary)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(v)); err != nil {
+			t.Fatal(err)
+		}
+		if err := zw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		sum := sha256.Sum256(buf.Bytes())
+
+		writeFile(filepath.Join(pluginDir, v, binary+".zip"), buf.Bytes())
+		writeFile(filepath.Join(pluginDir, v, "packer-plugin-amazon_"+v+"_SHA256SUMS"),
+			[]byte(fmt.Sprintf("%s  %s.zip\n", hex.EncodeToString(sum[:]), binary)))
+	}
+	return dir
+}
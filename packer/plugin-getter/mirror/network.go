@@ -0,0 +1,72 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package mirror
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	plugingetter "github.com/hashicorp/packer/packer/plugin-getter"
+)
+
+const defaultUserAgent = "packer-network-mirror-plugin-getter"
+
+// NetworkGetter gets plugins from a mirror served over HTTP, with the same
+// layout as a filesystem mirror.
+type NetworkGetter struct {
+	// URL of the root of the mirror, like https://mirror.example.com/packer/.
+	URL string
+
+	// Client is the HTTP client used for requests, nil means we use the
+	// default one from http.
+	Client    *http.Client
+	UserAgent string
+}
+
+var _ plugingetter.Getter = &NetworkGetter{}
+
+func (g *NetworkGetter) Get(what string, opts plugingetter.GetOptions) (io.ReadCloser, error) {
+	p, err := opts.MirrorPath(what)
+	if err != nil {
+		return nil, err
+	}
+	base, err := url.Parse(strings.TrimSuffix(g.URL, "/") + "/")
+	if err != nil {
+		return nil, fmt.Errorf("invalid network mirror URL %q: %s", g.URL, err)
+	}
+	u := base.ResolveReference(&url.URL{Path: p})
+
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", defaultUserAgent)
+	if g.UserAgent != "" {
+		req.Header.Set("User-Agent", g.UserAgent)
+	}
+
+	client := g.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	log.Printf("[DEBUG] network-mirror-getter: getting %q", u)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("GET %s: unexpected status %s", u, resp.Status)
+	}
+
+	if what == "sha256" {
+		return plugingetter.TransformChecksumStream()(resp.Body)
+	}
+	return resp.Body, nil
+}
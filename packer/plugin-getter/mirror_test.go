@@ -0,0 +1,174 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/packer/hcl2template/addrs"
+)
+
+func TestRequirement_Mirror(t *testing.T) {
+	zips := map[string][]byte{}
+	var entries []ChecksumFileEntry
+	for _, platform := range []string{"darwin_amd64", "linux_amd64", "windows_amd64"} {
+		name := "packer-plugin-amazon_v1.2.5_x5.0_" + platform
+		zip, err := io.ReadAll(zipFile(map[string]string{name: platform}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		sum := sha256.Sum256(zip)
+		zips[name+".zip"] = zip
+		entries = append(entries, ChecksumFileEntry{Filename: name + ".zip", Checksum: hex.EncodeToString(sum[:])})
+	}
+
+	newGetter := func() *mockPluginGetter {
+		g := &mockPluginGetter{
+			Releases: []Release{
+				{Version: "v1.2.4"},
+				{Version: "v1.2.5"},
+				{Version: "v2.0.0"},
+			},
+			ChecksumFileEntries: map[string][]ChecksumFileEntry{
+				"1.2.5": entries,
+			},
+			Zips: map[string]io.ReadCloser{},
+		}
+		for name, zip := range zips {
+			g.Zips["github.com/hashicorp/packer-plugin-amazon/"+name] = io.NopCloser(bytes.NewReader(zip))
+		}
+		return g
+	}
+
+	identifier, diags := addrs.ParsePluginSourceString("github.com/hashicorp/amazon")
+	if len(diags) != 0 {
+		t.Fatal(diags)
+	}
+	pr := &Requirement{
+		Identifier:         identifier,
+		VersionConstraints: version.MustConstraints(version.NewConstraint("< v2")),
+	}
+	dir := t.TempDir()
+	pluginDir := filepath.Join(dir, "github.com", "hashicorp", "amazon")
+
+	mirrored, err := pr.Mirror(MirrorOptions{
+		Getters: []Getter{newGetter()},
+		Dir:     dir,
+		Platforms: []BinaryInstallationOptions{
+			{OS: "linux", ARCH: "amd64", APIVersionMajor: "5", APIVersionMinor: "0"},
+			{OS: "darwin", ARCH: "amd64", APIVersionMajor: "5", APIVersionMinor: "0"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Mirror: %s", err)
+	}
+	want := &MirroredRelease{
+		Version: "v1.2.5",
+		Zips: []string{
+			filepath.Join(pluginDir, "v1.2.5", "packer-plugin-amazon_v1.2.5_x5.0_linux_amd64.zip"),
+			filepath.Join(pluginDir, "v1.2.5", "packer-plugin-amazon_v1.2.5_x5.0_darwin_amd64.zip"),
+		},
+	}
+	if diff := cmp.Diff(want, mirrored); diff != "" {
+		t.Fatalf("unexpected mirrored release: %s", diff)
+	}
+	for _, zip := range mirrored.Zips {
+		content, err := os.ReadFile(zip)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(content, zips[filepath.Base(zip)]) {
+			t.Errorf("unexpected content for %s", zip)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(pluginDir, "v1.2.5", "packer-plugin-amazon_v1.2.5_x5.0_windows_amd64.zip")); err == nil {
+		t.Error("Expected windows binary not to be mirrored")
+	}
+
+	f, err := os.Open(filepath.Join(pluginDir, "v1.2.5", "packer-plugin-amazon_v1.2.5_SHA256SUMS"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sums, err := TransformChecksumStream()(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotEntries, err := ParseChecksumFileEntries(sums)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(entries, gotEntries, cmp.AllowUnexported(ChecksumFileEntry{})); diff != "" {
+		t.Errorf("unexpected checksum file: %s", diff)
+	}
+
+	// Mirroring another version keeps the previous one.
+	_, err = pr.Mirror(MirrorOptions{
+		Getters: []Getter{newGetter()},
+		Dir:     dir,
+		Platforms: []BinaryInstallationOptions{
+			{OS: "linux", ARCH: "amd64", APIVersionMajor: "5", APIVersionMinor: "0"},
+		},
+		Version: version.Must(version.NewVersion("v1.2.4")),
+	})
+	if err == nil {
+		t.Fatal("Expected mirroring a version without checksum file to fail")
+	}
+
+	f, err = os.Open(filepath.Join(pluginDir, "releases.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	releases, err := ParseReleases(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]Release{{Version: "v1.2.5"}}, releases); diff != "" {
+		t.Errorf("unexpected releases: %s", diff)
+	}
+}
+
+func TestRequirement_Mirror_checksumMismatch(t *testing.T) {
+	identifier, diags := addrs.ParsePluginSourceString("github.com/hashicorp/amazon")
+	if len(diags) != 0 {
+		t.Fatal(diags)
+	}
+	pr := &Requirement{Identifier: identifier}
+	dir := t.TempDir()
+
+	_, err := pr.Mirror(MirrorOptions{
+		Getters: []Getter{&mockPluginGetter{
+			Releases: []Release{{Version: "v1.2.5"}},
+			ChecksumFileEntries: map[string][]ChecksumFileEntry{
+				"1.2.5": {{
+					Filename: "packer-plugin-amazon_v1.2.5_x5.0_linux_amd64.zip",
+					Checksum: "133713371337133713371337c4a152edd277366a7f71ff3812583e4a35dd0d4a",
+				}},
+			},
+			Zips: map[string]io.ReadCloser{
+				"github.com/hashicorp/packer-plugin-amazon/packer-plugin-amazon_v1.2.5_x5.0_linux_amd64.zip": zipFile(map[string]string{
+					"packer-plugin-amazon_v1.2.5_x5.0_linux_amd64": "h4xx",
+				}),
+			},
+		}},
+		Dir: dir,
+		Platforms: []BinaryInstallationOptions{
+			{OS: "linux", ARCH: "amd64", APIVersionMajor: "5", APIVersionMinor: "0"},
+		},
+	})
+	if err == nil {
+		t.Fatal("Expected a zip with a wrong checksum not to be mirrored")
+	}
+	zip := filepath.Join(dir, "github.com", "hashicorp", "amazon", "v1.2.5", "packer-plugin-amazon_v1.2.5_x5.0_linux_amd64.zip")
+	if _, err := os.Stat(zip); err == nil {
+		t.Fatalf("Expected %s not to be written", zip)
+	}
+}
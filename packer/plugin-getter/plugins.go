@@ -5,6 +5,8 @@ package plugingetter
 
 import (
 	"archive/zip"
+	"bufio"
+	"bytes"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
@@ -421,10 +423,76 @@ func ParseChecksumFileEntries(f io.Reader) ([]ChecksumFileEntry, error) {
 	return entries, json.NewDecoder(f).Decode(&entries)
 }
 
+// TransformChecksumStream returns a function transforming a SHA256SUMS text
+// file, as published along with the releases of a plugin, into the JSON list
+// of ChecksumFileEntry Packer expects from a Getter.
+func TransformChecksumStream() func(in io.ReadCloser) (io.ReadCloser, error) {
+	return func(in io.ReadCloser) (io.ReadCloser, error) {
+		defer in.Close()
+		rd := bufio.NewReader(in)
+		buffer := bytes.NewBufferString("[")
+		json := json.NewEncoder(buffer)
+		for i := 0; ; i++ {
+			line, err := rd.ReadString('\n')
+			if err != nil {
+				if err != io.EOF {
+					return nil, fmt.Errorf(
+						"Error reading checksum file: %s", err)
+				}
+				break
+			}
+			parts := strings.Fields(line)
+			switch len(parts) {
+			case 2: // nominal case
+				checksumString, checksumFilename := parts[0], parts[1]
+
+				if i > 0 {
+					_, _ = buffer.WriteString(",")
+				}
+				if err := json.Encode(struct {
+					Checksum string `json:"checksum"`
+					Filename string `json:"filename"`
+				}{
+					Checksum: checksumString,
+					Filename: checksumFilename,
+				}); err != nil {
+					return nil, err
+				}
+			}
+		}
+		_, _ = buffer.WriteString("]")
+		return io.NopCloser(buffer), nil
+	}
+}
+
 func (pr *Requirement) InstallLatest(opts InstallOptions) (*Installation, error) {
+	return pr.install(opts, nil)
+}
+
+// InstallLocked installs the version of the plugin set in lock, or the latest
+// one if lock has no version yet, and records the selected version and its
+// checksums in lock. The release is rejected if its checksums do not match
+// the ones recorded in lock.
+//
+// As with InstallLatest, a nil Installation is returned when the plugin was
+// already installed.
+func (pr *Requirement) InstallLocked(opts InstallOptions, lock *LockedPlugin) (*Installation, error) {
+	return pr.install(opts, lock)
+}
+
+func (pr *Requirement) install(opts InstallOptions, lock *LockedPlugin) (*Installation, error) {
 
 	getters := opts.Getters
 
+	var lockedVersion *version.Version
+	if lock != nil && lock.Version != "" {
+		v, err := version.NewVersion(lock.Version)
+		if err != nil {
+			return nil, fmt.Errorf("invalid locked version %q for %s: %s", lock.Version, pr.Identifier, err)
+		}
+		lockedVersion = v
+	}
+
 	log.Printf("[TRACE] getting available versions for the %s plugin", pr.Identifier)
 	versions := version.Collection{}
 	var errs *multierror.Error
@@ -461,12 +529,18 @@ func (pr *Requirement) InstallLatest(opts InstallOptions) (*Installation, error)
 				log.Printf("[TRACE] %s, ignoring it", err.Error())
 				continue
 			}
+			if lockedVersion != nil && !v.Equal(lockedVersion) {
+				continue
+			}
 			if pr.VersionConstraints.Check(v) {
 				versions = append(versions, v)
 			}
 		}
 		if len(versions) == 0 {
 			err := fmt.Errorf("no matching version found in releases. In %v", releases)
+			if lockedVersion != nil {
+				err = fmt.Errorf("locked version %s not found in releases. In %v", lock.Version, releases)
+			}
 			errs = multierror.Append(errs, err)
 			log.Printf("[TRACE] %s", err.Error())
 			continue
@@ -530,6 +604,13 @@ func (pr *Requirement) InstallLatest(opts InstallOptions) (*Installation, error)
 					continue
 				}
 
+				if lock != nil && checksummer.Type == "sha256" {
+					if err := lock.recordRelease(version, entries); err != nil {
+						errs = multierror.Append(errs, err)
+						return nil, errs
+					}
+				}
+
 				for _, entry := range entries {
 					if err := entry.init(pr); err != nil {
 						err := fmt.Errorf("could not parse checksum filename %s. Is it correctly formatted ? %s", entry.Filename, err)
@@ -584,6 +665,12 @@ func (pr *Requirement) InstallLatest(opts InstallOptions) (*Installation, error)
 								// if outputFile is there and matches the checksum: do nothing more.
 								if err := localChecksum.ChecksumFile(localChecksum.Expected, potentialOutputFilename); err == nil {
 									log.Printf("[INFO] %s v%s plugin is already correctly installed in %q", pr.Identifier, version, potentialOutputFilename)
+									if lock != nil {
+										if err := lock.recordInstallation(version, potentialOutputFilename); err != nil {
+											errs = multierror.Append(errs, err)
+											return nil, errs
+										}
+									}
 									return nil, nil // success
 								}
 							}
@@ -719,6 +806,23 @@ func (pr *Requirement) InstallLatest(opts InstallOptions) (*Installation, error)
 							log.Printf("[WARNING] %v, ignoring", err)
 						}
 
+						if checksum.Checksummer.Type == "sha256" {
+							// Lets a lock check the binary on platforms it
+							// has no checksum of the binary for.
+							if err := os.WriteFile(outputFileName+zipChecksumFileExt, []byte(hex.EncodeToString(checksum.Expected)), 0644); err != nil {
+								err := fmt.Errorf("failed to write local zip checksum file: %s", err)
+								errs = multierror.Append(errs, err)
+								log.Printf("[WARNING] %v, ignoring", err)
+							}
+						}
+
+						if lock != nil {
+							if err := lock.recordInstallation(version, outputFileName); err != nil {
+								errs = multierror.Append(errs, err)
+								return nil, errs
+							}
+						}
+
 						// Success !!
 						return &Installation{
 							BinaryPath: strings.ReplaceAll(outputFileName, "\\", "/"),
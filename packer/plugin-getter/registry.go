@@ -0,0 +1,48 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// GetterRegistry is a Getter forwarding requests to the getter registered for
+// the hostname of the plugin source, like github.com or a self-hosted GitLab.
+type GetterRegistry struct {
+	getters map[string]Getter
+}
+
+var _ Getter = &GetterRegistry{}
+
+// Register sets the getter of the plugins hosted on hostname, replacing any
+// previously registered one.
+func (r *GetterRegistry) Register(hostname string, getter Getter) {
+	if r.getters == nil {
+		r.getters = map[string]Getter{}
+	}
+	r.getters[strings.ToLower(hostname)] = getter
+}
+
+// Hostnames returns the sorted list of hostnames with a registered getter.
+func (r *GetterRegistry) Hostnames() []string {
+	hostnames := make([]string, 0, len(r.getters))
+	for hostname := range r.getters {
+		hostnames = append(hostnames, hostname)
+	}
+	sort.Strings(hostnames)
+	return hostnames
+}
+
+func (r *GetterRegistry) Get(what string, opts GetOptions) (io.ReadCloser, error) {
+	hostname := opts.PluginRequirement.Identifier.Hostname
+	getter, found := r.getters[strings.ToLower(hostname)]
+	if !found {
+		return nil, fmt.Errorf("no plugin source is configured for %s, the known plugin hosts are %s",
+			hostname, strings.Join(r.Hostnames(), ", "))
+	}
+	return getter.Get(what, opts)
+}
@@ -0,0 +1,57 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugingetter
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/packer/hcl2template/addrs"
+)
+
+func TestGetterRegistry_Get(t *testing.T) {
+	github := &mockPluginGetter{Releases: []Release{{Version: "v1.0.0"}}}
+	gitlab := &mockPluginGetter{Releases: []Release{{Version: "v2.0.0"}}}
+	registry := &GetterRegistry{}
+	registry.Register("github.com", github)
+	registry.Register("GitLab.Example.com", gitlab)
+
+	tests := []struct {
+		source      string
+		wantVersion string
+		wantErr     string
+	}{
+		{"github.com/hashicorp/amazon", "v1.0.0", ""},
+		{"gitlab.example.com/infra/amazon", "v2.0.0", ""},
+		{"plugins.example.com/infra/amazon", "", "the known plugin hosts are github.com, gitlab.example.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			identifier, diags := addrs.ParsePluginSourceString(tt.source)
+			if len(diags) != 0 {
+				t.Fatal(diags)
+			}
+			rc, err := registry.Get("releases", GetOptions{
+				PluginRequirement: &Requirement{Identifier: identifier},
+			})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			releases, err := ParseReleases(io.NopCloser(rc))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(releases) != 1 || releases[0].Version != tt.wantVersion {
+				t.Errorf("Expected release %s, got %v", tt.wantVersion, releases)
+			}
+		})
+	}
+}
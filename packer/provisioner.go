@@ -20,6 +20,7 @@ type HookedProvisioner struct {
 	Provisioner packersdk.Provisioner
 	Config      interface{}
 	TypeName    string
+	Name        string
 }
 
 // A Hook implementation that runs the given provisioners.
@@ -27,6 +28,20 @@ type ProvisionHook struct {
 	// The provisioners to run as part of the hook. These should already
 	// be prepared (by calling Prepare) at some earlier stage.
 	Provisioners []*HookedProvisioner
+
+	// Journal, when set, records the provisioners of the build named
+	// BuildName that completed. Provisioners it already recorded as
+	// completed are skipped.
+	Journal   *BuildJournal
+	BuildName string
+
+	// Report, when set, records the runs of the provisioners.
+	Report *BuildReport
+
+	// EventUi, when set, is sent the events reporting the runs of the
+	// provisioners. The ui the hook is run with comes back from the builder
+	// plugin, so it can't tell whether the events are output.
+	EventUi packersdk.Ui
 }
 
 // BuilderDataCommonKeys is the list of common keys that all builder will
@@ -118,16 +133,33 @@ func (h *ProvisionHook) Run(ctx context.Context, name string, ui packersdk.Ui, c
 				"`communicator` config was set to \"none\". If you have any provisioners\n" +
 				"then a communicator is required. Please fix this to continue.")
 	}
-	for _, p := range h.Provisioners {
+	for i, p := range h.Provisioners {
+		if h.Journal != nil && h.Journal.Completed(h.BuildName, i) {
+			ui.Say(fmt.Sprintf("Skipping provisioner %s, it completed during a previous run", p.TypeName))
+			h.Report.skipProvisioner(p.TypeName, p.Name)
+			continue
+		}
+
 		ts := CheckpointReporter.AddSpan(p.TypeName, "provisioner", p.Config)
+		machineEvent(h.EventUi, MachineProvisionerStart, p.TypeName, p.Name)
+		start := time.Now()
 
 		cast := CastDataToMap(data)
 		err := p.Provisioner.Provision(ctx, ui, comm, cast)
 
 		ts.End(err)
+		machineEvent(h.EventUi, MachineProvisionerEnd, p.TypeName, p.Name,
+			machineDuration(time.Since(start)), machineError(err))
+		h.Report.addProvisioner(p.TypeName, p.Name, start, err)
 		if err != nil {
 			return err
 		}
+
+		if h.Journal != nil {
+			if err := h.Journal.ProvisionerCompleted(h.BuildName, i); err != nil {
+				log.Printf("Failed to update the build journal: %s", err)
+			}
+		}
 	}
 
 	return nil
@@ -162,8 +194,14 @@ func (p *PausedProvisioner) Provision(ctx context.Context, ui packersdk.Ui, comm
 // RetriedProvisioner is a Provisioner implementation that retries
 // the provisioner whenever there's an error.
 type RetriedProvisioner struct {
-	MaxRetries  int
+	MaxRetries int
+	// RetryPolicy sets how long to wait between tries and which errors are
+	// retried. Its zero value retries all errors right away.
+	RetryPolicy RetryPolicy
 	Provisioner packersdk.Provisioner
+
+	lock          sync.Mutex
+	retriedErrors []error
 }
 
 func (r *RetriedProvisioner) ConfigSpec() hcldec.ObjectSpec { return r.ConfigSpec() }
@@ -177,30 +215,62 @@ func (r *RetriedProvisioner) Provision(ctx context.Context, ui packersdk.Ui, com
 		return ctx.Err()
 	}
 
+	start := time.Now()
 	err := r.Provisioner.Provision(ctx, ui, comm, generatedData)
 	if err == nil {
 		return nil
 	}
 
-	leftTries := r.MaxRetries
-	for ; leftTries > 0; leftTries-- {
+	for retry := 0; retry < r.MaxRetries; retry++ {
 		if ctx.Err() != nil { // context was cancelled
 			return ctx.Err()
 		}
 
-		ui.Say(fmt.Sprintf("Provisioner failed with %q, retrying with %d trie(s) left", err, leftTries))
+		if !r.RetryPolicy.Retryable(err) {
+			ui.Say(fmt.Sprintf("Provisioner failed with %q, not retrying an error matching no retry condition", err))
+			return err
+		}
+
+		backoff := r.RetryPolicy.Backoff(retry)
+		if max := r.RetryPolicy.MaxDuration; max > 0 && time.Since(start)+backoff > max {
+			ui.Say(fmt.Sprintf("Provisioner failed with %q, not retrying after the maximum retry duration of %s", err, max))
+			return err
+		}
+
+		r.lock.Lock()
+		r.retriedErrors = append(r.retriedErrors, err)
+		r.lock.Unlock()
+
+		leftTries := r.MaxRetries - retry
+		if backoff == 0 {
+			ui.Say(fmt.Sprintf("Provisioner failed with %q, retrying with %d trie(s) left", err, leftTries))
+		} else {
+			ui.Say(fmt.Sprintf("Provisioner failed with %q, retrying in %s with %d trie(s) left", err, backoff.Round(time.Millisecond), leftTries))
+			select {
+			case <-time.After(backoff):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
 
-		err := r.Provisioner.Provision(ctx, ui, comm, generatedData)
+		err = r.Provisioner.Provision(ctx, ui, comm, generatedData)
 		if err == nil {
 			return nil
 		}
-
 	}
 	ui.Say("retry limit reached.")
 
 	return err
 }
 
+// RetriedErrors returns the errors of the tries of the provisioner that
+// were retried.
+func (r *RetriedProvisioner) RetriedErrors() []error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return append([]error(nil), r.retriedErrors...)
+}
+
 // DebuggedProvisioner is a Provisioner implementation that waits until a key
 // press before the provisioner is actually run.
 type DebuggedProvisioner struct {
@@ -0,0 +1,64 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package packer
+
+import (
+	"context"
+	"fmt"
+
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// Condition is the `when` condition of a provisioner or of a post-processor,
+// which is skipped when the condition is false.
+type Condition struct {
+	// Expression is the source of the condition, as shown to the user.
+	Expression string
+	// Evaluate evaluates the condition with the data generated by the
+	// builder. known is false when the condition depends on values that
+	// are not known yet, for example generated data when planning a build
+	// with nil data.
+	Evaluate func(generatedData map[string]interface{}) (value, known bool, err error)
+}
+
+// evaluate evaluates the condition when running a build, at which point all
+// of its values must be known.
+func (c *Condition) evaluate(generatedData map[string]interface{}) (bool, error) {
+	value, known, err := c.Evaluate(generatedData)
+	if err != nil {
+		return false, fmt.Errorf("Failed evaluating condition %s: %s", c.Expression, err)
+	}
+	if !known {
+		return false, fmt.Errorf("Failed evaluating condition %s: its value is unknown", c.Expression)
+	}
+	return value, nil
+}
+
+// skipped tells whether the condition is already known to be false before
+// running the build.
+func (c *Condition) skipped() bool {
+	value, known, err := c.Evaluate(nil)
+	return err == nil && known && !value
+}
+
+// ConditionalProvisioner is a Provisioner implementation that only runs its
+// provisioner when its condition is true.
+type ConditionalProvisioner struct {
+	Condition Condition
+	// Name is the name of the provisioner, as shown when it is skipped.
+	Name string
+	packersdk.Provisioner
+}
+
+func (p *ConditionalProvisioner) Provision(ctx context.Context, ui packersdk.Ui, comm packersdk.Communicator, generatedData map[string]interface{}) error {
+	run, err := p.Condition.evaluate(generatedData)
+	if err != nil {
+		return err
+	}
+	if !run {
+		ui.Say(fmt.Sprintf("Provisioner %s skipped (condition false): %s", p.Name, p.Condition.Expression))
+		return nil
+	}
+	return p.Provisioner.Provision(ctx, ui, comm, generatedData)
+}
@@ -0,0 +1,190 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package packer
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// hostCondition is true when the Host generated by the builder is
+// example.com, and unknown when there is no generated data.
+var hostCondition = Condition{
+	Expression: `build.Host == "example.com"`,
+	Evaluate: func(data map[string]interface{}) (bool, bool, error) {
+		if data == nil {
+			return false, false, nil
+		}
+		return data["Host"] == "example.com", true, nil
+	},
+}
+
+func TestConditionalProvisioner_impl(t *testing.T) {
+	var _ packersdk.Provisioner = new(ConditionalProvisioner)
+}
+
+func TestConditionalProvisioner_Provision(t *testing.T) {
+	tests := []struct {
+		data    map[string]interface{}
+		wantRun bool
+		wantErr bool
+	}{
+		{map[string]interface{}{"Host": "example.com"}, true, false},
+		{map[string]interface{}{"Host": "127.0.0.1"}, false, false},
+		{nil, false, true},
+	}
+	for _, tt := range tests {
+		mock := new(packersdk.MockProvisioner)
+		prov := &ConditionalProvisioner{
+			Condition:   hostCondition,
+			Name:        "shell",
+			Provisioner: mock,
+		}
+
+		ui := testUi()
+		err := prov.Provision(context.Background(), ui, new(packersdk.MockCommunicator), tt.data)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("%v: unexpected error %v", tt.data, err)
+		}
+		if mock.ProvCalled != tt.wantRun {
+			t.Errorf("%v: expected the provisioner to run: %t", tt.data, tt.wantRun)
+		}
+		out := readWriter(ui)
+		skipped := strings.Contains(out, "Provisioner shell skipped (condition false)")
+		if skipped != (!tt.wantRun && !tt.wantErr) {
+			t.Errorf("%v: unexpected output %q", tt.data, out)
+		}
+	}
+}
+
+func TestBuild_Run_skippedPostProcessor(t *testing.T) {
+	skip := &Condition{
+		Expression: "false",
+		Evaluate: func(map[string]interface{}) (bool, bool, error) {
+			return false, true, nil
+		},
+	}
+
+	build := testBuild()
+	build.PostProcessors = [][]CoreBuildPostProcessor{
+		{
+			{PostProcessor: &MockPostProcessor{ArtifactId: "skipped"}, PType: "pp", Condition: skip},
+			{PostProcessor: &MockPostProcessor{ArtifactId: "pp"}, PType: "pp", KeepInputArtifact: boolPointer(false)},
+		},
+	}
+	build.Prepare()
+
+	ui := testUi()
+	artifacts, err := build.Run(context.Background(), ui)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// The builder artifact was the input of the first post-processor that
+	// ran, so it is discarded as it would be without the skipped one.
+	var artifactIds []string
+	for _, artifact := range artifacts {
+		artifactIds = append(artifactIds, artifact.Id())
+	}
+	if expected := []string{"pp"}; !reflect.DeepEqual(artifactIds, expected) {
+		t.Fatalf("unexpected ids: %#v", artifactIds)
+	}
+	if out := readWriter(ui); !strings.Contains(out, "Post-processor pp skipped (condition false): false") {
+		t.Errorf("expected the skipped post-processor to be reported, got %q", out)
+	}
+}
+
+// artifactBuilder is a builder returning artifact.
+type artifactBuilder struct {
+	packersdk.MockBuilder
+	artifact *packersdk.MockArtifact
+}
+
+func (b *artifactBuilder) Run(context.Context, packersdk.Ui, packersdk.Hook) (packersdk.Artifact, error) {
+	return b.artifact, nil
+}
+
+func TestBuild_Run_skippedPostProcessorSequence(t *testing.T) {
+	skip := &Condition{
+		Expression: "false",
+		Evaluate: func(map[string]interface{}) (bool, bool, error) {
+			return false, true, nil
+		},
+	}
+
+	builderArtifact := &packersdk.MockArtifact{IdValue: "b"}
+	build := testBuild()
+	build.Builder = &artifactBuilder{artifact: builderArtifact}
+	build.PostProcessors = [][]CoreBuildPostProcessor{
+		{
+			{PostProcessor: &MockPostProcessor{ArtifactId: "compress"}, PType: "compress", Condition: skip},
+			{PostProcessor: &MockPostProcessor{ArtifactId: "vagrant"}, PType: "vagrant", Condition: skip},
+		},
+		{
+			{PostProcessor: &MockPostProcessor{ArtifactId: "checksum"}, PType: "checksum", Condition: skip},
+		},
+	}
+	build.Prepare()
+
+	artifacts, err := build.Run(context.Background(), testUi())
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// The sequences that were skipped entirely produce the builder artifact,
+	// which is kept and returned once.
+	if builderArtifact.DestroyCalled {
+		t.Error("the builder artifact should not be destroyed")
+	}
+	var artifactIds []string
+	for _, artifact := range artifacts {
+		artifactIds = append(artifactIds, artifact.Id())
+	}
+	if expected := []string{"b"}; !reflect.DeepEqual(artifactIds, expected) {
+		t.Fatalf("unexpected ids: %#v", artifactIds)
+	}
+}
+
+// generatedDataBuilder is a builder whose artifact carries generated data.
+type generatedDataBuilder struct {
+	packersdk.MockBuilder
+	data map[string]interface{}
+}
+
+func (b *generatedDataBuilder) Run(context.Context, packersdk.Ui, packersdk.Hook) (packersdk.Artifact, error) {
+	return &packersdk.MockArtifact{
+		IdValue:     "b",
+		StateValues: map[string]interface{}{"generated_data": b.data},
+	}, nil
+}
+
+func TestBuild_Run_chainedPostProcessorCondition(t *testing.T) {
+	build := testBuild()
+	build.Builder = &generatedDataBuilder{data: map[string]interface{}{"Host": "example.com"}}
+	// The artifact of the first post-processor carries no generated data,
+	// the condition of the second one is still evaluated against the data
+	// generated by the builder.
+	second := &MockPostProcessor{ArtifactId: "second"}
+	build.PostProcessors = [][]CoreBuildPostProcessor{
+		{
+			{PostProcessor: &MockPostProcessor{ArtifactId: "first"}, PType: "compress"},
+			{PostProcessor: second, PType: "checksum", Condition: &hostCondition},
+		},
+	}
+	build.Prepare()
+
+	if _, err := build.Run(context.Background(), testUi()); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !second.PostProcessCalled {
+		t.Fatal("expected the post-processor to run on the data generated by the builder")
+	}
+	if id := second.PostProcessArtifact.Id(); id != "first" {
+		t.Errorf("expected the post-processor to process the artifact of the previous one, got %q", id)
+	}
+}
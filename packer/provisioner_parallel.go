@@ -0,0 +1,93 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package packer
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/hcl/v2/hcldec"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"golang.org/x/sync/errgroup"
+)
+
+// ParallelProvisionerType is the type of the CoreBuildProvisioner of a group
+// of provisioners running in parallel.
+const ParallelProvisionerType = "provisioners"
+
+// ParallelProvisioner is a Provisioner implementation that runs a group of
+// provisioners concurrently, over the same communicator. The group fails as
+// soon as one of its provisioners fails, and the others are then cancelled.
+type ParallelProvisioner struct {
+	Provisioners []CoreBuildProvisioner
+
+	// report records the runs of the provisioners of the group, when set.
+	report *BuildReport
+	// eventUi is sent the events reporting the runs of the provisioners of
+	// the group, when set.
+	eventUi packersdk.Ui
+}
+
+// ConfigSpec returns nil: a group has no configuration of its own, each of its
+// provisioners is configured by its own block.
+func (p *ParallelProvisioner) ConfigSpec() hcldec.ObjectSpec { return nil }
+func (p *ParallelProvisioner) FlatConfig() interface{}       { return nil }
+
+func (p *ParallelProvisioner) Prepare(raws ...interface{}) error {
+	for _, child := range p.Provisioners {
+		configs := make([]interface{}, len(child.config), len(child.config)+len(raws))
+		copy(configs, child.config)
+		configs = append(configs, raws...)
+
+		if err := child.Provisioner.Prepare(configs...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *ParallelProvisioner) Provision(ctx context.Context, ui packersdk.Ui, comm packersdk.Communicator, generatedData map[string]interface{}) error {
+	g, ctx := errgroup.WithContext(ctx)
+	for _, child := range p.Provisioners {
+		child := child
+		label := child.label()
+		childUi := &TargetedUI{
+			Target: label,
+			Ui:     ui,
+		}
+
+		var config interface{} = child.HCLConfig
+		if len(child.config) > 0 {
+			config = child.config[0]
+		}
+
+		g.Go(func() error {
+			ts := CheckpointReporter.AddSpan(child.PType, "provisioner", config)
+			machineEvent(p.eventUi, MachineProvisionerStart, child.PType, child.PName)
+			start := time.Now()
+
+			err := child.Provisioner.Provision(ctx, childUi, comm, generatedData)
+
+			ts.End(err)
+			machineEvent(p.eventUi, MachineProvisionerEnd, child.PType, child.PName,
+				machineDuration(time.Since(start)), machineError(err))
+			p.report.addProvisioner(child.PType, child.PName, start, err)
+			if err != nil {
+				return fmt.Errorf("%s: %w", label, err)
+			}
+			return nil
+		})
+	}
+	return g.Wait()
+}
+
+// label returns the name of the provisioner, or its type when it has no
+// name.
+func (p CoreBuildProvisioner) label() string {
+	if p.PName != "" {
+		return p.PName
+	}
+	return p.PType
+}
@@ -0,0 +1,129 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package packer
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+func TestParallelProvisioner_impl(t *testing.T) {
+	var _ packersdk.Provisioner = new(ParallelProvisioner)
+}
+
+func TestParallelProvisioner_config(t *testing.T) {
+	prov := &ParallelProvisioner{}
+	if spec := prov.ConfigSpec(); spec != nil {
+		t.Errorf("unexpected config spec: %#v", spec)
+	}
+	if config := prov.FlatConfig(); config != nil {
+		t.Errorf("unexpected flat config: %#v", config)
+	}
+}
+
+func TestParallelProvisioner_Prepare(t *testing.T) {
+	a, b := new(packersdk.MockProvisioner), new(packersdk.MockProvisioner)
+	prov := &ParallelProvisioner{
+		Provisioners: []CoreBuildProvisioner{
+			{PType: "a", Provisioner: a, config: []interface{}{"a-config"}},
+			{PType: "b", Provisioner: b},
+		},
+	}
+
+	if err := prov.Prepare(42); err != nil {
+		t.Fatal(err)
+	}
+	if len(a.PrepConfigs) != 2 || a.PrepConfigs[0] != "a-config" || a.PrepConfigs[1] != 42 {
+		t.Errorf("unexpected configs for a: %#v", a.PrepConfigs)
+	}
+	if len(b.PrepConfigs) != 1 || b.PrepConfigs[0] != 42 {
+		t.Errorf("unexpected configs for b: %#v", b.PrepConfigs)
+	}
+}
+
+func TestParallelProvisioner_Provision(t *testing.T) {
+	// Every provisioner waits for all of them to be started, which only
+	// happens if they run concurrently.
+	var started sync.WaitGroup
+	started.Add(3)
+	newProv := func() *packersdk.MockProvisioner {
+		return &packersdk.MockProvisioner{
+			ProvFunc: func(ctx context.Context) error {
+				started.Done()
+				started.Wait()
+				return nil
+			},
+		}
+	}
+	provs := []*packersdk.MockProvisioner{newProv(), newProv(), newProv()}
+	prov := &ParallelProvisioner{
+		Provisioners: []CoreBuildProvisioner{
+			{PType: "shell", PName: "tools", Provisioner: provs[0]},
+			{PType: "shell", Provisioner: provs[1]},
+			{PType: "file", Provisioner: provs[2]},
+		},
+	}
+
+	ui := testUi()
+	comm := new(packersdk.MockCommunicator)
+	done := make(chan error, 1)
+	go func() {
+		done <- prov.Provision(context.Background(), ui, comm, map[string]interface{}{})
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("provisioners did not run concurrently")
+	}
+
+	expectedTargets := []string{"tools", "shell", "file"}
+	for i, p := range provs {
+		if p.ProvCommunicator != comm {
+			t.Errorf("provisioner %d: should have proper comm", i)
+		}
+		targeted, ok := p.ProvUi.(*TargetedUI)
+		if !ok || targeted.Target != expectedTargets[i] || targeted.Ui != ui {
+			t.Errorf("provisioner %d: expected a ui targeting %q, got %#v", i, expectedTargets[i], p.ProvUi)
+		}
+	}
+}
+
+func TestParallelProvisioner_Provision_cancelsSiblings(t *testing.T) {
+	cancelled := make(chan struct{})
+	prov := &ParallelProvisioner{
+		Provisioners: []CoreBuildProvisioner{
+			{PType: "shell", PName: "broken", Provisioner: &packersdk.MockProvisioner{
+				ProvFunc: func(ctx context.Context) error {
+					return errors.New("script failed")
+				},
+			}},
+			{PType: "shell", PName: "slow", Provisioner: &packersdk.MockProvisioner{
+				ProvFunc: func(ctx context.Context) error {
+					<-ctx.Done()
+					close(cancelled)
+					return ctx.Err()
+				},
+			}},
+		},
+	}
+
+	err := prov.Provision(context.Background(), testUi(), new(packersdk.MockCommunicator), map[string]interface{}{})
+	if err == nil || !strings.Contains(err.Error(), "broken: script failed") {
+		t.Fatalf("expected the error of the failed provisioner, got %v", err)
+	}
+	select {
+	case <-cancelled:
+	default:
+		t.Fatal("expected the other provisioners to be cancelled")
+	}
+}
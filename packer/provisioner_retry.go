@@ -0,0 +1,166 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package packer
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"regexp"
+	"strconv"
+	"time"
+
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-sdk/shell"
+)
+
+// DefaultRetryMultiplier is the factor by which the backoff between two
+// tries grows when RetryPolicy.Multiplier is not set.
+const DefaultRetryMultiplier = 2
+
+// RetryPolicy sets how a RetriedProvisioner retries a failed provisioner.
+type RetryPolicy struct {
+	// InitialBackoff is the time to wait before the first retry. No time
+	// is waited between tries when zero.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the time waited between two tries, jitter included,
+	// when set.
+	MaxBackoff time.Duration
+	// Multiplier is the factor by which the backoff grows after each try,
+	// defaults to DefaultRetryMultiplier.
+	Multiplier float64
+	// Jitter randomizes each backoff by up to this fraction of it, between
+	// 0 and 1, so that parallel builds don't retry all at once.
+	Jitter float64
+	// MaxDuration is the total time after which a failed provisioner is no
+	// longer retried, when set.
+	MaxDuration time.Duration
+
+	// RetryOn and RetryOnExitCodes select the errors that are retried: an
+	// error is retried if it matches one of the expressions of RetryOn, or
+	// if it comes from a script exiting with one of the codes of
+	// RetryOnExitCodes. All errors are retried when both are empty.
+	RetryOn          []*regexp.Regexp
+	RetryOnExitCodes []int
+}
+
+// Backoff returns the time to wait before the retry number retry, starting
+// at 0.
+func (p *RetryPolicy) Backoff(retry int) time.Duration {
+	if p.InitialBackoff <= 0 {
+		return 0
+	}
+	multiplier := p.Multiplier
+	if multiplier == 0 {
+		multiplier = DefaultRetryMultiplier
+	}
+
+	backoff := float64(p.InitialBackoff) * math.Pow(multiplier, float64(retry))
+	if p.MaxBackoff > 0 && backoff > float64(p.MaxBackoff) {
+		backoff = float64(p.MaxBackoff)
+	}
+	if p.Jitter > 0 {
+		backoff += backoff * p.Jitter * (2*rand.Float64() - 1)
+		// The jitter may push a capped backoff past the cap.
+		if p.MaxBackoff > 0 && backoff > float64(p.MaxBackoff) {
+			backoff = float64(p.MaxBackoff)
+		}
+	}
+	if backoff >= math.MaxInt64 {
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Duration(backoff)
+}
+
+// Retryable tells whether err should be retried.
+func (p *RetryPolicy) Retryable(err error) bool {
+	if len(p.RetryOn) == 0 && len(p.RetryOnExitCodes) == 0 {
+		return true
+	}
+
+	for _, re := range p.RetryOn {
+		if re.MatchString(err.Error()) {
+			return true
+		}
+	}
+	if code, ok := scriptExitCode(err); ok {
+		for _, c := range p.RetryOnExitCodes {
+			if c == code {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// exitStatusRe matches the message of shell.ErrorInvalidExitCode errors,
+// which lose their type when sent over RPC by plugins.
+var exitStatusRe = regexp.MustCompile(`exited with non-zero exit status: (-?\d+)`)
+
+// scriptExitCode returns the exit code of the script that caused err, if
+// any.
+func scriptExitCode(err error) (int, bool) {
+	var exitErr *shell.ErrorInvalidExitCode
+	if errors.As(err, &exitErr) {
+		return exitErr.Code, true
+	}
+	m := exitStatusRe.FindStringSubmatch(err.Error())
+	if m == nil {
+		return 0, false
+	}
+	code, convErr := strconv.Atoi(m[1])
+	return code, convErr == nil
+}
+
+// ProvisionerRetries describes the retries of a provisioner of a build.
+type ProvisionerRetries struct {
+	Type string
+	Name string
+	// Errors are the errors of the tries that were retried.
+	Errors []error
+}
+
+// ProvisionerRetries returns the provisioners of the build that were
+// retried during its run, in order.
+func (b *CoreBuild) ProvisionerRetries() []ProvisionerRetries {
+	var res []ProvisionerRetries
+	for _, p := range b.Provisioners {
+		res = append(res, provisionerRetries(p)...)
+	}
+	return res
+}
+
+func provisionerRetries(p CoreBuildProvisioner) []ProvisionerRetries {
+	var prov packersdk.Provisioner = p.Provisioner
+	for prov != nil {
+		switch wrapped := prov.(type) {
+		case *RetriedProvisioner:
+			if errs := wrapped.RetriedErrors(); len(errs) > 0 {
+				return []ProvisionerRetries{{
+					Type:   p.PType,
+					Name:   p.PName,
+					Errors: errs,
+				}}
+			}
+			prov = wrapped.Provisioner
+		case *ParallelProvisioner:
+			var res []ProvisionerRetries
+			for _, child := range wrapped.Provisioners {
+				res = append(res, provisionerRetries(child)...)
+			}
+			return res
+		case *ConditionalProvisioner:
+			prov = wrapped.Provisioner
+		case *PausedProvisioner:
+			prov = wrapped.Provisioner
+		case *TimeoutProvisioner:
+			prov = wrapped.Provisioner
+		case *DebuggedProvisioner:
+			prov = wrapped.Provisioner
+		default:
+			prov = nil
+		}
+	}
+	return nil
+}
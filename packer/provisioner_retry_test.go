@@ -0,0 +1,173 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package packer
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-sdk/rpc"
+	"github.com/hashicorp/packer-plugin-sdk/shell"
+)
+
+// flakyProvisioner fails with its errors, in order, before succeeding.
+type flakyProvisioner struct {
+	packersdk.MockProvisioner
+	errs  []error
+	calls int
+}
+
+func (p *flakyProvisioner) Provision(context.Context, packersdk.Ui, packersdk.Communicator, map[string]interface{}) error {
+	p.calls++
+	if p.calls <= len(p.errs) {
+		return p.errs[p.calls-1]
+	}
+	return nil
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	policy := RetryPolicy{
+		InitialBackoff: time.Second,
+		MaxBackoff:     10 * time.Second,
+	}
+	for retry, expected := range []time.Duration{
+		time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second,
+	} {
+		if got := policy.Backoff(retry); got != expected {
+			t.Errorf("retry %d: expected a %s backoff, got %s", retry, expected, got)
+		}
+	}
+
+	policy = RetryPolicy{InitialBackoff: time.Second, Multiplier: 3}
+	if got := policy.Backoff(2); got != 9*time.Second {
+		t.Errorf("expected a 9s backoff, got %s", got)
+	}
+	if got := policy.Backoff(1000); got <= 0 {
+		t.Errorf("expected an overflowing backoff to stay positive, got %s", got)
+	}
+
+	policy = RetryPolicy{InitialBackoff: 10 * time.Second, Jitter: 0.5}
+	for i := 0; i < 100; i++ {
+		if got := policy.Backoff(0); got < 5*time.Second || got > 15*time.Second {
+			t.Fatalf("expected a backoff between 5s and 15s, got %s", got)
+		}
+	}
+
+	policy = RetryPolicy{InitialBackoff: time.Second, MaxBackoff: 10 * time.Second, Jitter: 0.5}
+	for i := 0; i < 100; i++ {
+		for retry := 0; retry < 6; retry++ {
+			if got := policy.Backoff(retry); got > policy.MaxBackoff {
+				t.Fatalf("retry %d: expected a backoff of at most %s, got %s", retry, policy.MaxBackoff, got)
+			}
+		}
+	}
+
+	if got := (&RetryPolicy{}).Backoff(3); got != 0 {
+		t.Errorf("expected no backoff by default, got %s", got)
+	}
+}
+
+func TestRetryPolicy_Retryable(t *testing.T) {
+	policy := RetryPolicy{
+		RetryOn:          []*regexp.Regexp{regexp.MustCompile(`Could not resolve host`)},
+		RetryOnExitCodes: []int{100},
+	}
+	tests := []struct {
+		err      error
+		expected bool
+	}{
+		{errors.New("curl: (6) Could not resolve host: example.com"), true},
+		{errors.New("syntax error near unexpected token"), false},
+		{&shell.ErrorInvalidExitCode{Code: 100, Allowed: []int{0}}, true},
+		{&shell.ErrorInvalidExitCode{Code: 1, Allowed: []int{0}}, false},
+		// errors from plugins come over RPC as plain messages.
+		{rpc.NewBasicError(&shell.ErrorInvalidExitCode{Code: 100, Allowed: []int{0}}), true},
+		{rpc.NewBasicError(&shell.ErrorInvalidExitCode{Code: 2, Allowed: []int{0}}), false},
+	}
+	for _, tt := range tests {
+		if got := policy.Retryable(tt.err); got != tt.expected {
+			t.Errorf("Retryable(%q): expected %t, got %t", tt.err, tt.expected, got)
+		}
+	}
+
+	if !(&RetryPolicy{}).Retryable(errors.New("anything")) {
+		t.Error("expected all errors to be retried by default")
+	}
+}
+
+func TestRetriedProvisioner_retryOn(t *testing.T) {
+	transient := errors.New("Could not resolve host")
+	prov := &flakyProvisioner{errs: []error{transient, transient, errors.New("script bug"), transient}}
+	retried := &RetriedProvisioner{
+		MaxRetries: 5,
+		RetryPolicy: RetryPolicy{
+			RetryOn: []*regexp.Regexp{regexp.MustCompile("resolve")},
+		},
+		Provisioner: prov,
+	}
+
+	err := retried.Provision(context.Background(), testUi(), new(packersdk.MockCommunicator), nil)
+	if err == nil || err.Error() != "script bug" {
+		t.Fatalf("expected the non retryable error to be returned, got %v", err)
+	}
+	if prov.calls != 3 {
+		t.Errorf("expected 3 tries, got %d", prov.calls)
+	}
+	if errs := retried.RetriedErrors(); len(errs) != 2 {
+		t.Errorf("expected 2 retried errors, got %v", errs)
+	}
+}
+
+func TestRetriedProvisioner_maxDuration(t *testing.T) {
+	prov := &flakyProvisioner{errs: []error{errors.New("a"), errors.New("b"), errors.New("c")}}
+	retried := &RetriedProvisioner{
+		MaxRetries: 5,
+		RetryPolicy: RetryPolicy{
+			InitialBackoff: 10 * time.Millisecond,
+			MaxDuration:    25 * time.Millisecond,
+		},
+		Provisioner: prov,
+	}
+
+	err := retried.Provision(context.Background(), testUi(), new(packersdk.MockCommunicator), nil)
+	if err == nil || err.Error() != "b" {
+		t.Fatalf("expected the retries to stop after the max duration, got %v", err)
+	}
+	if prov.calls != 2 {
+		t.Errorf("expected 2 tries, got %d", prov.calls)
+	}
+}
+
+func TestRetriedProvisioner_lastError(t *testing.T) {
+	prov := &flakyProvisioner{errs: []error{errors.New("a"), errors.New("b"), errors.New("c")}}
+	retried := &RetriedProvisioner{MaxRetries: 2, Provisioner: prov}
+
+	err := retried.Provision(context.Background(), testUi(), new(packersdk.MockCommunicator), nil)
+	if err == nil || err.Error() != "c" {
+		t.Fatalf("expected the error of the last try, got %v", err)
+	}
+}
+
+func TestCoreBuild_ProvisionerRetries(t *testing.T) {
+	prov := &flakyProvisioner{errs: []error{errors.New("a")}}
+	retried := &RetriedProvisioner{MaxRetries: 2, Provisioner: prov}
+	build := &CoreBuild{
+		Provisioners: []CoreBuildProvisioner{
+			{PType: "shell", Provisioner: &PausedProvisioner{Provisioner: retried}},
+			{PType: "file", Provisioner: &RetriedProvisioner{MaxRetries: 2, Provisioner: &flakyProvisioner{}}},
+		},
+	}
+	if err := retried.Provision(context.Background(), testUi(), new(packersdk.MockCommunicator), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	retries := build.ProvisionerRetries()
+	if len(retries) != 1 || retries[0].Type != "shell" || len(retries[0].Errors) != 1 {
+		t.Fatalf("unexpected provisioner retries: %#v", retries)
+	}
+}
@@ -31,8 +31,8 @@ func TestProvisionHook(t *testing.T) {
 
 	hook := &ProvisionHook{
 		Provisioners: []*HookedProvisioner{
-			{pA, nil, ""},
-			{pB, nil, ""},
+			{pA, nil, "", ""},
+			{pB, nil, "", ""},
 		},
 	}
 
@@ -57,8 +57,8 @@ func TestProvisionHook_nilComm(t *testing.T) {
 
 	hook := &ProvisionHook{
 		Provisioners: []*HookedProvisioner{
-			{pA, nil, ""},
-			{pB, nil, ""},
+			{pA, nil, "", ""},
+			{pB, nil, "", ""},
 		},
 	}
 
@@ -81,7 +81,7 @@ func TestProvisionHook_cancel(t *testing.T) {
 
 	hook := &ProvisionHook{
 		Provisioners: []*HookedProvisioner{
-			{p, nil, ""},
+			{p, nil, "", ""},
 		},
 	}
 
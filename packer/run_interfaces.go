@@ -16,6 +16,13 @@ type GetBuildsOptions struct {
 	Debug, Force bool
 	OnError      string
 
+	// DeferDependentBuilds leaves the builds depending on other builds
+	// unconfigured: their plugins are only started, with the artifacts of
+	// these builds, when CoreBuild.ResolveDependencies is called. Otherwise
+	// they are configured with unknown dependency values, which is enough
+	// to validate them but not to run them.
+	DeferDependentBuilds bool
+
 	// count only/except match count; so say something when nothing matched.
 	ExceptMatches, OnlyMatches int
 }
@@ -86,6 +93,13 @@ type ConfigFixer interface {
 
 type InspectConfigOptions struct {
 	packersdk.Ui
+
+	// Format is the format of the inspection, text or json. The json format
+	// is only supported for HCL2 templates.
+	Format string
+	// ShowDataSources reveals the outputs of the data sources in the json
+	// format. They can be secrets, so they are redacted otherwise.
+	ShowDataSources bool
 }
 
 type ConfigInspector interface {
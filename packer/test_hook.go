@@ -0,0 +1,253 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package packer
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// BuildTest is the test stage of a build: assertions checked against the
+// machine once it is provisioned, before the post-processors run.
+type BuildTest struct {
+	// Name is the name of the test suite in the report, defaults to the
+	// name of the build.
+	Name string
+	// JUnitReport is the path of the JUnit XML report written once the
+	// assertions are checked, defaults to "<build name>.junit.xml".
+	JUnitReport string
+	Assertions  []Assertion
+}
+
+// Assertion is a check run on the machine. It either runs Command, and
+// checks its ExitCode and that its output matches Stdout, or checks that
+// the FileExists path exists.
+type Assertion struct {
+	Name string
+
+	Command  string
+	ExitCode int
+	Stdout   *regexp.Regexp
+
+	FileExists string
+}
+
+// AssertionResult is the result of checking an Assertion.
+type AssertionResult struct {
+	Name string
+	// Failure tells why the assertion is not met, and Error why it could
+	// not be checked. Both are empty when the assertion passed.
+	Failure  string
+	Error    string
+	Output   string
+	Duration time.Duration
+}
+
+// TestHook is a Hook implementation that checks the assertions of a build
+// test through the communicator. It is run with the HookProvision hooks,
+// after the provisioners, and fails the build if any assertion fails.
+type TestHook struct {
+	Test      *BuildTest
+	BuildName string
+}
+
+func (h *TestHook) Run(ctx context.Context, name string, ui packersdk.Ui, comm packersdk.Communicator, data interface{}) error {
+	connType, _ := CastDataToMap(data)["ConnType"].(string)
+	if comm == nil || connType == "none" {
+		return fmt.Errorf(
+			"No communicator found for tests! This is usually because the\n" +
+				"`communicator` config was set to \"none\". A communicator is\n" +
+				"required to run the assertions of a test block.")
+	}
+
+	ui.Say(fmt.Sprintf("Running %d test assertion(s)...", len(h.Test.Assertions)))
+	start := time.Now()
+	failed := 0
+	var results []AssertionResult
+	for _, a := range h.Test.Assertions {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		res := a.check(ctx, comm, connType)
+		results = append(results, res)
+		switch {
+		case res.Failure != "":
+			failed++
+			ui.Error(fmt.Sprintf("Assertion %s failed: %s", a.Name, res.Failure))
+		case res.Error != "":
+			failed++
+			ui.Error(fmt.Sprintf("Assertion %s errored: %s", a.Name, res.Error))
+		default:
+			ui.Say(fmt.Sprintf("Assertion %s passed", a.Name))
+		}
+	}
+
+	report := h.reportPath()
+	if err := h.writeJUnitReport(report, results, start, time.Since(start)); err != nil {
+		return fmt.Errorf("Failed writing the test report: %s", err)
+	}
+	ui.Say(fmt.Sprintf("Test report written to %s", report))
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d test assertion(s) failed, see %s", failed, len(h.Test.Assertions), report)
+	}
+	return nil
+}
+
+func (h *TestHook) suiteName() string {
+	if h.Test.Name != "" {
+		return h.Test.Name
+	}
+	return h.BuildName
+}
+
+func (h *TestHook) reportPath() string {
+	if h.Test.JUnitReport != "" {
+		return h.Test.JUnitReport
+	}
+	return h.BuildName + ".junit.xml"
+}
+
+// check checks the assertion against the machine, reached with the connType
+// communicator.
+func (a *Assertion) check(ctx context.Context, comm packersdk.Communicator, connType string) (res AssertionResult) {
+	res.Name = a.Name
+	start := time.Now()
+	defer func() { res.Duration = time.Since(start) }()
+
+	command := a.Command
+	if a.FileExists != "" {
+		command = fileExistsCommand(a.FileExists, connType)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := &packersdk.RemoteCmd{
+		Command: command,
+		Stdout:  &stdout,
+		Stderr:  &stderr,
+	}
+	if err := comm.Start(ctx, cmd); err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	exited := make(chan int, 1)
+	go func() { exited <- cmd.Wait() }()
+	var code int
+	select {
+	case code = <-exited:
+	case <-ctx.Done():
+		res.Error = ctx.Err().Error()
+		return res
+	}
+	res.Output = stdout.String() + stderr.String()
+
+	switch {
+	case a.FileExists != "":
+		if code != 0 {
+			res.Failure = fmt.Sprintf("%s does not exist", a.FileExists)
+		}
+	case code != a.ExitCode:
+		res.Failure = fmt.Sprintf("%q exited with status %d, expected %d", a.Command, code, a.ExitCode)
+	case a.Stdout != nil && !a.Stdout.Match(stdout.Bytes()):
+		res.Failure = fmt.Sprintf("the output of %q does not match %q", a.Command, a.Stdout)
+	}
+	return res
+}
+
+// fileExistsCommand returns the command exiting with a zero status when path
+// exists. WinRM runs Windows commands, so PowerShell checks it; the other
+// communicators are expected to run the commands in a POSIX shell.
+func fileExistsCommand(path, connType string) string {
+	if connType == "winrm" {
+		return fmt.Sprintf(`powershell -NoProfile -NonInteractive -Command "if (Test-Path -LiteralPath '%s') { exit 0 } else { exit 1 }"`,
+			strings.ReplaceAll(path, "'", "''"))
+	}
+	return "test -e " + shellQuote(path)
+}
+
+// shellQuote quotes s for a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'"'"'`) + "'"
+}
+
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Error     *junitMessage `xml:"error,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+}
+
+// writeJUnitReport writes the results of the assertions to path, as a JUnit
+// XML report.
+func (h *TestHook) writeJUnitReport(path string, results []AssertionResult, start time.Time, duration time.Duration) error {
+	suite := junitTestSuite{
+		Name:      h.suiteName(),
+		Tests:     len(results),
+		Time:      junitSeconds(duration),
+		Timestamp: start.UTC().Format(time.RFC3339),
+	}
+	for _, res := range results {
+		tc := junitTestCase{
+			Name:      res.Name,
+			ClassName: h.BuildName,
+			Time:      junitSeconds(res.Duration),
+			SystemOut: res.Output,
+		}
+		if res.Failure != "" {
+			suite.Failures++
+			tc.Failure = &junitMessage{Message: res.Failure}
+		}
+		if res.Error != "" {
+			suite.Errors++
+			tc.Error = &junitMessage{Message: res.Error}
+		}
+		suite.TestCases = append(suite.TestCases, tc)
+	}
+
+	out, err := xml.MarshalIndent(junitTestSuites{Suites: []junitTestSuite{suite}}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(path, append([]byte(xml.Header), append(out, '\n')...), 0644)
+}
+
+func junitSeconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
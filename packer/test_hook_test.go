@@ -0,0 +1,192 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package packer
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// scriptedCommunicator answers the commands it knows of with their output
+// and exit status, and fails to start the others.
+type scriptedCommunicator struct {
+	packersdk.MockCommunicator
+	commands map[string]scriptedCommand
+}
+
+type scriptedCommand struct {
+	stdout string
+	status int
+}
+
+func (c *scriptedCommunicator) Start(ctx context.Context, rc *packersdk.RemoteCmd) error {
+	cmd, ok := c.commands[rc.Command]
+	if !ok {
+		return errors.New("connection lost")
+	}
+	go func() {
+		io.WriteString(rc.Stdout, cmd.stdout)
+		rc.SetExited(cmd.status)
+	}()
+	return nil
+}
+
+func TestTestHook_impl(t *testing.T) {
+	var _ packersdk.Hook = new(TestHook)
+}
+
+func TestTestHook_Run(t *testing.T) {
+	report := filepath.Join(t.TempDir(), "reports", "junit.xml")
+	hook := &TestHook{
+		BuildName: "null.example",
+		Test: &BuildTest{
+			JUnitReport: report,
+			Assertions: []Assertion{
+				{Name: "nginx-running", Command: "systemctl is-active nginx", Stdout: regexp.MustCompile("^active")},
+				{Name: "nginx-config", FileExists: "/etc/nginx/it's.conf"},
+				{Name: "no-apache", Command: "which apache2", ExitCode: 1},
+			},
+		},
+	}
+	comm := &scriptedCommunicator{commands: map[string]scriptedCommand{
+		"systemctl is-active nginx":          {stdout: "active\n"},
+		`test -e '/etc/nginx/it'"'"'s.conf'`: {},
+		"which apache2":                      {status: 1},
+	}}
+
+	if err := hook.Run(context.Background(), packersdk.HookProvision, testUi(), comm, nil); err != nil {
+		t.Fatalf("expected all assertions to pass, got %s", err)
+	}
+
+	var got junitTestSuites
+	raw, err := os.ReadFile(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := xml.Unmarshal(raw, &got); err != nil {
+		t.Fatalf("invalid JUnit report: %s", err)
+	}
+	if len(got.Suites) != 1 || got.Suites[0].Name != "null.example" || got.Suites[0].Tests != 3 || got.Suites[0].Failures != 0 {
+		t.Fatalf("unexpected report: %s", raw)
+	}
+}
+
+func TestTestHook_Run_winRM(t *testing.T) {
+	hook := &TestHook{
+		BuildName: "null.example",
+		Test: &BuildTest{
+			JUnitReport: filepath.Join(t.TempDir(), "junit.xml"),
+			Assertions: []Assertion{
+				{Name: "iis-config", FileExists: `C:\inetpub\it's.config`},
+			},
+		},
+	}
+	comm := &scriptedCommunicator{commands: map[string]scriptedCommand{
+		`powershell -NoProfile -NonInteractive -Command "if (Test-Path -LiteralPath 'C:\inetpub\it''s.config') { exit 0 } else { exit 1 }"`: {},
+	}}
+
+	data := map[string]interface{}{"ConnType": "winrm"}
+	if err := hook.Run(context.Background(), packersdk.HookProvision, testUi(), comm, data); err != nil {
+		t.Fatalf("expected the file to be checked with PowerShell, got %s", err)
+	}
+}
+
+func TestTestHook_Run_failures(t *testing.T) {
+	report := filepath.Join(t.TempDir(), "junit.xml")
+	hook := &TestHook{
+		BuildName: "null.example",
+		Test: &BuildTest{
+			Name:        "smoke",
+			JUnitReport: report,
+			Assertions: []Assertion{
+				{Name: "nginx-running", Command: "systemctl is-active nginx", Stdout: regexp.MustCompile("^active")},
+				{Name: "nginx-config", FileExists: "/etc/nginx/nginx.conf"},
+				{Name: "exit-code", Command: "true", ExitCode: 3},
+				{Name: "unreachable", Command: "uptime"},
+			},
+		},
+	}
+	comm := &scriptedCommunicator{commands: map[string]scriptedCommand{
+		"systemctl is-active nginx":       {stdout: "inactive\n", status: 0},
+		"test -e '/etc/nginx/nginx.conf'": {status: 1},
+		"true":                            {},
+	}}
+
+	ui := testUi()
+	err := hook.Run(context.Background(), packersdk.HookProvision, ui, comm, nil)
+	if err == nil || !strings.Contains(err.Error(), "4 of 4 test assertion(s) failed") {
+		t.Fatalf("expected the assertions to fail, got %v", err)
+	}
+	errOut := readErrorWriter(ui)
+	for _, expected := range []string{
+		`Assertion nginx-running failed: the output of "systemctl is-active nginx" does not match "^active"`,
+		"Assertion nginx-config failed: /etc/nginx/nginx.conf does not exist",
+		`Assertion exit-code failed: "true" exited with status 0, expected 3`,
+		"Assertion unreachable errored: connection lost",
+	} {
+		if !strings.Contains(errOut, expected) {
+			t.Errorf("expected %q in the output, got %q", expected, errOut)
+		}
+	}
+
+	var got junitTestSuites
+	raw, err := os.ReadFile(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := xml.Unmarshal(raw, &got); err != nil {
+		t.Fatalf("invalid JUnit report: %s", err)
+	}
+	suite := got.Suites[0]
+	if suite.Name != "smoke" || suite.Failures != 3 || suite.Errors != 1 {
+		t.Fatalf("unexpected report: %s", raw)
+	}
+	if tc := suite.TestCases[0]; tc.Failure == nil || tc.SystemOut != "inactive\n" {
+		t.Errorf("expected the output of the failed command in the report, got %#v", tc)
+	}
+}
+
+func TestBuild_Run_failedTest(t *testing.T) {
+	report := filepath.Join(t.TempDir(), "junit.xml")
+	build := testBuild()
+	// The mock builder runs the provision hook with a mock communicator, on
+	// which all commands exit with status 0.
+	build.Test = &BuildTest{
+		JUnitReport: report,
+		Assertions:  []Assertion{{Name: "fails", Command: "false", ExitCode: 1}},
+	}
+	pp := build.PostProcessors[0][0].PostProcessor.(*MockPostProcessor)
+	build.Prepare()
+
+	if _, err := build.Run(context.Background(), testUi()); err == nil {
+		t.Fatal("expected the build to fail")
+	}
+	if pp.PostProcessCalled {
+		t.Error("expected the post-processors not to run")
+	}
+	if _, err := os.Stat(report); err != nil {
+		t.Errorf("expected a report to be written: %s", err)
+	}
+}
+
+func TestTestHook_Run_noCommunicator(t *testing.T) {
+	hook := &TestHook{
+		BuildName: "null.example",
+		Test:      &BuildTest{Assertions: []Assertion{{Name: "uptime", Command: "uptime"}}},
+	}
+	data := map[interface{}]interface{}{"ConnType": "none"}
+	err := hook.Run(context.Background(), packersdk.HookProvision, testUi(), new(packersdk.MockCommunicator), data)
+	if err == nil || !strings.Contains(err.Error(), "No communicator found for tests!") {
+		t.Fatalf("expected the tests to require a communicator, got %v", err)
+	}
+}
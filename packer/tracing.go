@@ -0,0 +1,173 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package packer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	packerVersion "github.com/hashicorp/packer/version"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracesFileEnvVar is the environment variable setting the file to which the
+// spans of a run are written, as JSON, for offline use.
+const TracesFileEnvVar = "PACKER_OTEL_TRACES_FILE"
+
+const tracerName = "github.com/hashicorp/packer"
+
+// Attributes set on the spans of a build.
+const (
+	attrBuildName     = attribute.Key("packer.build.name")
+	attrBuilderType   = attribute.Key("packer.builder.type")
+	attrComponentType = attribute.Key("packer.component.type")
+	attrComponentName = attribute.Key("packer.component.name")
+	attrOutcome       = attribute.Key("packer.outcome")
+	attrArtifactCount = attribute.Key("packer.artifact.count")
+	attrTemplatePath  = attribute.Key("packer.template.path")
+)
+
+// SetupTracing sets up the export of the traces of Packer from the
+// environment:
+//
+//   - The OTLP exporter is enabled when OTEL_TRACES_EXPORTER is "otlp" or when
+//     an OTLP endpoint is set with OTEL_EXPORTER_OTLP_ENDPOINT or
+//     OTEL_EXPORTER_OTLP_TRACES_ENDPOINT. It is configured with the standard
+//     OTEL_EXPORTER_OTLP_* environment variables, and uses the gRPC protocol
+//     when OTEL_EXPORTER_OTLP_PROTOCOL is "grpc", HTTP otherwise.
+//   - The file exporter is enabled when PACKER_OTEL_TRACES_FILE is set.
+//
+// Tracing is disabled when none of them is, or when OTEL_TRACES_EXPORTER is
+// "none". The returned function flushes the spans and must be called before
+// Packer exits.
+func SetupTracing(ctx context.Context) (shutdown func(context.Context) error, err error) {
+	noop := func(context.Context) error { return nil }
+	if os.Getenv("OTEL_TRACES_EXPORTER") == "none" {
+		return noop, nil
+	}
+
+	var opts []sdktrace.TracerProviderOption
+	if otlpTracingEnabled() {
+		exporter, err := newOTLPExporter(ctx)
+		if err != nil {
+			return noop, fmt.Errorf("failed to set up the OTLP trace exporter: %s", err)
+		}
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	}
+	var tracesFile *os.File
+	if path := os.Getenv(TracesFileEnvVar); path != "" {
+		tracesFile, err = os.Create(path)
+		if err != nil {
+			return noop, fmt.Errorf("failed to create the traces file: %s", err)
+		}
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(tracesFile))
+		if err != nil {
+			tracesFile.Close()
+			return noop, err
+		}
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	}
+	if len(opts) == 0 {
+		return noop, nil
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithSchemaURL(semconv.SchemaURL),
+		resource.WithAttributes(
+			semconv.ServiceName("packer"),
+			semconv.ServiceVersion(packerVersion.FormattedVersion()),
+		),
+		resource.WithFromEnv(),
+	)
+	if err != nil {
+		return noop, err
+	}
+	provider := sdktrace.NewTracerProvider(append(opts, sdktrace.WithResource(res))...)
+	otel.SetTracerProvider(provider)
+
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if tracesFile != nil {
+			err = errors.Join(err, tracesFile.Close())
+		}
+		return err
+	}, nil
+}
+
+func otlpTracingEnabled() bool {
+	if os.Getenv("OTEL_TRACES_EXPORTER") == "otlp" {
+		return true
+	}
+	return os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" ||
+		os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") != ""
+}
+
+func newOTLPExporter(ctx context.Context) (*otlptrace.Exporter, error) {
+	protocol := os.Getenv("OTEL_EXPORTER_OTLP_TRACES_PROTOCOL")
+	if protocol == "" {
+		protocol = os.Getenv("OTEL_EXPORTER_OTLP_PROTOCOL")
+	}
+	if protocol == "grpc" {
+		return otlptracegrpc.New(ctx)
+	}
+	return otlptracehttp.New(ctx)
+}
+
+// StartTrace starts the span of a Packer command run on the template at
+// templatePath, the root of the spans of its builds.
+func StartTrace(ctx context.Context, command, templatePath string) (context.Context, trace.Span) {
+	return tracer().Start(ctx, "packer "+command, trace.WithAttributes(
+		attrTemplatePath.String(templatePath),
+	))
+}
+
+func tracer() trace.Tracer {
+	return otel.Tracer(tracerName, trace.WithInstrumentationVersion(packerVersion.FormattedVersion()))
+}
+
+// endSpan ends span, with an error status if err is set.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		msg := reportError(err)
+		span.RecordError(errors.New(msg))
+		span.SetStatus(codes.Error, msg)
+	}
+	span.End()
+}
+
+// traceStep records the run of a provisioner or post-processor as a span
+// started and ended at the times of step.
+func traceStep(ctx context.Context, kind string, step StepReport) {
+	name := step.Name
+	if name == "" {
+		name = step.Type
+	}
+	_, span := tracer().Start(ctx, kind+" "+name,
+		trace.WithTimestamp(step.Start),
+		trace.WithAttributes(
+			attrComponentType.String(step.Type),
+			attrComponentName.String(step.Name),
+			attrOutcome.String(step.Outcome),
+		),
+	)
+	if step.Error != "" {
+		span.RecordError(errors.New(step.Error))
+		span.SetStatus(codes.Error, step.Error)
+	}
+	end := step.Start.Add(time.Duration(step.DurationSeconds * float64(time.Second)))
+	span.End(trace.WithTimestamp(end))
+}
@@ -0,0 +1,151 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package packer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// recordSpans sets a tracer provider recording the spans for the duration of
+// the test.
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return recorder
+}
+
+func TestBuild_Run_tracing(t *testing.T) {
+	recorder := recordSpans(t)
+	build := testBuild()
+	build.Prepare()
+
+	ctx, root := StartTrace(context.Background(), "build", "template.pkr.hcl")
+	if _, err := build.Run(ctx, testUi()); err != nil {
+		t.Fatal(err)
+	}
+	root.End()
+
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+	}
+	parents := map[string]string{
+		"build test":                   "packer build",
+		"builder foo":                  "build test",
+		"provisioner mock-provisioner": "builder foo",
+		"post-processor testPPName":    "build test",
+	}
+	for name, parent := range parents {
+		span, ok := spans[name]
+		if !ok {
+			t.Errorf("expected a %q span, got %v", name, spanNames(recorder.Ended()))
+			continue
+		}
+		if span.Parent().SpanID() != spans[parent].SpanContext().SpanID() {
+			t.Errorf("expected %q to be a child of %q", name, parent)
+		}
+		if span.SpanContext().TraceID() != root.SpanContext().TraceID() {
+			t.Errorf("expected %q to be part of the trace of the command", name)
+		}
+	}
+
+	pp := spans["post-processor testPPName"]
+	if !hasAttribute(pp, attrComponentType.String("testPP")) || !hasAttribute(pp, attrOutcome.String(OutcomeSucceeded)) {
+		t.Errorf("unexpected post-processor attributes: %v", pp.Attributes())
+	}
+}
+
+func TestBuild_Run_tracingError(t *testing.T) {
+	recorder := recordSpans(t)
+	build := testBuild()
+	build.Builder = &packersdk.MockBuilder{RunErrResult: true}
+	build.Prepare()
+
+	if _, err := build.Run(context.Background(), testUi()); err == nil {
+		t.Fatal("expected the build to fail")
+	}
+
+	for _, span := range recorder.Ended() {
+		if span.Status().Code != codes.Error {
+			t.Errorf("expected span %q to have an error status, got %v", span.Name(), span.Status())
+		}
+	}
+}
+
+func TestSetupTracing_file(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "traces.json")
+	t.Setenv(TracesFileEnvVar, path)
+	t.Setenv("OTEL_TRACES_EXPORTER", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "")
+	previous := otel.GetTracerProvider()
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	shutdown, err := SetupTracing(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, span := StartTrace(context.Background(), "build", "template.pkr.hcl")
+	span.End()
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{`"Name":"packer build"`, `"Value":"template.pkr.hcl"`} {
+		if !strings.Contains(string(raw), expected) {
+			t.Errorf("expected %s in the traces file, got %s", expected, raw)
+		}
+	}
+}
+
+func TestSetupTracing_disabled(t *testing.T) {
+	t.Setenv(TracesFileEnvVar, filepath.Join(t.TempDir(), "traces.json"))
+	t.Setenv("OTEL_TRACES_EXPORTER", "none")
+	previous := otel.GetTracerProvider()
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	if _, err := SetupTracing(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if otel.GetTracerProvider() != previous {
+		t.Error("expected no tracer provider to be set")
+	}
+	if _, err := os.Stat(os.Getenv(TracesFileEnvVar)); !os.IsNotExist(err) {
+		t.Error("expected no traces file to be written")
+	}
+}
+
+func spanNames(spans []sdktrace.ReadOnlySpan) []string {
+	var names []string
+	for _, span := range spans {
+		names = append(names, span.Name())
+	}
+	return names
+}
+
+func hasAttribute(span sdktrace.ReadOnlySpan, kv attribute.KeyValue) bool {
+	for _, attr := range span.Attributes() {
+		if attr == kv {
+			return true
+		}
+	}
+	return false
+}
@@ -121,18 +121,52 @@ func (u *TargetedUI) Ask(query string) (string, error) {
 	return u.Ui.Ask(u.prefixLines(true, query))
 }
 
+// targetedMessageWriter is implemented by UIs that keep track of the target
+// of a message themselves, rather than having it prefixed by the TargetedUI.
+type targetedMessageWriter interface {
+	targetedMessage(target, kind, message string)
+}
+
 func (u *TargetedUI) Say(message string) {
+	if tw, ok := u.Ui.(targetedMessageWriter); ok {
+		tw.targetedMessage(u.Target, "say", message)
+		return
+	}
 	u.Ui.Say(u.prefixLines(true, message))
 }
 
 func (u *TargetedUI) Message(message string) {
+	if tw, ok := u.Ui.(targetedMessageWriter); ok {
+		tw.targetedMessage(u.Target, "message", message)
+		return
+	}
 	u.Ui.Message(u.prefixLines(false, message))
 }
 
 func (u *TargetedUI) Error(message string) {
+	if tw, ok := u.Ui.(targetedMessageWriter); ok {
+		tw.targetedMessage(u.Target, "error", message)
+		return
+	}
 	u.Ui.Error(u.prefixLines(true, message))
 }
 
+// machineEventWriter is implemented by UIs that output the events reporting
+// the progress of a build. These events are sent with machineEvent rather than
+// Machine, so that they are not part of the -machine-readable output.
+type machineEventWriter interface {
+	machineEvent(target, category string, args ...string)
+}
+
+func (u *TargetedUI) machineEvent(target, category string, args ...string) {
+	if ew, ok := u.Ui.(machineEventWriter); ok {
+		if target == "" {
+			target = u.Target
+		}
+		ew.machineEvent(target, category, args...)
+	}
+}
+
 func (u *TargetedUI) Machine(t string, args ...string) {
 	// Prefix in the target, then pass through
 	u.Ui.Machine(fmt.Sprintf("%s,%s", u.Target, t), args...)
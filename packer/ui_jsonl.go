@@ -0,0 +1,220 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package packer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// Machine-readable message types reporting the progress of a build.
+const (
+	MachineBuildStart         = "build-start"
+	MachineBuildEnd           = "build-end"
+	MachineProvisionerStart   = "provisioner-start"
+	MachineProvisionerEnd     = "provisioner-end"
+	MachinePostProcessorStart = "post-processor-start"
+	MachinePostProcessorEnd   = "post-processor-end"
+	MachineArtifactProduced   = "artifact-produced"
+)
+
+// UiEvent is a single line of the output of the JSONLinesUi.
+type UiEvent struct {
+	Time  time.Time `json:"time"`
+	Type  string    `json:"type"`
+	Build string    `json:"build,omitempty"`
+
+	// Message is set for ui messages.
+	Message string `json:"message,omitempty"`
+
+	// Builder is set for build events.
+	Builder string `json:"builder,omitempty"`
+	// PType and PName are set for provisioner and post-processor events.
+	PType string `json:"ptype,omitempty"`
+	PName string `json:"pname,omitempty"`
+	// DurationSeconds is set for events ending a step.
+	DurationSeconds *float64 `json:"duration_seconds,omitempty"`
+	// Error is set for events ending a step that failed.
+	Error string `json:"error,omitempty"`
+
+	Artifact *UiEventArtifact `json:"artifact,omitempty"`
+
+	// Data holds the arguments of machine-readable messages without a
+	// dedicated representation.
+	Data []string `json:"data,omitempty"`
+}
+
+// UiEventArtifact describes an artifact produced by a build.
+type UiEventArtifact struct {
+	BuilderID string   `json:"builder_id"`
+	ID        string   `json:"id"`
+	Files     []string `json:"files"`
+}
+
+// JSONLinesUi is a UI that outputs one JSON encoded UiEvent per line to the
+// given Writer.
+type JSONLinesUi struct {
+	Writer io.Writer
+	PB     packersdk.NoopProgressTracker
+
+	l sync.Mutex
+}
+
+var _ packersdk.Ui = new(JSONLinesUi)
+
+func (u *JSONLinesUi) Ask(query string) (string, error) {
+	return "", errors.New("jsonl UI can't ask")
+}
+
+func (u *JSONLinesUi) Say(message string) {
+	u.targetedMessage("", "say", message)
+}
+
+func (u *JSONLinesUi) Message(message string) {
+	u.targetedMessage("", "message", message)
+}
+
+func (u *JSONLinesUi) Error(message string) {
+	u.targetedMessage("", "error", message)
+}
+
+// targetedMessage is called by the TargetedUI so that messages are not
+// prefixed with their target.
+func (u *JSONLinesUi) targetedMessage(target, kind, message string) {
+	u.write(UiEvent{
+		Type:    "ui-" + kind,
+		Build:   target,
+		Message: packersdk.LogSecretFilter.FilterString(message),
+	})
+}
+
+// machineEvent is called with the events reporting the progress of a build.
+func (u *JSONLinesUi) machineEvent(target, category string, args ...string) {
+	if target != "" {
+		category = target + "," + category
+	}
+	u.Machine(category, args...)
+}
+
+func (u *JSONLinesUi) Machine(category string, args ...string) {
+	// Determine if we have a target, and set it
+	target := ""
+	commaIdx := strings.Index(category, ",")
+	if commaIdx > -1 {
+		target = category[0:commaIdx]
+		category = category[commaIdx+1:]
+	}
+
+	for i := range args {
+		args[i] = packersdk.LogSecretFilter.FilterString(args[i])
+	}
+
+	event := UiEvent{
+		Type:  category,
+		Build: target,
+	}
+
+	switch category {
+	case MachineBuildStart:
+		event.Builder = argAt(args, 0)
+	case MachineBuildEnd:
+		event.DurationSeconds = parseDurationArg(argAt(args, 0))
+		event.Error = argAt(args, 1)
+	case MachineProvisionerStart, MachinePostProcessorStart:
+		event.PType = argAt(args, 0)
+		event.PName = argAt(args, 1)
+	case MachineProvisionerEnd, MachinePostProcessorEnd:
+		event.PType = argAt(args, 0)
+		event.PName = argAt(args, 1)
+		event.DurationSeconds = parseDurationArg(argAt(args, 2))
+		event.Error = argAt(args, 3)
+	case MachineArtifactProduced:
+		event.Artifact = &UiEventArtifact{
+			BuilderID: argAt(args, 0),
+			ID:        argAt(args, 1),
+			Files:     []string{},
+		}
+		if len(args) > 2 {
+			event.Artifact.Files = args[2:]
+		}
+	default:
+		event.Data = args
+	}
+
+	u.write(event)
+}
+
+func (u *JSONLinesUi) TrackProgress(src string, currentSize, totalSize int64, stream io.ReadCloser) (body io.ReadCloser) {
+	return u.PB.TrackProgress(src, currentSize, totalSize, stream)
+}
+
+func (u *JSONLinesUi) write(event UiEvent) {
+	event.Time = time.Now().UTC()
+
+	b, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("[ERR] failed to encode ui event: %s", err)
+		return
+	}
+
+	u.l.Lock()
+	defer u.l.Unlock()
+
+	_, err = fmt.Fprintf(u.Writer, "%s\n", b)
+	if err != nil {
+		if err == syscall.EPIPE || strings.Contains(err.Error(), "broken pipe") {
+			// Ignore epipe errors because that just means that the file
+			// is probably closed or going to /dev/null or something.
+		} else {
+			panic(err)
+		}
+	}
+	log.Printf("%s", b)
+}
+
+func argAt(args []string, i int) string {
+	if i < len(args) {
+		return args[i]
+	}
+	return ""
+}
+
+func parseDurationArg(arg string) *float64 {
+	d, err := strconv.ParseFloat(arg, 64)
+	if err != nil {
+		return nil
+	}
+	return &d
+}
+
+// machineEvent sends an event reporting the progress of a build to ui, when
+// it outputs them. Nothing is sent when ui is nil.
+func machineEvent(ui packersdk.Ui, category string, args ...string) {
+	if ew, ok := ui.(machineEventWriter); ok {
+		ew.machineEvent("", category, args...)
+	}
+}
+
+// machineDuration formats d for the machine-readable step events.
+func machineDuration(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', 3, 64)
+}
+
+// machineError formats err for the machine-readable step events.
+func machineError(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
@@ -0,0 +1,137 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package packer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+func TestJSONLinesUi_ImplUi(t *testing.T) {
+	var raw interface{}
+	raw = &JSONLinesUi{}
+	if _, ok := raw.(packersdk.Ui); !ok {
+		t.Fatalf("JSONLinesUi must implement Ui")
+	}
+}
+
+func decodeUiEvents(t *testing.T, buf *bytes.Buffer) []UiEvent {
+	t.Helper()
+
+	var events []UiEvent
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var event UiEvent
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			t.Fatalf("failed to decode event %q: %s", line, err)
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+func TestJSONLinesUi(t *testing.T) {
+	buf := new(bytes.Buffer)
+	ui := &JSONLinesUi{Writer: buf}
+	targeted := &TargetedUI{Target: "null.foo", Ui: ui}
+
+	ui.Say("hello")
+	targeted.Error("bad\nthings")
+	targeted.Machine(MachineProvisionerEnd, "shell", "setup", "1.500", "")
+	targeted.Machine(MachineArtifactProduced, "packer.null", "id", "a.txt", "b.txt")
+	targeted.Machine("artifact-count", "1")
+
+	duration := 1.5
+	expected := []UiEvent{
+		{Type: "ui-say", Message: "hello"},
+		{Type: "ui-error", Build: "null.foo", Message: "bad\nthings"},
+		{Type: MachineProvisionerEnd, Build: "null.foo", PType: "shell", PName: "setup", DurationSeconds: &duration},
+		{Type: MachineArtifactProduced, Build: "null.foo", Artifact: &UiEventArtifact{
+			BuilderID: "packer.null",
+			ID:        "id",
+			Files:     []string{"a.txt", "b.txt"},
+		}},
+		{Type: "artifact-count", Build: "null.foo", Data: []string{"1"}},
+	}
+	events := decodeUiEvents(t, buf)
+	if diff := cmp.Diff(expected, events, cmpopts.IgnoreFields(UiEvent{}, "Time")); diff != "" {
+		t.Fatalf("unexpected events: %s", diff)
+	}
+}
+
+func TestJSONLinesUi_build(t *testing.T) {
+	buf := new(bytes.Buffer)
+	ui := &JSONLinesUi{Writer: buf}
+
+	build := testBuild()
+	build.Provisioners[0].PName = "setup"
+	if _, err := build.Prepare(); err != nil {
+		t.Fatalf("bad error: %s", err)
+	}
+
+	if _, err := build.Run(context.Background(), ui); err != nil {
+		t.Fatalf("bad error: %s", err)
+	}
+
+	var types []string
+	for _, event := range decodeUiEvents(t, buf) {
+		if strings.HasPrefix(event.Type, "ui-") {
+			continue
+		}
+		if event.Build != "test" {
+			t.Errorf("event %q should target the build, got %q", event.Type, event.Build)
+		}
+		if event.Type == MachineProvisionerStart && (event.PType != "mock-provisioner" || event.PName != "setup") {
+			t.Errorf("unexpected provisioner event: %#v", event)
+		}
+		types = append(types, event.Type)
+	}
+
+	expected := []string{
+		MachineBuildStart,
+		MachineProvisionerStart,
+		MachineProvisionerEnd,
+		MachinePostProcessorStart,
+		MachinePostProcessorEnd,
+		MachineArtifactProduced,
+		MachineArtifactProduced,
+		MachineBuildEnd,
+	}
+	if diff := cmp.Diff(expected, types); diff != "" {
+		t.Fatalf("unexpected events: %s", diff)
+	}
+}
+
+func TestMachineReadableUi_buildEvents(t *testing.T) {
+	buf := new(bytes.Buffer)
+	ui := &MachineReadableUi{Writer: buf}
+
+	build := testBuild()
+	if _, err := build.Prepare(); err != nil {
+		t.Fatalf("bad error: %s", err)
+	}
+	if _, err := build.Run(context.Background(), ui); err != nil {
+		t.Fatalf("bad error: %s", err)
+	}
+
+	for _, category := range []string{
+		MachineBuildStart,
+		MachineBuildEnd,
+		MachineProvisionerStart,
+		MachineProvisionerEnd,
+		MachinePostProcessorStart,
+		MachinePostProcessorEnd,
+		MachineArtifactProduced,
+	} {
+		if strings.Contains(buf.String(), ","+category+",") {
+			t.Errorf("the machine-readable output should not contain %q events: %s", category, buf.String())
+		}
+	}
+}
@@ -16,12 +16,38 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
 
 	"github.com/hashicorp/hcl/v2/hcldec"
 	"github.com/hashicorp/packer-plugin-sdk/common"
 	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
 	"github.com/hashicorp/packer-plugin-sdk/template/config"
 	"github.com/hashicorp/packer-plugin-sdk/template/interpolate"
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/sha3"
+	"golang.org/x/sync/errgroup"
+	"lukechampine.com/blake3"
+)
+
+// Formats of the checksum files.
+const (
+	// FormatDefault writes one `<checksum>\t<file>` line per file.
+	FormatDefault = ""
+	// FormatGNU writes files that can be checked with the GNU coreutils
+	// `sha256sum -c` family of commands.
+	FormatGNU = "gnu"
+	// FormatBSD writes BSD-style tagged lines, `SHA256 (<file>) = <checksum>`,
+	// that can be checked with `sha256sum -c` or `cksum -c` and can hold
+	// several checksum types.
+	FormatBSD = "bsd"
+)
+
+// Ways of writing the paths of the checksummed files.
+const (
+	PathBase     = "base"
+	PathRelative = "relative"
+	PathAbsolute = "absolute"
 )
 
 type Config struct {
@@ -29,6 +55,8 @@ type Config struct {
 
 	ChecksumTypes []string `mapstructure:"checksum_types"`
 	OutputPath    string   `mapstructure:"output"`
+	Format        string   `mapstructure:"format"`
+	Paths         string   `mapstructure:"paths"`
 	ctx           interpolate.Context
 }
 
@@ -36,23 +64,44 @@ type PostProcessor struct {
 	config Config
 }
 
+// hashType describes a supported checksum type.
+type hashType struct {
+	// tag is the name of the algorithm in BSD-style tagged lines.
+	tag string
+	new func() hash.Hash
+}
+
+var hashTypes = map[string]hashType{
+	"md5":         {"MD5", md5.New},
+	"sha1":        {"SHA1", sha1.New},
+	"sha224":      {"SHA224", sha256.New224},
+	"sha256":      {"SHA256", sha256.New},
+	"sha384":      {"SHA384", sha512.New384},
+	"sha512":      {"SHA512", sha512.New},
+	"sha3-224":    {"SHA3-224", sha3.New224},
+	"sha3-256":    {"SHA3-256", sha3.New256},
+	"sha3-384":    {"SHA3-384", sha3.New384},
+	"sha3-512":    {"SHA3-512", sha3.New512},
+	"blake2b-256": {"BLAKE2b-256", newBlake2b(blake2b.Size256)},
+	"blake2b-384": {"BLAKE2b-384", newBlake2b(blake2b.Size384)},
+	"blake2b-512": {"BLAKE2b", newBlake2b(blake2b.Size)},
+	"blake3":      {"BLAKE3", func() hash.Hash { return blake3.New(32, nil) }},
+}
+
+func newBlake2b(size int) func() hash.Hash {
+	return func() hash.Hash {
+		// New only fails with an invalid size or a key that is too long.
+		h, _ := blake2b.New(size, nil)
+		return h
+	}
+}
+
 func getHash(t string) hash.Hash {
-	var h hash.Hash
-	switch t {
-	case "md5":
-		h = md5.New()
-	case "sha1":
-		h = sha1.New()
-	case "sha224":
-		h = sha256.New224()
-	case "sha256":
-		h = sha256.New()
-	case "sha384":
-		h = sha512.New384()
-	case "sha512":
-		h = sha512.New()
-	}
-	return h
+	ht, ok := hashTypes[t]
+	if !ok {
+		return nil
+	}
+	return ht.new()
 }
 
 func (p *PostProcessor) ConfigSpec() hcldec.ObjectSpec { return p.config.FlatMapstructure().HCL2Spec() }
@@ -82,6 +131,22 @@ func (p *PostProcessor) Configure(raws ...interface{}) error {
 		}
 	}
 
+	switch p.config.Format {
+	case FormatDefault, FormatGNU, FormatBSD:
+	default:
+		errs = packersdk.MultiErrorAppend(errs,
+			fmt.Errorf("Unrecognized format: %s, expected one of %q or %q", p.config.Format, FormatGNU, FormatBSD))
+	}
+
+	switch p.config.Paths {
+	case "":
+		p.config.Paths = PathBase
+	case PathBase, PathRelative, PathAbsolute:
+	default:
+		errs = packersdk.MultiErrorAppend(errs,
+			fmt.Errorf("Unrecognized paths: %s, expected one of %q, %q or %q", p.config.Paths, PathBase, PathRelative, PathAbsolute))
+	}
+
 	if p.config.OutputPath == "" {
 		p.config.OutputPath = "packer_{{.BuildName}}_{{.BuilderType}}_{{.ChecksumType}}.checksum"
 	}
@@ -100,7 +165,6 @@ func (p *PostProcessor) Configure(raws ...interface{}) error {
 
 func (p *PostProcessor) PostProcess(ctx context.Context, ui packersdk.Ui, artifact packersdk.Artifact) (packersdk.Artifact, bool, bool, error) {
 	files := artifact.Files()
-	var h hash.Hash
 
 	var generatedData map[interface{}]interface{}
 	stateData := artifact.State("generated_data")
@@ -118,46 +182,163 @@ func (p *PostProcessor) PostProcess(ctx context.Context, ui packersdk.Ui, artifa
 
 	newartifact := NewArtifact(artifact.Files())
 
-	for _, ct := range p.config.ChecksumTypes {
-		h = getHash(ct)
+	sums, err := p.computeChecksums(ctx, files)
+	if err != nil {
+		return nil, false, true, err
+	}
+
+	// written maps the checksum files written to the checksum type they
+	// contain, GNU-style files can only contain one.
+	written := map[string]string{}
+	for i, ct := range p.config.ChecksumTypes {
 		generatedData["ChecksumType"] = ct
 		p.config.ctx.Data = generatedData
 
-		for _, art := range files {
-			checksumFile, err := interpolate.Render(p.config.OutputPath, &p.config.ctx)
+		checksumFile, err := interpolate.Render(p.config.OutputPath, &p.config.ctx)
+		if err != nil {
+			return nil, false, true, err
+		}
+		if prev, ok := written[checksumFile]; ok && prev != ct && p.config.Format == FormatGNU {
+			return nil, false, true, fmt.Errorf(
+				"can't write both %s and %s checksums in %s with the gnu format, "+
+					"use {{.ChecksumType}} in output or the bsd format", prev, ct, checksumFile)
+		}
+		written[checksumFile] = ct
+
+		if _, err := os.Stat(checksumFile); err != nil {
+			newartifact.files = append(newartifact.files, checksumFile)
+		}
+		if err := os.MkdirAll(filepath.Dir(checksumFile), os.FileMode(0755)); err != nil {
+			return nil, false, true, fmt.Errorf("unable to create dir: %s", err.Error())
+		}
+
+		var lines strings.Builder
+		for j, art := range files {
+			name, err := p.checksumPath(checksumFile, art)
 			if err != nil {
 				return nil, false, true, err
 			}
+			lines.WriteString(p.checksumLine(ct, sums[j][i], name))
+		}
 
-			if _, err := os.Stat(checksumFile); err != nil {
-				newartifact.files = append(newartifact.files, checksumFile)
-			}
-			if err := os.MkdirAll(filepath.Dir(checksumFile), os.FileMode(0755)); err != nil {
-				return nil, false, true, fmt.Errorf("unable to create dir: %s", err.Error())
-			}
-			fw, err := os.OpenFile(checksumFile, os.O_WRONLY|os.O_APPEND|os.O_CREATE, os.FileMode(0644))
-			if err != nil {
-				return nil, false, true, fmt.Errorf("unable to create file %s: %s", checksumFile, err.Error())
+		fw, err := os.OpenFile(checksumFile, os.O_WRONLY|os.O_APPEND|os.O_CREATE, os.FileMode(0644))
+		if err != nil {
+			return nil, false, true, fmt.Errorf("unable to create file %s: %s", checksumFile, err.Error())
+		}
+		if _, err := fw.WriteString(lines.String()); err != nil {
+			fw.Close()
+			return nil, false, true, fmt.Errorf("unable to write file %s: %s", checksumFile, err.Error())
+		}
+		if err := fw.Close(); err != nil {
+			return nil, false, true, fmt.Errorf("unable to write file %s: %s", checksumFile, err.Error())
+		}
+	}
+
+	// sets keep and forceOverride to true because we don't want to accidentally
+	// delete the very artifact we're checksumming.
+	return newartifact, true, true, nil
+}
+
+// computeChecksums reads each file once to compute all its checksums. Files
+// are hashed in parallel. The checksums are indexed by file and then by
+// checksum type, in the order of the configuration.
+func (p *PostProcessor) computeChecksums(ctx context.Context, files []string) ([][][]byte, error) {
+	sums := make([][][]byte, len(files))
+
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(runtime.GOMAXPROCS(-1))
+	for i, art := range files {
+		i, art := i, art
+		g.Go(func() error {
+			hashes := make([]hash.Hash, len(p.config.ChecksumTypes))
+			writers := make([]io.Writer, len(hashes))
+			for j, ct := range p.config.ChecksumTypes {
+				hashes[j] = getHash(ct)
+				writers[j] = hashes[j]
 			}
+
 			fr, err := os.Open(art)
 			if err != nil {
-				fw.Close()
-				return nil, false, true, fmt.Errorf("unable to open file %s: %s", art, err.Error())
+				return fmt.Errorf("unable to open file %s: %s", art, err.Error())
 			}
+			defer fr.Close()
 
-			if _, err = io.Copy(h, fr); err != nil {
-				fr.Close()
-				fw.Close()
-				return nil, false, true, fmt.Errorf("unable to compute %s hash for %s", ct, art)
+			if _, err := io.Copy(io.MultiWriter(writers...), &contextReader{ctx: ctx, r: fr}); err != nil {
+				return fmt.Errorf("unable to compute %s hash for %s: %s",
+					strings.Join(p.config.ChecksumTypes, ", "), art, err)
 			}
-			fr.Close()
-			fw.WriteString(fmt.Sprintf("%x\t%s\n", h.Sum(nil), filepath.Base(art)))
-			fw.Close()
-			h.Reset()
+
+			sums[i] = make([][]byte, len(hashes))
+			for j, h := range hashes {
+				sums[i][j] = h.Sum(nil)
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	return sums, nil
+}
+
+// checksumPath returns how art is referred to in checksumFile.
+func (p *PostProcessor) checksumPath(checksumFile, art string) (string, error) {
+	switch p.config.Paths {
+	case PathRelative:
+		dir, err := filepath.Abs(filepath.Dir(checksumFile))
+		if err != nil {
+			return "", err
+		}
+		abs, err := filepath.Abs(art)
+		if err != nil {
+			return "", err
+		}
+		rel, err := filepath.Rel(dir, abs)
+		if err != nil {
+			return "", fmt.Errorf("unable to make %s relative to %s: %s", art, checksumFile, err)
+		}
+		return filepath.ToSlash(rel), nil
+	case PathAbsolute:
+		abs, err := filepath.Abs(art)
+		if err != nil {
+			return "", err
 		}
+		return filepath.ToSlash(abs), nil
+	default:
+		return filepath.Base(art), nil
 	}
+}
 
-	// sets keep and forceOverride to true because we don't want to accidentally
-	// delete the very artifact we're checksumming.
-	return newartifact, true, true, nil
+// checksumLine formats the checksum of the file called name.
+func (p *PostProcessor) checksumLine(ct string, sum []byte, name string) string {
+	switch p.config.Format {
+	case FormatGNU, FormatBSD:
+		// Like coreutils, escape names containing a backslash or a new line
+		// and flag the line with a leading backslash.
+		prefix := ""
+		if strings.ContainsAny(name, "\\\n") {
+			prefix = "\\"
+			name = strings.NewReplacer("\\", "\\\\", "\n", "\\n").Replace(name)
+		}
+		if p.config.Format == FormatBSD {
+			return fmt.Sprintf("%s%s (%s) = %x\n", prefix, hashTypes[ct].tag, name, sum)
+		}
+		return fmt.Sprintf("%s%x  %s\n", prefix, sum, name)
+	default:
+		return fmt.Sprintf("%x\t%s\n", sum, name)
+	}
+}
+
+// contextReader stops reading once its context is cancelled, so that the
+// other files stop being hashed when one fails.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r *contextReader) Read(b []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(b)
 }
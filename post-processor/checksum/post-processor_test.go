@@ -45,6 +45,90 @@ func TestChecksumSHA1(t *testing.T) {
 	defer f.Close()
 }
 
+func TestChecksumFormats(t *testing.T) {
+	const (
+		sha256sum = "c0535e4be2b79ffd93291305436bf889314e4a3faec05ecffcbb7df31ad9e51a"
+		blake3sum = "793c10bc0b28c378330d39edace7260af9da81d603b8ffede2706a21eda893f4"
+	)
+	tc := []struct {
+		name   string
+		config string
+		output string
+		want   string
+	}{
+		{
+			name:   "gnu",
+			config: `"checksum_types": ["sha256"], "format": "gnu", "output": "SHA256SUMS"`,
+			output: "SHA256SUMS",
+			want:   sha256sum + "  package.txt\n",
+		},
+		{
+			name:   "bsd with several types",
+			config: `"checksum_types": ["sha256", "blake3"], "format": "bsd", "output": "CHECKSUMS"`,
+			output: "CHECKSUMS",
+			want: "SHA256 (package.txt) = " + sha256sum + "\n" +
+				"BLAKE3 (package.txt) = " + blake3sum + "\n",
+		},
+		{
+			name:   "relative paths",
+			config: `"checksum_types": ["sha256"], "format": "gnu", "paths": "relative", "output": "sums/SHA256SUMS"`,
+			output: "sums/SHA256SUMS",
+			want:   sha256sum + "  ../package.txt\n",
+		},
+	}
+	for _, tt := range tc {
+		t.Run(tt.name, func(t *testing.T) {
+			config := fmt.Sprintf(`{"post-processors": [{"type": "checksum", %s}]}`, tt.config)
+			artifact := testChecksum(t, config)
+			defer artifact.Destroy()
+			defer os.RemoveAll("sums")
+
+			buf, err := os.ReadFile(tt.output)
+			if err != nil {
+				t.Fatalf("Unable to read checksum file: %s", err)
+			}
+			if string(buf) != tt.want {
+				t.Errorf("Unexpected checksum file, expected:\n%s\nfound:\n%s", tt.want, buf)
+			}
+		})
+	}
+}
+
+func TestChecksumGNUSingleType(t *testing.T) {
+	ui, artifact, err := setup(t)
+	if err != nil {
+		t.Fatalf("Error bootstrapping test: %s", err)
+	}
+	defer artifact.Destroy()
+
+	checksum := PostProcessor{}
+	err = checksum.Configure(map[string]interface{}{
+		"checksum_types": []string{"sha256", "sha3-256"},
+		"format":         "gnu",
+		"output":         "SUMS",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove("SUMS")
+
+	_, _, _, err = checksum.PostProcess(context.Background(), ui, artifact)
+	if err == nil {
+		t.Fatal("Expected an error when writing two checksum types in a gnu file")
+	}
+}
+
+func TestChecksumTypes(t *testing.T) {
+	for ct := range hashTypes {
+		if getHash(ct) == nil {
+			t.Errorf("No hash for %s", ct)
+		}
+	}
+	if getHash("crc32") != nil {
+		t.Error("Expected no hash for an unknown checksum type")
+	}
+}
+
 // Test Helpers
 
 func setup(t *testing.T) (packersdk.Ui, packersdk.Artifact, error) {
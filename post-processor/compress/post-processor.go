@@ -15,6 +15,8 @@ import (
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"sort"
+	"time"
 
 	"github.com/biogo/hts/bgzf"
 	"github.com/dsnet/compress/bzip2"
@@ -23,6 +25,7 @@ import (
 	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
 	"github.com/hashicorp/packer-plugin-sdk/template/config"
 	"github.com/hashicorp/packer-plugin-sdk/template/interpolate"
+	"github.com/klauspost/compress/zstd"
 	"github.com/klauspost/pgzip"
 	"github.com/pierrec/lz4/v4"
 	"github.com/ulikunitz/xz"
@@ -37,6 +40,9 @@ var (
 	ErrWrongInputCount = fmt.Errorf(
 		"Can only have 1 input file when not using tar/zip")
 
+	ErrDeterministicNotTar = fmt.Errorf(
+		"deterministic is only supported when creating a tar archive")
+
 	filenamePattern = regexp.MustCompile(`(?:\.([a-z0-9]+))`)
 )
 
@@ -47,12 +53,20 @@ type Config struct {
 	OutputPath       string `mapstructure:"output"`
 	Format           string `mapstructure:"format"`
 	CompressionLevel int    `mapstructure:"compression_level"`
+	// Create tar archives that only depend on the content and permissions
+	// of the files: entries are sorted by name, owners are zeroed and all
+	// modification times are set to `mtime`.
+	Deterministic bool `mapstructure:"deterministic"`
+	// The modification time, in RFC3339 format, set on the entries of a
+	// deterministic archive. Defaults to the Unix epoch.
+	Mtime string `mapstructure:"mtime"`
 
 	// Derived fields
 	Archive   string
 	Algorithm string
 
-	ctx interpolate.Context
+	mtime time.Time
+	ctx   interpolate.Context
 }
 
 type PostProcessor struct {
@@ -102,6 +116,18 @@ func (p *PostProcessor) Configure(raws ...interface{}) error {
 
 	p.config.detectFromFilename()
 
+	p.config.mtime = time.Unix(0, 0).UTC()
+	if p.config.Mtime != "" {
+		p.config.mtime, err = time.Parse(time.RFC3339, p.config.Mtime)
+		if err != nil {
+			errs = packersdk.MultiErrorAppend(
+				errs, fmt.Errorf("Error parsing mtime: %s", err))
+		}
+	}
+	if p.config.Deterministic && p.config.Archive != "tar" {
+		errs = packersdk.MultiErrorAppend(errs, ErrDeterministicNotTar)
+	}
+
 	if len(errs.Errors) > 0 {
 		return errs
 	}
@@ -188,6 +214,14 @@ func (p *PostProcessor) PostProcess(
 			return nil, false, false, fmt.Errorf(errTmpl, p.config.Algorithm, err)
 		}
 		defer output.Close()
+	case "zstd":
+		ui.Say(fmt.Sprintf("Using zstd compression with %d cores for %s",
+			runtime.GOMAXPROCS(-1), target))
+		output, err = makeZstdWriter(outputFile, p.config.CompressionLevel)
+		if err != nil {
+			return nil, false, false, fmt.Errorf(errTmpl, p.config.Algorithm, err)
+		}
+		defer output.Close()
 	case "pgzip":
 		ui.Say(fmt.Sprintf("Using pgzip compression with %d cores for %s",
 			runtime.GOMAXPROCS(-1), target))
@@ -210,7 +244,11 @@ func (p *PostProcessor) PostProcess(
 	switch p.config.Archive {
 	case "tar":
 		ui.Say(fmt.Sprintf("Tarring %s with %s", target, compression))
-		err = createTarArchive(artifact.Files(), output)
+		if p.config.Deterministic {
+			err = createDeterministicTarArchive(artifact.Files(), output, p.config.mtime)
+		} else {
+			err = createTarArchive(artifact.Files(), output)
+		}
 		if err != nil {
 			return nil, false, false, fmt.Errorf("Error creating tar: %s", err)
 		}
@@ -261,6 +299,7 @@ func (config *Config) detectFromFilename() {
 		"bgzf":  "bgzf",
 		"xz":    "xz",
 		"bzip2": "bzip2",
+		"zst":   "zstd",
 	}
 
 	if config.Format == "" {
@@ -375,6 +414,32 @@ func makePgzipWriter(output io.WriteCloser, compressionLevel int) (io.WriteClose
 	return gzipWriter, nil
 }
 
+func makeZstdWriter(output io.WriteCloser, compressionLevel int) (io.WriteCloser, error) {
+	levels := map[int]zstd.EncoderLevel{
+		-1: zstd.SpeedDefault,
+		1:  zstd.SpeedFastest,
+		2:  zstd.SpeedFastest,
+		3:  zstd.SpeedFastest,
+		4:  zstd.SpeedDefault,
+		5:  zstd.SpeedDefault,
+		6:  zstd.SpeedDefault,
+		7:  zstd.SpeedBetterCompression,
+		8:  zstd.SpeedBetterCompression,
+		9:  zstd.SpeedBestCompression,
+	}
+	level, ok := levels[compressionLevel]
+	if !ok {
+		return nil, ErrInvalidCompressionLevel
+	}
+	zstdWriter, err := zstd.NewWriter(output,
+		zstd.WithEncoderLevel(level),
+		zstd.WithEncoderConcurrency(runtime.GOMAXPROCS(-1)))
+	if err != nil {
+		return nil, err
+	}
+	return zstdWriter, nil
+}
+
 func createTarArchive(files []string, output io.WriteCloser) error {
 	archive := tar.NewWriter(output)
 	defer archive.Close()
@@ -410,6 +475,59 @@ func createTarArchive(files []string, output io.WriteCloser) error {
 	return nil
 }
 
+// createDeterministicTarArchive creates a tar archive that only depends on
+// the name, permissions and content of files, so that the same inputs always
+// produce the same archive.
+func createDeterministicTarArchive(files []string, output io.WriteCloser, mtime time.Time) error {
+	archive := tar.NewWriter(output)
+	defer archive.Close()
+
+	sorted := append([]string{}, files...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return filepath.Base(sorted[i]) < filepath.Base(sorted[j])
+	})
+
+	for _, path := range sorted {
+		if err := addDeterministicTarEntry(archive, path, mtime); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func addDeterministicTarEntry(archive *tar.Writer, path string, mtime time.Time) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("Unable to read file %s: %s", path, err)
+	}
+	defer file.Close()
+
+	fi, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("Unable to get fileinfo for %s: %s", path, err)
+	}
+	if !fi.Mode().IsRegular() {
+		return fmt.Errorf("Unable to add %s to a deterministic archive: not a regular file", path)
+	}
+
+	header := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     fi.Name(),
+		Size:     fi.Size(),
+		Mode:     int64(fi.Mode().Perm()),
+		ModTime:  mtime,
+		Format:   tar.FormatGNU,
+	}
+	if err := archive.WriteHeader(header); err != nil {
+		return fmt.Errorf("Failed to write tar header for %s: %s", path, err)
+	}
+
+	if _, err := io.Copy(archive, file); err != nil {
+		return fmt.Errorf("Failed to copy %s data to archive: %s", path, err)
+	}
+	return nil
+}
+
 func createZipArchive(files []string, output io.WriteCloser) error {
 	archive := zip.NewWriter(output)
 	defer archive.Close()
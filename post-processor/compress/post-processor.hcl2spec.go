@@ -21,6 +21,8 @@ type FlatConfig struct {
 	OutputPath          *string           `mapstructure:"output" cty:"output" hcl:"output"`
 	Format              *string           `mapstructure:"format" cty:"format" hcl:"format"`
 	CompressionLevel    *int              `mapstructure:"compression_level" cty:"compression_level" hcl:"compression_level"`
+	Deterministic       *bool             `mapstructure:"deterministic" cty:"deterministic" hcl:"deterministic"`
+	Mtime               *string           `mapstructure:"mtime" cty:"mtime" hcl:"mtime"`
 	Archive             *string           `cty:"archive" hcl:"archive"`
 	Algorithm           *string           `cty:"algorithm" hcl:"algorithm"`
 }
@@ -48,6 +50,8 @@ func (*FlatConfig) HCL2Spec() map[string]hcldec.Spec {
 		"output":                     &hcldec.AttrSpec{Name: "output", Type: cty.String, Required: false},
 		"format":                     &hcldec.AttrSpec{Name: "format", Type: cty.String, Required: false},
 		"compression_level":          &hcldec.AttrSpec{Name: "compression_level", Type: cty.Number, Required: false},
+		"deterministic":              &hcldec.AttrSpec{Name: "deterministic", Type: cty.Bool, Required: false},
+		"mtime":                      &hcldec.AttrSpec{Name: "mtime", Type: cty.String, Required: false},
 		"archive":                    &hcldec.AttrSpec{Name: "archive", Type: cty.String, Required: false},
 		"algorithm":                  &hcldec.AttrSpec{Name: "algorithm", Type: cty.String, Required: false},
 	}
@@ -6,6 +6,7 @@ package compress
 import (
 	"archive/tar"
 	"archive/zip"
+	"bytes"
 	"compress/gzip"
 	"context"
 	"fmt"
@@ -13,11 +14,13 @@ import (
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/dsnet/compress/bzip2"
 	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
 	"github.com/hashicorp/packer-plugin-sdk/template"
 	"github.com/hashicorp/packer/builder/file"
+	"github.com/klauspost/compress/zstd"
 	"github.com/pierrec/lz4/v4"
 )
 
@@ -61,6 +64,16 @@ func TestDetectFilename(t *testing.T) {
 	if lotsOfDots.Algorithm != "lz4" {
 		t.Error("Expected to find lz4 algorithm setting")
 	}
+
+	// Test .tar.zst
+	tarZst := Config{OutputPath: "test.tar.zst"}
+	tarZst.detectFromFilename()
+	if tarZst.Archive != "tar" {
+		t.Error("Expected to find tar archive setting")
+	}
+	if tarZst.Algorithm != "zstd" {
+		t.Error("Expected to find zstd algorithm setting")
+	}
 }
 
 const expectedFileContents = "Hello world!"
@@ -236,6 +249,27 @@ func TestArchive(t *testing.T) {
 			lz4Reader := lz4.NewReader(archive)
 			return io.ReadAll(lz4Reader)
 		},
+		"zst": func(archive *os.File) ([]byte, error) {
+			zstdReader, err := zstd.NewReader(archive)
+			if err != nil {
+				return nil, err
+			}
+			defer zstdReader.Close()
+			return io.ReadAll(zstdReader)
+		},
+		"tar.zst": func(archive *os.File) ([]byte, error) {
+			zstdReader, err := zstd.NewReader(archive)
+			if err != nil {
+				return nil, err
+			}
+			defer zstdReader.Close()
+			tarReader := tar.NewReader(zstdReader)
+			_, err = tarReader.Next()
+			if err != nil {
+				return nil, err
+			}
+			return io.ReadAll(tarReader)
+		},
 	}
 
 	tmpArchiveFile := "temp-archive-package"
@@ -289,3 +323,82 @@ func TestArchive(t *testing.T) {
 	}
 
 }
+
+func TestDeterministicTar(t *testing.T) {
+	build := func(output string) []byte {
+		config := fmt.Sprintf(`
+		{
+			"post-processors": [
+				{
+					"type": "compress",
+					"output": "%s",
+					"deterministic": true,
+					"mtime": "2021-01-02T03:04:05Z"
+				}
+			]
+		}
+		`, output)
+
+		artifact := testArchive(t, config)
+		defer artifact.Destroy()
+
+		b, err := os.ReadFile(output)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+
+	first := build("deterministic-1.tar.zst")
+	// Make sure the input file gets a different modification time.
+	time.Sleep(10 * time.Millisecond)
+	second := build("deterministic-2.tar.zst")
+	if !bytes.Equal(first, second) {
+		t.Fatal("Expected identical inputs to produce identical archives")
+	}
+
+	zstdReader, err := zstd.NewReader(bytes.NewReader(first))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zstdReader.Close()
+	header, err := tar.NewReader(zstdReader).Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !header.ModTime.Equal(time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)) {
+		t.Errorf("Unexpected mtime %s", header.ModTime)
+	}
+	if header.Uid != 0 || header.Gid != 0 || header.Uname != "" || header.Gname != "" {
+		t.Errorf("Expected owner to be zeroed, found %d:%d (%s:%s)",
+			header.Uid, header.Gid, header.Uname, header.Gname)
+	}
+}
+
+func TestDeterministicConfigure(t *testing.T) {
+	tc := map[string]struct {
+		config  map[string]interface{}
+		wantErr bool
+	}{
+		"tar": {
+			config: map[string]interface{}{"output": "out.tar.gz", "deterministic": true},
+		},
+		"zip": {
+			config:  map[string]interface{}{"output": "out.zip", "deterministic": true},
+			wantErr: true,
+		},
+		"bad mtime": {
+			config:  map[string]interface{}{"output": "out.tar", "deterministic": true, "mtime": "yesterday"},
+			wantErr: true,
+		},
+	}
+	for name, tt := range tc {
+		t.Run(name, func(t *testing.T) {
+			var p PostProcessor
+			err := p.Configure(tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Configure() error = %v, wantErr %t", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,274 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+//go:generate packer-sdc mapstructure-to-hcl2 -type Config
+
+package sign
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/hashicorp/packer-plugin-sdk/common"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-sdk/template/config"
+	"github.com/hashicorp/packer-plugin-sdk/template/interpolate"
+)
+
+// Supported key types.
+const (
+	KeyTypeOpenPGP  = "openpgp"
+	KeyTypeSSH      = "ssh"
+	KeyTypeMinisign = "minisign"
+)
+
+// Modes of the post-processor.
+const (
+	ModeSign   = "sign"
+	ModeVerify = "verify"
+)
+
+type Config struct {
+	common.PackerConfig `mapstructure:",squash"`
+
+	KeyType        string `mapstructure:"key_type"`
+	Mode           string `mapstructure:"mode"`
+	PrivateKeyFile string `mapstructure:"private_key_file"`
+	PrivateKey     string `mapstructure:"private_key"`
+	Passphrase     string `mapstructure:"passphrase"`
+	PublicKeyFile  string `mapstructure:"public_key_file"`
+	PublicKey      string `mapstructure:"public_key"`
+	Armor          bool   `mapstructure:"armor"`
+	Namespace      string `mapstructure:"namespace"`
+	TrustedComment string `mapstructure:"trusted_comment"`
+	OutputPath     string `mapstructure:"output"`
+
+	ctx interpolate.Context
+}
+
+type PostProcessor struct {
+	config Config
+
+	signer   signer
+	verifier verifier
+}
+
+func (p *PostProcessor) ConfigSpec() hcldec.ObjectSpec { return p.config.FlatMapstructure().HCL2Spec() }
+
+func (p *PostProcessor) Configure(raws ...interface{}) error {
+	err := config.Decode(&p.config, &config.DecodeOpts{
+		PluginType:         "sign",
+		Interpolate:        true,
+		InterpolateContext: &p.config.ctx,
+		InterpolateFilter: &interpolate.RenderFilter{
+			Exclude: []string{"output"},
+		},
+	}, raws...)
+	if err != nil {
+		return err
+	}
+	errs := new(packersdk.MultiError)
+
+	if p.config.Mode == "" {
+		p.config.Mode = ModeSign
+	}
+	if p.config.Mode != ModeSign && p.config.Mode != ModeVerify {
+		errs = packersdk.MultiErrorAppend(errs,
+			fmt.Errorf("Unrecognized mode: %s, expected %q or %q", p.config.Mode, ModeSign, ModeVerify))
+	}
+
+	if p.config.Namespace == "" {
+		p.config.Namespace = "file"
+	}
+
+	switch p.config.KeyType {
+	case KeyTypeOpenPGP:
+		if p.config.OutputPath == "" && p.config.Armor {
+			p.config.OutputPath = "{{.File}}.asc"
+		}
+	case KeyTypeSSH:
+	case KeyTypeMinisign:
+		if p.config.OutputPath == "" {
+			p.config.OutputPath = "{{.File}}.minisig"
+		}
+	case "":
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("key_type must be specified"))
+	default:
+		errs = packersdk.MultiErrorAppend(errs,
+			fmt.Errorf("Unrecognized key_type: %s, expected one of %q, %q or %q",
+				p.config.KeyType, KeyTypeOpenPGP, KeyTypeSSH, KeyTypeMinisign))
+	}
+
+	if p.config.OutputPath == "" {
+		p.config.OutputPath = "{{.File}}.sig"
+	}
+
+	if err = interpolate.Validate(p.config.OutputPath, &p.config.ctx); err != nil {
+		errs = packersdk.MultiErrorAppend(
+			errs, fmt.Errorf("Error parsing target template: %s", err))
+	}
+
+	if len(errs.Errors) > 0 {
+		return errs
+	}
+
+	switch p.config.Mode {
+	case ModeSign:
+		key, err := readKey("private_key", p.config.PrivateKey, p.config.PrivateKeyFile)
+		if err != nil {
+			return err
+		}
+		p.signer, err = newSigner(&p.config, key)
+		if err != nil {
+			return fmt.Errorf("Error loading private key: %s", err)
+		}
+	case ModeVerify:
+		key, err := readKey("public_key", p.config.PublicKey, p.config.PublicKeyFile)
+		if err != nil {
+			return err
+		}
+		p.verifier, err = newVerifier(&p.config, key)
+		if err != nil {
+			return fmt.Errorf("Error loading public key: %s", err)
+		}
+	}
+
+	return nil
+}
+
+// readKey returns the key set either inline or through a file.
+func readKey(name, inline, file string) ([]byte, error) {
+	switch {
+	case inline != "" && file != "":
+		return nil, fmt.Errorf("only one of %s or %s_file can be set", name, name)
+	case inline != "":
+		return []byte(inline), nil
+	case file != "":
+		key, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read %s_file: %s", name, err)
+		}
+		return key, nil
+	default:
+		return nil, fmt.Errorf("one of %s or %s_file must be set", name, name)
+	}
+}
+
+func (p *PostProcessor) PostProcess(ctx context.Context, ui packersdk.Ui, artifact packersdk.Artifact) (packersdk.Artifact, bool, bool, error) {
+	var generatedData map[interface{}]interface{}
+	stateData := artifact.State("generated_data")
+	if stateData != nil {
+		// Make sure it's not a nil map so we can assign to it later.
+		generatedData = stateData.(map[interface{}]interface{})
+	}
+	// If stateData has a nil map generatedData will be nil
+	// and we need to make sure it's not
+	if generatedData == nil {
+		generatedData = make(map[interface{}]interface{})
+	}
+	generatedData["BuildName"] = p.config.PackerBuildName
+	generatedData["BuilderType"] = p.config.PackerBuilderType
+
+	files := artifact.Files()
+	signatures := make([]string, len(files))
+	for i, file := range files {
+		generatedData["File"] = file
+		p.config.ctx.Data = generatedData
+		signature, err := interpolate.Render(p.config.OutputPath, &p.config.ctx)
+		if err != nil {
+			return nil, false, true, fmt.Errorf("Error interpolating output value: %s", err)
+		}
+		signatures[i] = signature
+	}
+
+	if p.config.Mode == ModeVerify {
+		if err := p.verify(ui, files, signatures); err != nil {
+			return nil, false, true, err
+		}
+		// The input artifact is passed through untouched.
+		return artifact, true, true, nil
+	}
+
+	newartifact := NewArtifact(artifact.Files())
+	for i, file := range files {
+		if err := ctx.Err(); err != nil {
+			return nil, false, true, err
+		}
+		ui.Say(fmt.Sprintf("Signing %s with %s key", file, p.config.KeyType))
+		if err := p.sign(file, signatures[i]); err != nil {
+			return nil, false, true, err
+		}
+		newartifact.files = append(newartifact.files, signatures[i])
+	}
+
+	// sets keep and forceOverride to true because we don't want to accidentally
+	// delete the very artifact we're signing.
+	return newartifact, true, true, nil
+}
+
+func (p *PostProcessor) sign(file, signature string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return fmt.Errorf("unable to open file %s: %s", file, err)
+	}
+	defer f.Close()
+
+	sig, err := p.signer.Sign(f, filepath.Base(file))
+	if err != nil {
+		return fmt.Errorf("unable to sign %s: %s", file, err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(signature), os.FileMode(0755)); err != nil {
+		return fmt.Errorf("unable to create dir: %s", err)
+	}
+	if err := os.WriteFile(signature, sig, os.FileMode(0644)); err != nil {
+		return fmt.Errorf("unable to write signature %s: %s", signature, err)
+	}
+	return nil
+}
+
+// verify checks the signature of each file. Files that are the signature of
+// another file, as when the artifact comes from a sign post-processor, are
+// not checked themselves.
+func (p *PostProcessor) verify(ui packersdk.Ui, files, signatures []string) error {
+	isSignature := map[string]bool{}
+	for _, signature := range signatures {
+		isSignature[filepath.Clean(signature)] = true
+	}
+
+	errs := new(packersdk.MultiError)
+	for i, file := range files {
+		if isSignature[filepath.Clean(file)] {
+			continue
+		}
+		ui.Say(fmt.Sprintf("Verifying signature of %s", file))
+		if err := p.verifyFile(file, signatures[i]); err != nil {
+			errs = packersdk.MultiErrorAppend(errs, err)
+		}
+	}
+	if len(errs.Errors) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (p *PostProcessor) verifyFile(file, signature string) error {
+	sig, err := os.ReadFile(signature)
+	if err != nil {
+		return fmt.Errorf("unable to read signature of %s: %s", file, err)
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return fmt.Errorf("unable to open file %s: %s", file, err)
+	}
+	defer f.Close()
+
+	if err := p.verifier.Verify(f, sig); err != nil {
+		return fmt.Errorf("invalid signature %s for %s: %s", signature, file, err)
+	}
+	return nil
+}
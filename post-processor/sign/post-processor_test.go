@@ -0,0 +1,235 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package sign
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"golang.org/x/crypto/ssh"
+)
+
+const expectedFileContents = "Hello world!"
+
+func TestSignAndVerify(t *testing.T) {
+	sshPrivate, sshPublic := testSSHKey(t)
+	pgpPrivate, pgpPublic := testOpenPGPKey(t)
+
+	tc := map[string]struct {
+		sign      map[string]interface{}
+		verify    map[string]interface{}
+		signature string
+	}{
+		"ssh": {
+			sign:      map[string]interface{}{"key_type": "ssh", "private_key": sshPrivate},
+			verify:    map[string]interface{}{"key_type": "ssh", "public_key": sshPublic},
+			signature: "package.txt.sig",
+		},
+		"openpgp": {
+			sign:      map[string]interface{}{"key_type": "openpgp", "private_key": pgpPrivate},
+			verify:    map[string]interface{}{"key_type": "openpgp", "public_key": pgpPublic},
+			signature: "package.txt.sig",
+		},
+		"openpgp armored": {
+			sign:      map[string]interface{}{"key_type": "openpgp", "private_key": pgpPrivate, "armor": true},
+			verify:    map[string]interface{}{"key_type": "openpgp", "public_key": pgpPublic, "armor": true},
+			signature: "package.txt.asc",
+		},
+		"minisign": {
+			sign: map[string]interface{}{
+				"key_type":         "minisign",
+				"private_key_file": "test-fixtures/minisign.key",
+				"passphrase":       "packer",
+			},
+			verify: map[string]interface{}{
+				"key_type":        "minisign",
+				"public_key_file": "test-fixtures/minisign.pub",
+			},
+			signature: "package.txt.minisig",
+		},
+		"minisign unencrypted": {
+			sign: map[string]interface{}{
+				"key_type":         "minisign",
+				"private_key_file": "test-fixtures/minisign-unencrypted.key",
+			},
+			verify: map[string]interface{}{
+				"key_type":        "minisign",
+				"public_key_file": "test-fixtures/minisign-unencrypted.pub",
+			},
+			signature: "package.txt.minisig",
+		},
+	}
+
+	for name, tt := range tc {
+		t.Run(name, func(t *testing.T) {
+			file := testFile(t)
+
+			signed := testPostProcess(t, tt.sign, NewArtifact([]string{file}))
+			signature := filepath.Join(filepath.Dir(file), tt.signature)
+			if want := []string{file, signature}; !equalFiles(signed.Files(), want) {
+				t.Fatalf("Unexpected artifact files %v, expected %v", signed.Files(), want)
+			}
+
+			// The signature file is part of the artifact and is skipped.
+			verified := testPostProcess(t, tt.verify, signed)
+			if verified != signed {
+				t.Fatal("Expected the input artifact to be passed through")
+			}
+
+			if err := os.WriteFile(file, []byte("tampered"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			var p PostProcessor
+			if err := p.Configure(tt.verify); err != nil {
+				t.Fatal(err)
+			}
+			_, _, _, err := p.PostProcess(context.Background(), packersdk.TestUi(t), signed)
+			if err == nil {
+				t.Fatal("Expected the verification of a tampered file to fail")
+			}
+		})
+	}
+}
+
+func TestVerifyMissingSignature(t *testing.T) {
+	_, sshPublic := testSSHKey(t)
+
+	var p PostProcessor
+	if err := p.Configure(map[string]interface{}{
+		"key_type":   "ssh",
+		"mode":       "verify",
+		"public_key": sshPublic,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	_, _, _, err := p.PostProcess(context.Background(), packersdk.TestUi(t), NewArtifact([]string{testFile(t)}))
+	if err == nil {
+		t.Fatal("Expected the verification of a file without signature to fail")
+	}
+}
+
+func TestConfigure(t *testing.T) {
+	tc := map[string]map[string]interface{}{
+		"missing key type": {"private_key": "key"},
+		"unknown key type": {"key_type": "x509", "private_key": "key"},
+		"unknown mode":     {"key_type": "ssh", "mode": "check", "private_key": "key"},
+		"missing key":      {"key_type": "ssh"},
+		"two keys":         {"key_type": "ssh", "private_key": "key", "private_key_file": "key.pem"},
+		"invalid key":      {"key_type": "ssh", "private_key": "key"},
+		"minisign without passphrase": {
+			"key_type":         "minisign",
+			"private_key_file": "test-fixtures/minisign.key",
+		},
+		"minisign wrong passphrase": {
+			"key_type":         "minisign",
+			"private_key_file": "test-fixtures/minisign.key",
+			"passphrase":       "not packer",
+		},
+	}
+	for name, config := range tc {
+		t.Run(name, func(t *testing.T) {
+			var p PostProcessor
+			if err := p.Configure(config); err == nil {
+				t.Fatal("Expected Configure to fail")
+			}
+		})
+	}
+}
+
+// Test Helpers
+
+func testPostProcess(t *testing.T, config map[string]interface{}, artifact packersdk.Artifact) packersdk.Artifact {
+	if _, ok := config["public_key"]; ok {
+		config["mode"] = "verify"
+	}
+	if _, ok := config["public_key_file"]; ok {
+		config["mode"] = "verify"
+	}
+
+	var p PostProcessor
+	if err := p.Configure(config); err != nil {
+		t.Fatalf("Configure: %s", err)
+	}
+	out, keep, forceOverride, err := p.PostProcess(context.Background(), packersdk.TestUi(t), artifact)
+	if err != nil {
+		t.Fatalf("PostProcess: %s", err)
+	}
+	if !keep || !forceOverride {
+		t.Fatal("Expected the input artifact to be kept")
+	}
+	return out
+}
+
+func testFile(t *testing.T) string {
+	file := filepath.Join(t.TempDir(), "package.txt")
+	if err := os.WriteFile(file, []byte(expectedFileContents), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func testSSHKey(t *testing.T) (string, string) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := ssh.MarshalPrivateKey(priv, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sshPub, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(block)), string(ssh.MarshalAuthorizedKey(sshPub))
+}
+
+func testOpenPGPKey(t *testing.T) (string, string) {
+	entity, err := openpgp.NewEntity("packer", "", "packer@example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var private, public bytes.Buffer
+	w, err := armor.Encode(&private, openpgp.PrivateKeyType, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := entity.SerializePrivate(w, nil); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	w, err = armor.Encode(&public, openpgp.PublicKeyType, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := entity.Serialize(w); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	return private.String(), public.String()
+}
+
+func equalFiles(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if filepath.Clean(a[i]) != filepath.Clean(b[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,287 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package sign
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"aead.dev/minisign"
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+	"golang.org/x/crypto/scrypt"
+)
+
+// signer creates the detached signature of a message.
+type signer interface {
+	Sign(message io.Reader, name string) ([]byte, error)
+}
+
+// verifier checks the detached signature of a message.
+type verifier interface {
+	Verify(message io.Reader, signature []byte) error
+}
+
+func newSigner(c *Config, key []byte) (signer, error) {
+	switch c.KeyType {
+	case KeyTypeOpenPGP:
+		return newOpenPGPSigner(key, c.Passphrase, c.Armor)
+	case KeyTypeSSH:
+		return newSSHSigner(key, c.Passphrase, c.Namespace)
+	case KeyTypeMinisign:
+		return newMinisignSigner(key, c.Passphrase, c.TrustedComment)
+	}
+	return nil, fmt.Errorf("unsupported key type %q", c.KeyType)
+}
+
+func newVerifier(c *Config, key []byte) (verifier, error) {
+	switch c.KeyType {
+	case KeyTypeOpenPGP:
+		return newOpenPGPVerifier(key)
+	case KeyTypeSSH:
+		return newSSHVerifier(key, c.Namespace)
+	case KeyTypeMinisign:
+		return newMinisignVerifier(key)
+	}
+	return nil, fmt.Errorf("unsupported key type %q", c.KeyType)
+}
+
+type openPGPSigner struct {
+	entity *openpgp.Entity
+	armor  bool
+}
+
+func newOpenPGPSigner(key []byte, passphrase string, armor bool) (*openPGPSigner, error) {
+	keyring, err := readOpenPGPKeyRing(key)
+	if err != nil {
+		return nil, err
+	}
+
+	var entity *openpgp.Entity
+	for _, e := range keyring {
+		if e.PrivateKey != nil {
+			entity = e
+			break
+		}
+	}
+	if entity == nil {
+		return nil, fmt.Errorf("no private key found")
+	}
+
+	keys := []*packet.PrivateKey{entity.PrivateKey}
+	for _, subkey := range entity.Subkeys {
+		if subkey.PrivateKey != nil {
+			keys = append(keys, subkey.PrivateKey)
+		}
+	}
+	for _, k := range keys {
+		if !k.Encrypted {
+			continue
+		}
+		if passphrase == "" {
+			return nil, fmt.Errorf("the private key is encrypted, a passphrase is required")
+		}
+		if err := k.Decrypt([]byte(passphrase)); err != nil {
+			return nil, fmt.Errorf("unable to decrypt the private key: %s", err)
+		}
+	}
+
+	return &openPGPSigner{entity: entity, armor: armor}, nil
+}
+
+func (s *openPGPSigner) Sign(message io.Reader, _ string) ([]byte, error) {
+	var sig bytes.Buffer
+	var err error
+	if s.armor {
+		err = openpgp.ArmoredDetachSign(&sig, s.entity, message, nil)
+	} else {
+		err = openpgp.DetachSign(&sig, s.entity, message, nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if s.armor {
+		sig.WriteString("\n")
+	}
+	return sig.Bytes(), nil
+}
+
+type openPGPVerifier struct {
+	keyring openpgp.EntityList
+}
+
+func newOpenPGPVerifier(key []byte) (*openPGPVerifier, error) {
+	keyring, err := readOpenPGPKeyRing(key)
+	if err != nil {
+		return nil, err
+	}
+	return &openPGPVerifier{keyring: keyring}, nil
+}
+
+func (v *openPGPVerifier) Verify(message io.Reader, signature []byte) error {
+	var err error
+	if isArmored(signature) {
+		_, err = openpgp.CheckArmoredDetachedSignature(v.keyring, message, bytes.NewReader(signature), nil)
+	} else {
+		_, err = openpgp.CheckDetachedSignature(v.keyring, message, bytes.NewReader(signature), nil)
+	}
+	return err
+}
+
+// readOpenPGPKeyRing reads an armored or a binary OpenPGP key ring.
+func readOpenPGPKeyRing(key []byte) (openpgp.EntityList, error) {
+	if isArmored(key) {
+		return openpgp.ReadArmoredKeyRing(bytes.NewReader(key))
+	}
+	return openpgp.ReadKeyRing(bytes.NewReader(key))
+}
+
+func isArmored(b []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(b), []byte("-----BEGIN "))
+}
+
+type minisignSigner struct {
+	key            minisign.PrivateKey
+	trustedComment string
+}
+
+func newMinisignSigner(key []byte, passphrase, trustedComment string) (*minisignSigner, error) {
+	key = bytes.TrimSpace(key)
+	unencrypted, err := isUnencryptedMinisignKey(key)
+	if err != nil {
+		return nil, err
+	}
+	if unencrypted {
+		// The minisign package only reads encrypted keys.
+		if key, err = encryptMinisignKey(key); err != nil {
+			return nil, err
+		}
+	} else if passphrase == "" {
+		return nil, fmt.Errorf("a passphrase is required to decrypt an encrypted minisign key")
+	}
+	privateKey, err := minisign.DecryptKey(passphrase, key)
+	if err != nil {
+		return nil, err
+	}
+	return &minisignSigner{key: privateKey, trustedComment: trustedComment}, nil
+}
+
+// A minisign private key is, after its untrusted comment, the base64 encoding
+// of:
+//
+//	signature algorithm (2) | kdf algorithm (2) | checksum algorithm (2) |
+//	kdf salt (32) | kdf ops limit (8) | kdf mem limit (8) |
+//	key id (8) | Ed25519 key (64) | checksum (32)
+//
+// The last 104 bytes are encrypted with a scrypt key stream, unless the kdf
+// algorithm is none, like for the keys generated with `minisign -G -W`.
+const (
+	minisignKeySize       = 158
+	minisignKDFOffset     = 2
+	minisignSaltOffset    = 6
+	minisignOpsOffset     = 38
+	minisignMemOffset     = 46
+	minisignSecretsOffset = 54
+)
+
+// decodeMinisignKey returns the bytes of the private key.
+func decodeMinisignKey(key []byte) ([]byte, error) {
+	lines := strings.Split(string(key), "\n")
+	encoded := strings.TrimSpace(lines[len(lines)-1])
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(decoded) != minisignKeySize {
+		return nil, fmt.Errorf("invalid minisign private key")
+	}
+	return decoded, nil
+}
+
+// isUnencryptedMinisignKey tells whether key was generated without a
+// password.
+func isUnencryptedMinisignKey(key []byte) (bool, error) {
+	decoded, err := decodeMinisignKey(key)
+	if err != nil {
+		return false, err
+	}
+	return decoded[minisignKDFOffset] == 0 && decoded[minisignKDFOffset+1] == 0, nil
+}
+
+// encryptMinisignKey encrypts the unencrypted key with an empty password, so
+// that minisign.DecryptKey reads it. The scrypt cost is the lowest minisign
+// accepts, as the key is only encrypted to be decrypted right away: these ops
+// and mem limits are converted to N = 2^10, r = 8 and p = 1, like libsodium
+// does.
+func encryptMinisignKey(key []byte) ([]byte, error) {
+	decoded, err := decodeMinisignKey(key)
+	if err != nil {
+		return nil, err
+	}
+	const (
+		ops = 1 << 15
+		mem = 1 << 21
+	)
+	salt := decoded[minisignSaltOffset:minisignOpsOffset]
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	keystream, err := scrypt.Key(nil, salt, 1<<10, 8, 1, minisignKeySize-minisignSecretsOffset)
+	if err != nil {
+		return nil, err
+	}
+	copy(decoded[minisignKDFOffset:], "Sc")
+	binary.LittleEndian.PutUint64(decoded[minisignOpsOffset:], ops)
+	binary.LittleEndian.PutUint64(decoded[minisignMemOffset:], mem)
+	for i, k := range keystream {
+		decoded[minisignSecretsOffset+i] ^= k
+	}
+	return []byte(base64.StdEncoding.EncodeToString(decoded)), nil
+}
+
+func (s *minisignSigner) Sign(message io.Reader, name string) ([]byte, error) {
+	r := minisign.NewReader(message)
+	if _, err := io.Copy(io.Discard, r); err != nil {
+		return nil, err
+	}
+
+	trustedComment := s.trustedComment
+	if trustedComment == "" {
+		// Same trusted comment as the minisign command.
+		trustedComment = fmt.Sprintf("timestamp:%d\tfile:%s\thashed", time.Now().Unix(), name)
+	}
+	untrustedComment := fmt.Sprintf("signature from minisign secret key %X", s.key.ID())
+
+	sig := r.SignWithComments(s.key, trustedComment, untrustedComment)
+	if !bytes.HasSuffix(sig, []byte("\n")) {
+		sig = append(sig, '\n')
+	}
+	return sig, nil
+}
+
+type minisignVerifier struct {
+	key minisign.PublicKey
+}
+
+func newMinisignVerifier(key []byte) (*minisignVerifier, error) {
+	var publicKey minisign.PublicKey
+	if err := publicKey.UnmarshalText(bytes.TrimSpace(key)); err != nil {
+		return nil, err
+	}
+	return &minisignVerifier{key: publicKey}, nil
+}
+
+func (v *minisignVerifier) Verify(message io.Reader, signature []byte) error {
+	r := minisign.NewReader(message)
+	if _, err := io.Copy(io.Discard, r); err != nil {
+		return err
+	}
+	if !r.Verify(v.key, bytes.TrimSpace(signature)) {
+		return fmt.Errorf("signature verification failed")
+	}
+	return nil
+}
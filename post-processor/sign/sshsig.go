@@ -0,0 +1,188 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package sign
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// SSH signatures follow the format of `ssh-keygen -Y sign`, described in
+// https://github.com/openssh/openssh-portable/blob/master/PROTOCOL.sshsig
+const (
+	sshSigMagic   = "SSHSIG"
+	sshSigVersion = 1
+	sshSigBegin   = "-----BEGIN SSH SIGNATURE-----"
+	sshSigEnd     = "-----END SSH SIGNATURE-----"
+	// sshSigLineLength is the length of the base64 lines of armored
+	// signatures, as written by ssh-keygen.
+	sshSigLineLength = 70
+)
+
+// sshSigBlob is the content of an SSH signature, after the magic preamble.
+type sshSigBlob struct {
+	Version       uint32
+	PublicKey     []byte
+	Namespace     string
+	Reserved      string
+	HashAlgorithm string
+	Signature     []byte
+}
+
+// sshSigSignedData is what is actually signed, after the magic preamble.
+type sshSigSignedData struct {
+	Namespace     string
+	Reserved      string
+	HashAlgorithm string
+	Hash          []byte
+}
+
+type sshSigner struct {
+	signer    ssh.Signer
+	namespace string
+}
+
+func newSSHSigner(key []byte, passphrase, namespace string) (*sshSigner, error) {
+	var signer ssh.Signer
+	var err error
+	if passphrase != "" {
+		signer, err = ssh.ParsePrivateKeyWithPassphrase(key, []byte(passphrase))
+	} else {
+		signer, err = ssh.ParsePrivateKey(key)
+	}
+	var missing *ssh.PassphraseMissingError
+	if errors.As(err, &missing) {
+		return nil, fmt.Errorf("the private key is encrypted, a passphrase is required")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &sshSigner{signer: signer, namespace: namespace}, nil
+}
+
+func (s *sshSigner) Sign(message io.Reader, _ string) ([]byte, error) {
+	const hashAlgorithm = "sha512"
+	h := sha512.New()
+	if _, err := io.Copy(h, message); err != nil {
+		return nil, err
+	}
+
+	signed := sshSigData(sshSigSignedData{
+		Namespace:     s.namespace,
+		HashAlgorithm: hashAlgorithm,
+		Hash:          h.Sum(nil),
+	})
+
+	var sig *ssh.Signature
+	var err error
+	algSigner, ok := s.signer.(ssh.AlgorithmSigner)
+	if ok && s.signer.PublicKey().Type() == ssh.KeyAlgoRSA {
+		// ssh-keygen refuses SHA-1 RSA signatures.
+		sig, err = algSigner.SignWithAlgorithm(rand.Reader, signed, ssh.KeyAlgoRSASHA512)
+	} else {
+		sig, err = s.signer.Sign(rand.Reader, signed)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	blob := append([]byte(sshSigMagic), ssh.Marshal(sshSigBlob{
+		Version:       sshSigVersion,
+		PublicKey:     s.signer.PublicKey().Marshal(),
+		Namespace:     s.namespace,
+		HashAlgorithm: hashAlgorithm,
+		Signature:     ssh.Marshal(sig),
+	})...)
+
+	out := &strings.Builder{}
+	out.WriteString(sshSigBegin + "\n")
+	encoded := base64.StdEncoding.EncodeToString(blob)
+	for len(encoded) > sshSigLineLength {
+		out.WriteString(encoded[:sshSigLineLength] + "\n")
+		encoded = encoded[sshSigLineLength:]
+	}
+	out.WriteString(encoded + "\n")
+	out.WriteString(sshSigEnd + "\n")
+	return []byte(out.String()), nil
+}
+
+type sshVerifier struct {
+	key       ssh.PublicKey
+	namespace string
+}
+
+func newSSHVerifier(key []byte, namespace string) (*sshVerifier, error) {
+	pub, _, _, _, err := ssh.ParseAuthorizedKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return &sshVerifier{key: pub, namespace: namespace}, nil
+}
+
+func (v *sshVerifier) Verify(message io.Reader, signature []byte) error {
+	armored := strings.TrimSpace(string(signature))
+	if !strings.HasPrefix(armored, sshSigBegin) || !strings.HasSuffix(armored, sshSigEnd) {
+		return fmt.Errorf("not an SSH signature")
+	}
+	armored = strings.TrimSuffix(strings.TrimPrefix(armored, sshSigBegin), sshSigEnd)
+	raw, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(armored), ""))
+	if err != nil {
+		return fmt.Errorf("malformed SSH signature: %s", err)
+	}
+	if !bytes.HasPrefix(raw, []byte(sshSigMagic)) {
+		return fmt.Errorf("malformed SSH signature: missing %s preamble", sshSigMagic)
+	}
+
+	var blob sshSigBlob
+	if err := ssh.Unmarshal(raw[len(sshSigMagic):], &blob); err != nil {
+		return fmt.Errorf("malformed SSH signature: %s", err)
+	}
+	if blob.Version != sshSigVersion {
+		return fmt.Errorf("unsupported SSH signature version %d", blob.Version)
+	}
+	if blob.Namespace != v.namespace {
+		return fmt.Errorf("signature namespace %q does not match %q", blob.Namespace, v.namespace)
+	}
+	if !bytes.Equal(blob.PublicKey, v.key.Marshal()) {
+		return fmt.Errorf("signature was made with another key")
+	}
+
+	var h hash.Hash
+	switch blob.HashAlgorithm {
+	case "sha256":
+		h = sha256.New()
+	case "sha512":
+		h = sha512.New()
+	default:
+		return fmt.Errorf("unsupported hash algorithm %q", blob.HashAlgorithm)
+	}
+	if _, err := io.Copy(h, message); err != nil {
+		return err
+	}
+
+	var sig ssh.Signature
+	if err := ssh.Unmarshal(blob.Signature, &sig); err != nil {
+		return fmt.Errorf("malformed SSH signature: %s", err)
+	}
+	return v.key.Verify(sshSigData(sshSigSignedData{
+		Namespace:     blob.Namespace,
+		Reserved:      blob.Reserved,
+		HashAlgorithm: blob.HashAlgorithm,
+		Hash:          h.Sum(nil),
+	}), &sig)
+}
+
+func sshSigData(data sshSigSignedData) []byte {
+	return append([]byte(sshSigMagic), ssh.Marshal(data)...)
+}